@@ -0,0 +1,91 @@
+// Package avatars handles the profile pictures of users.
+//
+// Uploaded images are decoded and re-encoded as square PNG thumbnails in
+// every size of Sizes, which drops any metadata such as the location a photo
+// was taken at. The thumbnails are kept in the blob store, and USER.avatar
+// holds the key they share. Users without an uploaded avatar get an
+// identicon generated from their ID.
+package avatars
+
+import (
+	"brickedup/backend/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Sizes are the widths and heights, in pixels, of the stored thumbnails.
+var Sizes = []int{32, 64, 128, 256}
+
+// DefaultSize is the size served when none is requested.
+const DefaultSize = 128
+
+// MaxBytes is the largest accepted upload, set with AVATAR_MAX_BYTES.
+var MaxBytes = utils.IntFromEnv("AVATAR_MAX_BYTES", 5<<20)
+
+// maxPixels is the most pixels an uploaded image may have, so small files
+// cannot decode to huge images.
+const maxPixels = 40_000_000
+
+// keyPrefix starts the keys of all uploaded avatars in USER.avatar. Other
+// values, such as the placeholder "default.png", mean there is none.
+const keyPrefix = "avatars/"
+
+var (
+	// ErrTooLarge is returned for uploads over MaxBytes or maxPixels.
+	ErrTooLarge = errors.New("image is too large")
+
+	// ErrUnsupportedType is returned for uploads that are not PNG, JPEG or
+	// GIF images.
+	ErrUnsupportedType = errors.New("image must be a PNG, JPEG or GIF")
+
+	// ErrInvalidImage is returned for uploads that cannot be decoded.
+	ErrInvalidImage = errors.New("image cannot be decoded")
+)
+
+// ContentTypes are the accepted types of uploads.
+var ContentTypes = []string{"image/png", "image/jpeg", "image/gif"}
+
+// isUploaded reports whether the value of USER.avatar refers to an
+// uploaded avatar.
+func isUploaded(avatar string) bool {
+	return strings.HasPrefix(avatar, keyPrefix)
+}
+
+// Keys returns the keys of the thumbnails of an avatar in the blob store,
+// given the value of USER.avatar, and none for avatars that were not
+// uploaded.
+func Keys(avatar string) []string {
+	if !isUploaded(avatar) {
+		return nil
+	}
+
+	keys := make([]string, len(Sizes))
+	for i, size := range Sizes {
+		keys[i] = fmt.Sprintf("%s/%d.png", avatar, size)
+	}
+	return keys
+}
+
+// URL returns the path serving the avatar of the user, given the value of
+// their USER.avatar. Uploaded avatars are versioned, so the URL changes
+// with every upload and can be cached for long.
+func URL(userid int, avatar string) string {
+	url := "/avatar?userid=" + strconv.Itoa(userid)
+	if isUploaded(avatar) {
+		url += "&v=" + avatar[strings.LastIndex(avatar, "/")+1:]
+	}
+	return url
+}
+
+// fitSize returns the smallest of Sizes that is at least `size`, or the
+// largest one.
+func fitSize(size int) int {
+	for _, s := range Sizes {
+		if s >= size {
+			return s
+		}
+	}
+	return Sizes[len(Sizes)-1]
+}
@@ -0,0 +1,39 @@
+package avatars
+
+import "testing"
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		avatar string
+		want   string
+	}{
+		{"avatars/1/0123456789abcdef", "/avatar?userid=1&v=0123456789abcdef"},
+		{"default.png", "/avatar?userid=1"},
+		{"", "/avatar?userid=1"},
+	}
+	for _, tt := range tests {
+		if got := URL(1, tt.avatar); got != tt.want {
+			t.Errorf("%q: expected %s, got %s", tt.avatar, tt.want, got)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := Keys("avatars/1/0123456789abcdef")
+	if len(keys) != len(Sizes) || keys[0] != "avatars/1/0123456789abcdef/32.png" {
+		t.Errorf("expected a key per size, got %v", keys)
+	}
+
+	if keys = Keys("default.png"); len(keys) != 0 {
+		t.Errorf("expected no keys for a default avatar, got %v", keys)
+	}
+}
+
+func TestFitSize(t *testing.T) {
+	tests := map[int]int{0: 32, 32: 32, 33: 64, 100: 128, 256: 256, 4096: 256}
+	for size, want := range tests {
+		if got := fitSize(size); got != want {
+			t.Errorf("size %d: expected %d, got %d", size, want, got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package avatars
+
+import (
+	"brickedup/backend/blobs"
+	"bytes"
+	"database/sql"
+	"fmt"
+	"image/png"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// identiconVersion changes whenever identicons are drawn differently, so
+// cached copies are replaced.
+const identiconVersion = "1"
+
+// Avatar is an avatar as served: a PNG image with a tag identifying its
+// content, for caching.
+type Avatar struct {
+	Data     []byte
+	ETag     string
+	Modified time.Time
+
+	// Version is the `v` parameter of URL, empty for identicons.
+	Version string
+}
+
+// Get returns the avatar of the user in the smallest of Sizes that is at
+// least `size` pixels. Users without an uploaded avatar get their
+// identicon. It returns sql.ErrNoRows for unknown users.
+func Get(db *sql.DB, userid int, size int) (*Avatar, error) {
+	size = fitSize(size)
+
+	var avatar sql.NullString
+	err := db.QueryRow(`SELECT avatar FROM USER WHERE id = ?`, userid).Scan(&avatar)
+	if err != nil {
+		return nil, err
+	}
+
+	if isUploaded(avatar.String) {
+		data, modified, err := blobs.Get(fmt.Sprintf("%s/%d.png", avatar.String, size))
+		if err == nil {
+			version := avatar.String[strings.LastIndex(avatar.String, "/")+1:]
+			return &Avatar{
+				Data:     data,
+				ETag:     fmt.Sprintf(`"%s-%d"`, version, size),
+				Modified: modified,
+				Version:  version,
+			}, nil
+		} else if err != blobs.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	var encoded bytes.Buffer
+	err = png.Encode(&encoded, identicon(userid, size))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Avatar{
+		Data: encoded.Bytes(),
+		ETag: fmt.Sprintf(`"identicon%s-%d-%d"`, identiconVersion, userid, size),
+	}, nil
+}
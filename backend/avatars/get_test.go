@@ -0,0 +1,50 @@
+package avatars
+
+import (
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/utils"
+	"bytes"
+	"database/sql"
+	"image/png"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestGet(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+
+	// John Doe only has the placeholder from before uploads
+	generated, err := Get(db, 1, 100)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if generated.Version != "" {
+		t.Errorf("expected an identicon, got version %s", generated.Version)
+	}
+	img, err := png.Decode(bytes.NewReader(generated.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 128 {
+		t.Errorf("expected the next larger size, got %v", img.Bounds())
+	}
+
+	if _, err = Upload(db, 1, testImage(t, 100, 100)); err != nil {
+		t.Fatal(err)
+	}
+
+	uploaded, err := Get(db, 1, 100)
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if uploaded.Version == "" || uploaded.ETag == generated.ETag || uploaded.Modified.IsZero() {
+		t.Errorf("expected the uploaded avatar, got %+v", uploaded)
+	}
+
+	if _, err = Get(db, 42, 64); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows for an unknown user, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package avatars
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+)
+
+// identiconGrid is the number of cells per side of an identicon.
+const identiconGrid = 5
+
+// identiconBackground fills the cells that are not set.
+var identiconBackground = color.NRGBA{0xf0, 0xf0, 0xf0, 0xff}
+
+// identicon draws the generated avatar of the user: a horizontally
+// symmetric pattern of cells in one color, both derived from a hash of the
+// ID, so the same user always gets the same picture.
+func identicon(userid int, size int) *image.NRGBA {
+	hash := sha256.Sum256([]byte("identicon:" + strconv.Itoa(userid)))
+
+	// A saturated color from the first bytes, dark enough to contrast with
+	// the background
+	fg := color.NRGBA{
+		R: 40 + hash[0]%160,
+		G: 40 + hash[1]%160,
+		B: 40 + hash[2]%160,
+		A: 0xff,
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(identiconBackground), image.Point{}, draw.Src)
+
+	// Leave a margin of half a cell around the pattern
+	cell := size / (identiconGrid + 1)
+	offset := (size - cell*identiconGrid) / 2
+
+	half := (identiconGrid + 1) / 2
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < half; col++ {
+			bit := row*half + col
+			if hash[3+bit/8]&(1<<(bit%8)) == 0 {
+				continue
+			}
+
+			for _, c := range []int{col, identiconGrid - 1 - col} {
+				r := image.Rect(offset+c*cell, offset+row*cell, offset+(c+1)*cell, offset+(row+1)*cell)
+				draw.Draw(img, r, image.NewUniform(fg), image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	return img
+}
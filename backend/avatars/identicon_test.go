@@ -0,0 +1,29 @@
+package avatars
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIdenticon(t *testing.T) {
+	first := identicon(1, 64)
+	if first.Bounds().Dx() != 64 || first.Bounds().Dy() != 64 {
+		t.Fatalf("expected 64x64, got %v", first.Bounds())
+	}
+
+	if !bytes.Equal(first.Pix, identicon(1, 64).Pix) {
+		t.Error("expected the same identicon for the same user")
+	}
+	if bytes.Equal(first.Pix, identicon(2, 64).Pix) {
+		t.Error("expected different identicons for different users")
+	}
+
+	// The pattern is mirrored
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 32; x++ {
+			if first.NRGBAAt(x, y) != first.NRGBAAt(63-x, y) {
+				t.Fatalf("identicon is not symmetric at %d,%d", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package avatars
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// Remove deletes the uploaded avatar of the user, who gets their identicon
+// again.
+func Remove(db *sql.DB, userid int) error {
+	var avatar sql.NullString
+	err := db.QueryRow(`SELECT avatar FROM USER WHERE id = ?`, userid).Scan(&avatar)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`UPDATE USER SET avatar = NULL WHERE id = ?`, userid)
+	if err != nil {
+		return err
+	}
+
+	deleteThumbnails(avatar.String)
+	return nil
+}
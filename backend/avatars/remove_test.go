@@ -0,0 +1,40 @@
+package avatars
+
+import (
+	"brickedup/backend/blobs"
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRemove(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	store := blobstest.Temp(t)
+
+	if _, err := Upload(db, 2, testImage(t, 50, 50)); err != nil {
+		t.Fatal(err)
+	}
+	var key string
+	if err := db.QueryRow(`SELECT avatar FROM USER WHERE id = 2`).Scan(&key); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Remove(db, 2); err != nil {
+		t.Fatalf("Remove returned error: %v", err)
+	}
+
+	if _, _, err := store.Get(key + "/64.png"); err != blobs.ErrNotFound {
+		t.Errorf("expected the thumbnails to be deleted, got %v", err)
+	}
+
+	avatar, err := Get(db, 2, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if avatar.Version != "" {
+		t.Errorf("expected the identicon, got version %s", avatar.Version)
+	}
+}
@@ -0,0 +1,46 @@
+package avatars
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// thumbnail crops the center square of `src` and scales it to size×size
+// pixels. Downscaling averages the source pixels covered by each target
+// pixel; upscaling repeats them.
+func thumbnail(src image.Image, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	// Work on premultiplied colors, so transparent pixels do not bleed
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), src, image.Pt(x0, y0), draw.Src)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		sy0, sy1 := y*side/size, max((y+1)*side/size, y*side/size+1)
+		for x := 0; x < size; x++ {
+			sx0, sx1 := x*side/size, max((x+1)*side/size, x*side/size+1)
+
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					c := square.RGBAAt(sx, sy)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+
+			avg := color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)}
+			dst.Set(x, y, avg)
+		}
+	}
+
+	return dst
+}
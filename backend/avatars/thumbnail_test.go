@@ -0,0 +1,44 @@
+package avatars
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	// A wide image, red in the middle and blue on both sides
+	src := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.RGBA{0, 0, 255, 255}), image.Point{}, draw.Src)
+	draw.Draw(src, image.Rect(100, 0, 200, 100), image.NewUniform(color.RGBA{255, 0, 0, 255}), image.Point{}, draw.Src)
+
+	for _, size := range []int{32, 256} {
+		thumb := thumbnail(src, size)
+		if thumb.Bounds().Dx() != size || thumb.Bounds().Dy() != size {
+			t.Fatalf("expected %dx%d, got %v", size, size, thumb.Bounds())
+		}
+
+		// Only the red center square is kept
+		for _, p := range []image.Point{{0, 0}, {size - 1, size - 1}, {size / 2, size / 2}} {
+			if c := thumb.NRGBAAt(p.X, p.Y); c != (color.NRGBA{255, 0, 0, 255}) {
+				t.Errorf("size %d: expected red at %v, got %v", size, p, c)
+			}
+		}
+	}
+}
+
+func TestThumbnailAverages(t *testing.T) {
+	// Alternating black and white columns average to gray
+	src := image.NewGray(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x += 2 {
+		for y := 0; y < 64; y++ {
+			src.SetGray(x, y, color.Gray{255})
+		}
+	}
+
+	c := thumbnail(src, 32).NRGBAAt(10, 10)
+	if c.R != 127 || c.A != 255 {
+		t.Errorf("expected gray, got %v", c)
+	}
+}
@@ -0,0 +1,91 @@
+package avatars
+
+import (
+	"brickedup/backend/blobs"
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"net/http"
+	"slices"
+
+	_ "image/gif"
+	_ "image/jpeg"
+
+	_ "modernc.org/sqlite"
+)
+
+// Upload makes the image in `data` the avatar of the user and returns the
+// URL serving it. The type is determined from the content, not from what
+// the client claims. The previous avatar is deleted.
+func Upload(db *sql.DB, userid int, data []byte) (string, error) {
+	if len(data) > MaxBytes {
+		return "", ErrTooLarge
+	}
+
+	if !slices.Contains(ContentTypes, http.DetectContentType(data)) {
+		return "", ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrInvalidImage
+	}
+	if config.Width*config.Height > maxPixels {
+		return "", ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrInvalidImage
+	}
+
+	var previous sql.NullString
+	err = db.QueryRow(`SELECT avatar FROM USER WHERE id = ?`, userid).Scan(&previous)
+	if err != nil {
+		return "", err
+	}
+
+	// The key changes with the content, so URLs can be cached for good
+	digest := sha256.Sum256(data)
+	key := fmt.Sprintf("%s%d/%s", keyPrefix, userid, hex.EncodeToString(digest[:8]))
+
+	for _, size := range Sizes {
+		var encoded bytes.Buffer
+		err = png.Encode(&encoded, thumbnail(img, size))
+		if err != nil {
+			return "", err
+		}
+
+		err = blobs.Put(fmt.Sprintf("%s/%d.png", key, size), encoded.Bytes())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	_, err = db.Exec(`UPDATE USER SET avatar = ? WHERE id = ?`, key, userid)
+	if err != nil {
+		return "", err
+	}
+
+	if previous.String != key {
+		deleteThumbnails(previous.String)
+	}
+
+	return URL(userid, key), nil
+}
+
+// deleteThumbnails removes the thumbnails of an uploaded avatar. Failures
+// are only logged, as the avatar is not referenced anymore.
+func deleteThumbnails(avatar string) {
+	for _, key := range Keys(avatar) {
+		err := blobs.Delete(key)
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}
+}
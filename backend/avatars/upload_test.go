@@ -0,0 +1,127 @@
+package avatars
+
+import (
+	"brickedup/backend/blobs"
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/utils"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// testImage returns a JPEG photo of the given size with an EXIF segment.
+func testImage(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{200, 50, 50, 255})
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Insert an APP1 segment after the start of image marker
+	exif := []byte("Exif\x00\x00GPS 51.5N 0.1W")
+	segment := append([]byte{0xff, 0xe1, 0, byte(len(exif) + 2)}, exif...)
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+func TestUpload(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	store := blobstest.Temp(t)
+
+	url, err := Upload(db, 1, testImage(t, 400, 300))
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+
+	var key string
+	if err = db.QueryRow(`SELECT avatar FROM USER WHERE id = 1`).Scan(&key); err != nil {
+		t.Fatal(err)
+	}
+	if url != URL(1, key) {
+		t.Errorf("expected %s, got %s", URL(1, key), url)
+	}
+
+	for _, size := range Sizes {
+		data, _, err := store.Get(fmt.Sprintf("%s/%d.png", key, size))
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if bytes.Contains(data, []byte("GPS")) {
+			t.Errorf("size %d: metadata was kept", size)
+		}
+
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if img.Bounds().Dx() != size || img.Bounds().Dy() != size {
+			t.Errorf("expected %dx%d, got %v", size, size, img.Bounds())
+		}
+	}
+
+	// A new upload replaces the thumbnails of the previous one
+	if _, err = Upload(db, 1, testImage(t, 64, 64)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = store.Get(key + "/32.png"); err != blobs.ErrNotFound {
+		t.Errorf("expected the previous avatar to be deleted, got %v", err)
+	}
+}
+
+func TestUploadRejected(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+
+	// A GIF claiming a huge canvas
+	var bomb bytes.Buffer
+	err := gif.EncodeAll(&bomb, &gif.GIF{
+		Image:  []*image.Paletted{image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black})},
+		Delay:  []int{0},
+		Config: image.Config{ColorModel: color.Palette{color.Black}, Width: 10000, Height: 10000},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	truncated := testImage(t, 100, 100)
+	truncated = truncated[:len(truncated)/2]
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"text", []byte("not an image"), ErrUnsupportedType},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), ErrUnsupportedType},
+		{"truncated", truncated, ErrInvalidImage},
+		{"too many pixels", bomb.Bytes(), ErrTooLarge},
+		{"too many bytes", append(testImage(t, 10, 10), make([]byte, MaxBytes)...), ErrTooLarge},
+	}
+	for _, tt := range tests {
+		if _, err := Upload(db, 1, tt.data); err != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	var avatar string
+	if err = db.QueryRow(`SELECT avatar FROM USER WHERE id = 1`).Scan(&avatar); err != nil {
+		t.Fatal(err)
+	}
+	if avatar != "avatar1.png" {
+		t.Errorf("expected the avatar to be unchanged, got %s", avatar)
+	}
+}
@@ -4,16 +4,86 @@ package backend
 
 import (
 	"brickedup/backend/endpoints"
+	"brickedup/backend/sessions"
+	"brickedup/backend/tokens"
+	"brickedup/backend/users"
+	"brickedup/backend/utils"
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 )
 
 // MainHandler checks if the request URL matches a known endpoint.
 // If it does, the corresponding handler is called; otherwise, it returns a 404 error.
+// Requests to non-public endpoints must carry a valid session. The session is
+// resolved once here and placed on the request context along with its user.
+// Instead of a session, requests may carry a personal access token in the
+// Authorization header; it has to grant the scope of the endpoint, and its
+// user is placed on the context in a session with ID 0.
+// Users who have yet to enable two-factor authentication required by one of
+// their organizations can only reach the endpoints that allow it.
 func MainHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
-	if handler, ok := endpoints.Endpoints[r.URL.Path]; ok {
-		handler(db, w, r)
+	endpoint, ok := endpoints.Endpoints[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
 		return
 	}
-	http.NotFound(w, r)
+
+	if !endpoint.Public {
+		session, err := authenticate(db, endpoint, r)
+		if err != nil {
+			if errors.Is(err, sessions.ErrInvalidSession) {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if errors.Is(err, tokens.ErrInvalidToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			if errors.Is(err, tokens.ErrInsufficientScope) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+endpoint.Scope+`"`)
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Println(err.Error())
+			return
+		}
+
+		if !endpoint.AllowWithoutMFA {
+			required, err := users.MFARequired(db, session.UserID)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				log.Println(err.Error())
+				return
+			}
+
+			if required {
+				http.Error(w, "Two-factor authentication required", http.StatusForbidden)
+				return
+			}
+		}
+
+		r = r.WithContext(sessions.NewContext(r.Context(), session))
+	}
+
+	endpoint.Handler(db, w, r)
+}
+
+// authenticate resolves the session or personal access token the request
+// was made with.
+func authenticate(db *sql.DB, endpoint endpoints.Endpoint, r *http.Request) (*utils.Session, error) {
+	token, ok := endpoints.BearerToken(r)
+	if !ok {
+		return sessions.GetSession(db, endpoints.SessionToken(r))
+	}
+
+	apiToken, err := tokens.Authenticate(db, token, endpoint.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &utils.Session{UserID: apiToken.UserID}, nil
 }
@@ -0,0 +1,983 @@
+package backend
+
+import (
+	"archive/zip"
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/endpoints"
+	"brickedup/backend/exports"
+	"brickedup/backend/mail"
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/oidc"
+	"brickedup/backend/oidc/oidctest"
+	"brickedup/backend/sessions"
+	"brickedup/backend/tokens"
+	"brickedup/backend/utils"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestMainHandlerAuthentication(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	created, err := sessions.CreateSession(db, 2, false, "", "")
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	// Let the new session expire from inactivity
+	_, err = db.Exec(
+		`UPDATE SESSION SET expires = ? WHERE id = (SELECT MAX(id) FROM SESSION)`,
+		time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("failed to expire session: %v", err)
+	}
+	expired := created.SessionID
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		session  string
+		wantCode int
+	}{
+		{
+			name:     "Public endpoint without session",
+			method:   http.MethodGet,
+			path:     "/verify",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Unknown endpoint",
+			method:   http.MethodGet,
+			path:     "/does-not-exist",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Missing session",
+			method:   http.MethodGet,
+			path:     "/get-all-users",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Unknown session",
+			method:   http.MethodGet,
+			path:     "/get-all-users",
+			session:  "not-a-session",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Expired session",
+			method:   http.MethodGet,
+			path:     "/get-all-users",
+			session:  expired,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Valid session",
+			method:   http.MethodGet,
+			path:     "/get-all-users",
+			session:  "session-1",
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.session != "" {
+				r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: tc.session})
+			}
+			w := httptest.NewRecorder()
+
+			MainHandler(db, w, r)
+
+			if w.Code != tc.wantCode {
+				t.Errorf("expected status %d, got %d", tc.wantCode, w.Code)
+			}
+		})
+	}
+}
+
+// TestMainHandlerUserInContext checks that handlers act on behalf of the
+// user the session belongs to.
+func TestMainHandlerUserInContext(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// "session-3" belongs to Mike Johnson (userid 3) in populate.sql
+	r := httptest.NewRequest(http.MethodDelete, "/delete-user", nil)
+	r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-3"})
+	w := httptest.NewRecorder()
+
+	MainHandler(db, w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var deactivated []int
+	rows, err := db.Query("SELECT id FROM USER WHERE deactivated IS NOT NULL")
+	if err != nil {
+		t.Fatalf("failed to query deactivated users: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		deactivated = append(deactivated, id)
+	}
+	if len(deactivated) != 1 || deactivated[0] != 3 {
+		t.Errorf("expected only user 3 to be deactivated, got %v", deactivated)
+	}
+}
+
+// TestMainHandlerLogout checks that a session can no longer be used once
+// the user logged out.
+func TestMainHandlerLogout(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	for _, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-2"})
+		w := httptest.NewRecorder()
+
+		MainHandler(db, w, r)
+
+		if w.Code != want {
+			t.Fatalf("expected status %d, got %d", want, w.Code)
+		}
+	}
+}
+
+// TestMainHandlerRefresh checks that /refresh is reachable without a valid
+// session and that a refresh token can only be exchanged once.
+func TestMainHandlerRefresh(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	session, err := sessions.CreateSession(db, 2, false, "", "")
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+
+	for _, want := range []int{http.StatusOK, http.StatusUnauthorized} {
+		r := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+		r.AddCookie(&http.Cookie{Name: endpoints.RefreshCookie, Value: session.RefreshToken})
+		w := httptest.NewRecorder()
+
+		MainHandler(db, w, r)
+
+		if w.Code != want {
+			t.Fatalf("expected status %d, got %d", want, w.Code)
+		}
+	}
+}
+
+// TestMainHandlerEmailPreview checks that email previews are only served
+// while they are enabled.
+func TestMainHandlerEmailPreview(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	defer func(enabled bool) { mail.PreviewEnabled = enabled }(mail.PreviewEnabled)
+
+	tests := []struct {
+		enabled  bool
+		query    string
+		wantCode int
+		wantType string
+	}{
+		{false, "?template=verification", http.StatusNotFound, ""},
+		{true, "?template=verification", http.StatusOK, "text/html"},
+		{true, "?template=reset&format=text", http.StatusOK, "text/plain"},
+		{true, "", http.StatusOK, "application/json"},
+		{true, "?template=unknown", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		mail.PreviewEnabled = tt.enabled
+
+		r := httptest.NewRequest(http.MethodGet, "/dev/email-preview"+tt.query, nil)
+		w := httptest.NewRecorder()
+
+		MainHandler(db, w, r)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%v %q: expected status %d, got %d", tt.enabled, tt.query, tt.wantCode, w.Code)
+		}
+		if !strings.HasPrefix(w.Header().Get("Content-Type"), tt.wantType) {
+			t.Errorf("%v %q: unexpected content type %q", tt.enabled, tt.query, w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+// TestMainHandlerRequire2FA checks that members of an organization requiring
+// 2FA can only set it up until they enabled it.
+func TestMainHandlerRequire2FA(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Jane Smith (2, "session-2") is a member of organization 1
+	_, err := db.Exec(`UPDATE ORGANIZATION SET require_2fa = 1 WHERE id = 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		wantCode int
+	}{
+		{http.MethodGet, "/get-all-users", http.StatusForbidden},
+		{http.MethodPost, "/2fa/enroll", http.StatusOK},
+		{http.MethodGet, "/2fa/qr", http.StatusOK},
+		{http.MethodGet, "/sessions", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-2"})
+		w := httptest.NewRecorder()
+
+		MainHandler(db, w, r)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.wantCode, w.Code)
+		}
+	}
+}
+
+// TestMainHandlerLogin checks that failed logins get the same response
+// whether or not the email exists.
+func TestMainHandlerLogin(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	login := func(email, password string) *httptest.ResponseRecorder {
+		form := url.Values{"email": {email}, "password": {password}}
+		r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		MainHandler(db, w, r)
+		return w
+	}
+
+	unknown := login("nouser@example.com", "password")
+	wrong := login("john.doe@example.com", "password")
+
+	if unknown.Code != http.StatusUnauthorized || wrong.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for both, got %d and %d", unknown.Code, wrong.Code)
+	}
+
+	if unknown.Body.String() != wrong.Body.String() {
+		t.Errorf("responses differ: %q and %q", unknown.Body.String(), wrong.Body.String())
+	}
+
+	// A third failure for the account makes the next attempt wait
+	login("john.doe@example.com", "password")
+	login("john.doe@example.com", "password")
+	throttled := login("john.doe@example.com", "hashed_password_1")
+	if throttled.Code != http.StatusTooManyRequests || throttled.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After, got %d", throttled.Code)
+	}
+}
+
+// TestMainHandlerToken checks that personal access tokens only reach the
+// endpoints their scopes cover.
+func TestMainHandlerToken(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// John Doe (1, "session-1") creates a read-only token
+	form := url.Values{"name": {"CI"}, "scopes": {"issues:read"}}
+	r := httptest.NewRequest(http.MethodPost, "/create-token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-1"})
+	w := httptest.NewRecorder()
+
+	MainHandler(db, w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created utils.NewAPIToken
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method   string
+		path     string
+		token    string
+		wantCode int
+	}{
+		{http.MethodGet, "/get-all-proj", created.Token, http.StatusOK},
+		{http.MethodGet, "/get-all-proj", "bu_not-a-token", http.StatusUnauthorized},
+		{http.MethodPost, "/create-proj", created.Token, http.StatusForbidden},
+		{http.MethodGet, "/get-all-users", created.Token, http.StatusForbidden},
+		{http.MethodPost, "/create-token", created.Token, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		r.Header.Set("Authorization", "Bearer "+tt.token)
+		w := httptest.NewRecorder()
+
+		MainHandler(db, w, r)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.wantCode, w.Code)
+		}
+		if tt.wantCode != http.StatusOK && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %s: expected a WWW-Authenticate header", tt.method, tt.path)
+		}
+	}
+
+	list, err := tokens.GetUserTokens(db, 1)
+	if err != nil || len(list) != 1 || list[0].LastUsed == nil {
+		t.Errorf("expected the token to be listed as used, got %+v (%v)", list, err)
+	}
+}
+
+// TestMainHandlerOIDC runs a single sign-on login against a mock provider.
+func TestMainHandlerOIDC(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	idp := oidctest.New(t)
+	previous := oidc.Providers
+	oidc.Providers = map[string]*oidc.Provider{"corp": idp.Provider("corp", "http://localhost:3100/oidc/callback")}
+	t.Cleanup(func() { oidc.Providers = previous })
+
+	r := httptest.NewRequest(http.MethodGet, "/oidc/login?provider=corp", nil)
+	w := httptest.NewRecorder()
+	MainHandler(db, w, r)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected redirect to the provider, got %d: %s", w.Code, w.Body.String())
+	}
+	var stateCookie *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == endpoints.OIDCStateCookie {
+			stateCookie = cookie
+		}
+	}
+	if stateCookie == nil {
+		t.Fatal("expected the state cookie to be set")
+	}
+
+	callback, err := idp.Login(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the state cookie, e.g. in another browser, the login fails
+	r = httptest.NewRequest(http.MethodGet, "/oidc/callback?"+callback.RawQuery, nil)
+	w = httptest.NewRecorder()
+	MainHandler(db, w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d without state cookie, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/oidc/callback?"+callback.RawQuery, nil)
+	r.AddCookie(stateCookie)
+	w = httptest.NewRecorder()
+	MainHandler(db, w, r)
+
+	if w.Code != http.StatusFound || w.Header().Get("Location") != mail.Link("/", nil) {
+		t.Fatalf("expected redirect to the frontend, got %d %q: %s", w.Code, w.Header().Get("Location"), w.Body.String())
+	}
+
+	var session string
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == endpoints.SessionCookie {
+			session = cookie.Value
+		}
+	}
+
+	var email string
+	err = db.QueryRow(
+		`SELECT u.email FROM SESSION s JOIN USER u ON u.id = s.userid WHERE s.token = ?`,
+		utils.HashToken(session)).Scan(&email)
+	if err != nil || email != idp.User.Email {
+		t.Errorf("expected a session of the provisioned user, got %q (%v)", email, err)
+	}
+}
+
+// TestMainHandlerMagicLink checks that login links only work in the browser
+// that asked for them.
+func TestMainHandlerMagicLink(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	form := url.Values{"email": {"jane.smith@example.com"}}
+	r := httptest.NewRequest(http.MethodPost, "/magic-link", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	MainHandler(db, w, r)
+
+	if w.Code != http.StatusOK || len(outbox.Messages()) != 1 {
+		t.Fatalf("expected a login link to be sent, got %d and %d emails", w.Code, len(outbox.Messages()))
+	}
+	var browser *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == endpoints.MagicLinkCookie {
+			browser = cookie
+		}
+	}
+	if browser == nil {
+		t.Fatal("expected the browser cookie to be set")
+	}
+
+	text := outbox.Messages()[0].Text
+	start := strings.Index(text, "?token=") + len("?token=")
+	token, _ := url.QueryUnescape(strings.Fields(text[start:])[0])
+
+	loginWith := func(cookie *http.Cookie) *httptest.ResponseRecorder {
+		form := url.Values{"token": {token}}
+		r := httptest.NewRequest(http.MethodPost, "/magic-link/login", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	if w := loginWith(nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d in another browser, got %d", http.StatusForbidden, w.Code)
+	}
+
+	w = loginWith(browser)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var hasSession bool
+	for _, cookie := range w.Result().Cookies() {
+		hasSession = hasSession || cookie.Name == endpoints.SessionCookie && cookie.Value != ""
+	}
+	if !hasSession {
+		t.Error("expected the session cookie to be set")
+	}
+
+	if w := loginWith(browser); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d on reuse, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+// TestMainHandlerEmailChange checks that the email only changes once the
+// current password is given and the new address is confirmed.
+func TestMainHandlerEmailChange(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	post := func(path string, form url.Values, session string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if session != "" {
+			r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: session})
+		}
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	form := url.Values{"email": {"john@example.org"}, "password": {"wrongpassword"}}
+	if w := post("/change-email", form, "session-1"); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a wrong password, got %d", http.StatusForbidden, w.Code)
+	}
+
+	form.Set("password", "hashed_password_1")
+	if w := post("/change-email", form, "session-1"); w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	if len(outbox.Messages()) != 2 {
+		t.Fatalf("expected a confirmation and a notice, got %d emails", len(outbox.Messages()))
+	}
+
+	text := outbox.Messages()[0].Text
+	start := strings.Index(text, "?token=") + len("?token=")
+	token, _ := url.QueryUnescape(strings.Fields(text[start:])[0])
+
+	if w := post("/confirm-email", url.Values{"token": {token}}, ""); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var email string
+	if err := db.QueryRow(`SELECT email FROM USER WHERE id = 1`).Scan(&email); err != nil {
+		t.Fatal(err)
+	}
+	if email != "john@example.org" {
+		t.Errorf("expected the new email, got %s", email)
+	}
+}
+
+// TestMainHandlerDeactivation deactivates an account and reactivates it
+// with the emailed link.
+func TestMainHandlerDeactivation(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	request := func(method, path string, form url.Values, session string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if session != "" {
+			r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: session})
+		}
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	// John is the only admin of his organization
+	if w := request(http.MethodDelete, "/delete-user", nil, "session-1"); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	w := request(http.MethodDelete, "/delete-user", nil, "session-3")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w = request(http.MethodGet, "/get-user", nil, "session-3"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the session to be revoked, got status %d", w.Code)
+	}
+
+	login := url.Values{"email": {"mike.johnson@example.com"}, "password": {"hashed_password_3"}}
+	if w = request(http.MethodPost, "/login", login, ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	if len(outbox.Messages()) != 1 {
+		t.Fatalf("expected the reactivation link to be emailed, got %d emails", len(outbox.Messages()))
+	}
+	text := outbox.Messages()[0].Text
+	start := strings.Index(text, "?token=") + len("?token=")
+	token, _ := url.QueryUnescape(strings.Fields(text[start:])[0])
+
+	if w = request(http.MethodPost, "/reactivate", url.Values{"token": {token}}, ""); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w = request(http.MethodPost, "/login", login, ""); w.Code != http.StatusOK {
+		t.Errorf("expected login to work again, got status %d", w.Code)
+	}
+}
+
+// TestMainHandlerUserSearch pages through the user directory.
+func TestMainHandlerUserSearch(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	search := func(query url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/users/search?"+query.Encode(), nil)
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-1"})
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	var ids []int
+	query := url.Values{"limit": {"1"}}
+	for range 5 {
+		w := search(query)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var page utils.UserPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, user := range page.Users {
+			ids = append(ids, user.ID)
+		}
+		if page.Next == "" {
+			break
+		}
+		query.Set("cursor", page.Next)
+	}
+	if len(ids) != 3 || ids[0] != 2 || ids[1] != 1 || ids[2] != 3 {
+		t.Errorf("expected the verified users by name, got %v", ids)
+	}
+
+	if w := search(url.Values{"orgid": {"2"}}); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := search(url.Values{"limit": {"1000"}}); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+// TestMainHandlerInvitation invites a user by email and has them accept.
+func TestMainHandlerInvitation(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	post := func(path string, form url.Values, session string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: session})
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	form := url.Values{
+		"kind":     {"org"},
+		"targetid": {"1"},
+		"roleid":   {"3"},
+		"email":    {"sarah.williams@example.com"},
+	}
+
+	// Jane Smith has no exec privileges in the organization
+	if w := post("/invite", form, "session-2"); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	if w := post("/invite", form, "session-1"); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if len(outbox.Messages()) != 1 {
+		t.Fatalf("expected an invitation email, got %d", len(outbox.Messages()))
+	}
+
+	text := outbox.Messages()[0].Text
+	start := strings.Index(text, "?token=") + len("?token=")
+	token, _ := url.QueryUnescape(strings.Fields(text[start:])[0])
+
+	// "session-5" belongs to Sarah Williams
+	if w := post("/accept-invitation", url.Values{"token": {token}}, "session-3"); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := post("/accept-invitation", url.Values{"token": {token}}, "session-5"); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var member bool
+	err := db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM ORG_MEMBER WHERE userid = 4 AND orgid = 1)`).Scan(&member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !member {
+		t.Error("expected Sarah Williams to be a member")
+	}
+}
+
+// TestMainHandlerAvatar uploads an avatar and serves it with caching headers.
+func TestMainHandlerAvatar(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+
+	img := image.NewRGBA(image.Rect(0, 0, 80, 60))
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		t.Fatal(err)
+	}
+
+	upload := func(contentType string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="avatar"; filename="me.png"`)
+		header.Set("Content-Type", contentType)
+		part, _ := form.CreatePart(header)
+		part.Write(encoded.Bytes())
+		form.Close()
+
+		r := httptest.NewRequest(http.MethodPost, "/upload-avatar", &body)
+		r.Header.Set("Content-Type", form.FormDataContentType())
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-1"})
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	if w := upload("image/svg+xml"); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected status %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+	}
+
+	w := upload("image/png")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var uploaded struct{ Avatar string }
+	if err := json.Unmarshal(w.Body.Bytes(), &uploaded); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(path string, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-2"})
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	w = get(uploaded.Avatar+"&size=64", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("expected a PNG image, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Header().Get("Cache-Control"), "immutable") {
+		t.Errorf("expected the versioned URL to be cached for good, got %q", w.Header().Get("Cache-Control"))
+	}
+
+	if w := get(uploaded.Avatar+"&size=64", w.Header().Get("ETag")); w.Code != http.StatusNotModified {
+		t.Errorf("expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	// Users without an avatar get their identicon
+	w = get("/avatar?userid=3", "")
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "private, no-cache" {
+		t.Errorf("expected a revalidated identicon, got %d %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestMainHandlerDataExport(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+	outbox := mailtest.Record(t)
+
+	request := func(method, path, session string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: session})
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	w := request(http.MethodPost, "/request-data-export", "session-1")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+
+	if _, err := exports.RunPending(db); err != nil {
+		t.Fatal(err)
+	}
+
+	w = request(http.MethodGet, "/data-exports", "session-1")
+	var list []utils.DataExport
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Status != exports.StatusReady {
+		t.Fatalf("expected a ready export, got %s", w.Body.String())
+	}
+
+	sent := outbox.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected the download link to be emailed, got %d emails", len(sent))
+	}
+	i := strings.Index(sent[0].Text, "token=")
+	token := strings.Fields(sent[0].Text[i+len("token="):])[0]
+
+	// Only the user who requested the export can download it
+	path := "/download-data-export?token=" + url.QueryEscape(token)
+	if w = request(http.MethodGet, path, "session-2"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = request(http.MethodGet, path, "session-1")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("expected a ZIP archive, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if _, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len())); err != nil {
+		t.Errorf("expected a valid ZIP archive: %v", err)
+	}
+}
+
+func TestMainHandlerPreferences(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	request := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-1"})
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	w := request(http.MethodPost, "/update-preferences", url.Values{
+		"timezone":              {"Europe/Paris"},
+		"default_org":           {"1"},
+		"notify_issue_assigned": {"email, in_app"},
+		"notify_issue_due":      {""},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w = request(http.MethodGet, "/preferences", nil)
+	var prefs utils.Preferences
+	if err := json.Unmarshal(w.Body.Bytes(), &prefs); err != nil {
+		t.Fatal(err)
+	}
+	if prefs.TimeZone != "Europe/Paris" || prefs.DefaultOrg == nil || *prefs.DefaultOrg != 1 {
+		t.Errorf("expected the updated preferences, got %s", w.Body.String())
+	}
+	if len(prefs.Notifications["issue_assigned"]) != 2 || len(prefs.Notifications["issue_due"]) != 0 {
+		t.Errorf("expected the updated notifications, got %v", prefs.Notifications)
+	}
+
+	// Organizations the user is not a member of are refused
+	w = request(http.MethodPost, "/update-preferences", url.Values{"default_org": {"2"}})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	w = request(http.MethodPost, "/update-preferences", url.Values{"default_org": {"first"}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestMainHandlerDependencies(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	request := func(method, path string, form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-1"})
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	w := request(http.MethodPost, "/add-dependency", url.Values{"issueid": {"2"}, "dependency": {"1"}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	// Issue 4 depends on issue 2, which now depends on issue 1
+	w = request(http.MethodPost, "/add-dependency", url.Values{"issueid": {"1"}, "dependency": {"4"}})
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "1 -> 4 -> 2 -> 1") {
+		t.Errorf("expected the cycle to be refused, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = request(http.MethodGet, "/get-dependencies?issueid=2", nil)
+	var deps utils.IssueDependencies
+	if err := json.Unmarshal(w.Body.Bytes(), &deps); err != nil {
+		t.Fatal(err)
+	}
+	if len(deps.Dependencies) != 1 || deps.Dependencies[0].ID != 1 || len(deps.Dependents) != 2 {
+		t.Errorf("expected issue 1 as dependency and two dependents, got %s", w.Body.String())
+	}
+
+	w = request(http.MethodDelete, "/remove-dependency?issueid=2&dependency=1", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	w = request(http.MethodDelete, "/remove-dependency?issueid=2&dependency=1", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestMainHandlerGetIssue(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	request := func(path, session string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: session})
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	if w := request("/get-issue?issueid=1", "session-1"); w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// Sarah is not a member of project 1
+	if w := request("/get-issue?issueid=1", "session-5"); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestMainHandlerProjectGraph(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	request := func(path, session string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: session})
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	w := request("/get-project-graph?projectid=1&issueid=2", "session-1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var graph utils.IssueGraph
+	if err := json.Unmarshal(w.Body.Bytes(), &graph); err != nil {
+		t.Fatal(err)
+	}
+	if graph.CriticalCost != 2000 || graph.Issue == nil || len(graph.Issue.Dependents) != 2 {
+		t.Errorf("expected the analysis of project 1, got %s", w.Body.String())
+	}
+
+	// Sarah is not a member of project 1
+	if w = request("/get-project-graph?projectid=1", "session-5"); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestMainHandlerExportProjectGraph(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	request := func(path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.AddCookie(&http.Cookie{Name: endpoints.SessionCookie, Value: "session-1"})
+		w := httptest.NewRecorder()
+		MainHandler(db, w, r)
+		return w
+	}
+
+	tests := map[string]string{
+		"dot":     "text/vnd.graphviz; charset=utf-8",
+		"mermaid": "text/plain; charset=utf-8",
+		"graphml": "application/graphml+xml; charset=utf-8",
+	}
+	for format, contentType := range tests {
+		w := request("/export-project-graph?projectid=1&format=" + format)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != contentType {
+			t.Errorf("%s: expected %s, got %d %s", format, contentType, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+
+	if w := request("/export-project-graph?projectid=1&format=png"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
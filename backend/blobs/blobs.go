@@ -0,0 +1,86 @@
+// Package blobs stores binary objects such as uploaded images.
+//
+// Objects are kept in a Store under keys like "avatars/1/3f2a/64.png".
+// Which store is used is configured with the BLOB_STORE environment
+// variable; "fs", the local filesystem below BLOB_DIR, is the default and
+// so far the only one. Code that stores objects uses Put, Get and Delete,
+// which go through Default.
+package blobs
+
+import (
+	"errors"
+	"log"
+	"os"
+	"regexp"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when no object has the requested key.
+	ErrNotFound = errors.New("blob not found")
+
+	// ErrInvalidKey is returned for keys that are not made of
+	// slash-separated names of lowercase letters, digits, '-', '_' and '.'.
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// keyRegex matches valid keys. No part may start with a dot, so keys
+// cannot escape the directory of a filesystem store.
+var keyRegex = regexp.MustCompile(`^[a-z0-9_-][a-z0-9._-]*(/[a-z0-9_-][a-z0-9._-]*)*$`)
+
+// Store keeps objects by key.
+type Store interface {
+	// Put stores `data` under `key`, replacing any object there.
+	Put(key string, data []byte) error
+
+	// Get returns the object under `key` and when it was stored.
+	Get(key string) ([]byte, time.Time, error)
+
+	// Delete removes the object under `key`, if there is one.
+	Delete(key string) error
+}
+
+// Default is the store used by Put, Get and Delete. Tests can replace it
+// with blobstest.Temp.
+var Default Store = FromEnv()
+
+// Put stores `data` under `key` in the Default store.
+func Put(key string, data []byte) error {
+	if !keyRegex.MatchString(key) {
+		return ErrInvalidKey
+	}
+	return Default.Put(key, data)
+}
+
+// Get returns the object under `key` in the Default store and when it was
+// stored.
+func Get(key string) ([]byte, time.Time, error) {
+	if !keyRegex.MatchString(key) {
+		return nil, time.Time{}, ErrInvalidKey
+	}
+	return Default.Get(key)
+}
+
+// Delete removes the object under `key` from the Default store.
+func Delete(key string) error {
+	if !keyRegex.MatchString(key) {
+		return ErrInvalidKey
+	}
+	return Default.Delete(key)
+}
+
+// FromEnv returns the store configured by the environment.
+func FromEnv() Store {
+	dir := os.Getenv("BLOB_DIR")
+	if dir == "" {
+		dir = "blobs"
+	}
+
+	switch driver := os.Getenv("BLOB_STORE"); driver {
+	case "", "fs":
+		return &FSStore{Dir: dir}
+	default:
+		log.Printf("Unknown BLOB_STORE %q, falling back to fs\n", driver)
+		return &FSStore{Dir: dir}
+	}
+}
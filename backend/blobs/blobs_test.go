@@ -0,0 +1,59 @@
+package blobs
+
+import (
+	"bytes"
+	"testing"
+)
+
+// temp makes Default a store in a temporary directory for the duration of
+// the test, like blobstest.Temp.
+func temp(t *testing.T) {
+	previous := Default
+	Default = &FSStore{Dir: t.TempDir()}
+	t.Cleanup(func() { Default = previous })
+}
+
+func TestStore(t *testing.T) {
+	temp(t)
+
+	if _, _, err := Get("avatars/1/64.png"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	for _, data := range [][]byte{[]byte("first"), []byte("second")} {
+		if err := Put("avatars/1/64.png", data); err != nil {
+			t.Fatalf("Put returned error: %v", err)
+		}
+
+		got, stored, err := Get("avatars/1/64.png")
+		if err != nil {
+			t.Fatalf("Get returned error: %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("expected %q, got %q", data, got)
+		}
+		if stored.IsZero() {
+			t.Error("expected the time the object was stored")
+		}
+	}
+
+	if err := Delete("avatars/1/64.png"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, _, err := Get("avatars/1/64.png"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after Delete, got %v", err)
+	}
+	if err := Delete("avatars/1/64.png"); err != nil {
+		t.Errorf("expected deleting a missing object to succeed, got %v", err)
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	temp(t)
+
+	for _, key := range []string{"", "../secret", "avatars/../../secret", "/etc/passwd", "avatars//1", "Avatars/1", "avatars/.hidden"} {
+		if err := Put(key, []byte("data")); err != ErrInvalidKey {
+			t.Errorf("%q: expected ErrInvalidKey, got %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// Package blobstest helps tests that store blobs.
+package blobstest
+
+import (
+	"brickedup/backend/blobs"
+	"testing"
+)
+
+// Temp makes blobs.Default a store in a temporary directory for the
+// duration of the test and returns it.
+func Temp(t *testing.T) *blobs.FSStore {
+	previous := blobs.Default
+	store := &blobs.FSStore{Dir: t.TempDir()}
+
+	blobs.Default = store
+	t.Cleanup(func() { blobs.Default = previous })
+
+	return store
+}
@@ -0,0 +1,24 @@
+package blobstest
+
+import (
+	"brickedup/backend/blobs"
+	"testing"
+)
+
+func TestTemp(t *testing.T) {
+	previous := blobs.Default
+
+	t.Run("temporary", func(t *testing.T) {
+		store := Temp(t)
+		if err := blobs.Put("avatars/1/32.png", []byte("data")); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := store.Get("avatars/1/32.png"); err != nil {
+			t.Errorf("expected the blob in the temporary store, got %v", err)
+		}
+	})
+
+	if blobs.Default != previous {
+		t.Error("expected the store to be restored after the test")
+	}
+}
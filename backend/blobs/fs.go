@@ -0,0 +1,71 @@
+package blobs
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FSStore keeps every object as a file below `Dir`, at the path given by
+// its key.
+type FSStore struct {
+	Dir string
+}
+
+// path returns the file of the object under `key`.
+func (s *FSStore) path(key string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(key))
+}
+
+// Put writes the object to a temporary file first and moves it in place
+// once complete, so readers never see partial objects.
+func (s *FSStore) Put(key string, data []byte) error {
+	path := s.path(key)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get reads the object's file, using its modification time as when the
+// object was stored.
+func (s *FSStore) Get(key string) ([]byte, time.Time, error) {
+	path := s.path(key)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, time.Time{}, ErrNotFound
+	} else if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, time.Time{}, ErrNotFound
+	}
+	return data, info.ModTime(), err
+}
+
+// Delete removes the object's file. Directories left empty are kept.
+func (s *FSStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
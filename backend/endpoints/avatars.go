@@ -0,0 +1,148 @@
+package endpoints
+
+import (
+	"brickedup/backend/avatars"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// avatarFormOverhead is how much larger than the image a multipart upload
+// may be, for its boundaries and headers.
+const avatarFormOverhead = 64 << 10
+
+// UploadAvatarHandler handles POST requests on /upload-avatar to replace the
+// logged-in user's avatar with the PNG, JPEG or GIF image uploaded as the
+// multipart file `avatar`. It responds with the URL of the new avatar.
+func UploadAvatarHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(avatars.MaxBytes+avatarFormOverhead))
+	file, header, err := r.FormFile("avatar")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, avatars.ErrTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		http.Error(w, "Missing file avatar", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// The declared type has to match as well as the content
+	declared, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+	if !slices.Contains(avatars.ContentTypes, declared) {
+		http.Error(w, avatars.ErrUnsupportedType.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(avatars.MaxBytes)+1))
+	if err != nil {
+		http.Error(w, "Invalid upload", http.StatusBadRequest)
+		return
+	}
+
+	url, err := avatars.Upload(db, getSessionUser(r), data)
+	if err != nil {
+		switch {
+		case errors.Is(err, avatars.ErrTooLarge):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		case errors.Is(err, avatars.ErrUnsupportedType):
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		case errors.Is(err, avatars.ErrInvalidImage):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Println(err.Error())
+		}
+		return
+	}
+
+	json, err := json.Marshal(map[string]string{"avatar": url})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// DeleteAvatarHandler handles DELETE requests on /delete-avatar to remove
+// the logged-in user's avatar, who then gets their identicon.
+func DeleteAvatarHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := avatars.Remove(db, getSessionUser(r))
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetAvatarHandler handles GET requests on /avatar to serve the avatar of
+// the user `userid` as a PNG image. It optionally takes the `size` in
+// pixels and the version `v` of the URLs returned for uploaded avatars;
+// responses to URLs with the current version never change and are cached
+// for good, others are revalidated with their ETag.
+func GetAvatarHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	userid, err := strconv.Atoi(query.Get("userid"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for userid", http.StatusBadRequest)
+		return
+	}
+
+	size := avatars.DefaultSize
+	if value := query.Get("size"); value != "" {
+		size, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid parameter for size", http.StatusBadRequest)
+			return
+		}
+	}
+
+	avatar, err := avatars.Get(db, userid, size)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	if avatar.Version != "" && query.Get("v") == avatar.Version {
+		w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("ETag", avatar.ETag)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, "", avatar.Modified, bytes.NewReader(avatar.Data))
+}
@@ -0,0 +1,225 @@
+package endpoints
+
+import (
+	"brickedup/backend/issues"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// AddDependencyHandler handles POST requests on /add-dependency to make the
+// issue `issueid` depend on the issue `dependency`. Dependencies that would
+// close a cycle are refused with 409 and the cycle in the message.
+func AddDependencyHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	issueid, err := strconv.Atoi(r.FormValue("issueid"))
+	if err != nil {
+		http.Error(w, "Invalid issue ID", http.StatusBadRequest)
+		return
+	}
+
+	dependency, err := strconv.Atoi(r.FormValue("dependency"))
+	if err != nil {
+		http.Error(w, "Invalid dependency", http.StatusBadRequest)
+		return
+	}
+
+	err = issues.SetDep(db, issueid, dependency, getSessionUser(r))
+	if err != nil {
+		dependencyError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// RemoveDependencyHandler handles DELETE requests on /remove-dependency to
+// remove the dependency of the issue `issueid` on the issue `dependency`,
+// both specified as URL parameters.
+func RemoveDependencyHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	issueid, err := strconv.Atoi(r.FormValue("issueid"))
+	if err != nil {
+		http.Error(w, "Invalid issue ID", http.StatusBadRequest)
+		return
+	}
+
+	dependency, err := strconv.Atoi(r.FormValue("dependency"))
+	if err != nil {
+		http.Error(w, "Invalid dependency", http.StatusBadRequest)
+		return
+	}
+
+	err = issues.RemoveDep(db, issueid, dependency, getSessionUser(r))
+	if err != nil {
+		dependencyError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetDependenciesHandler handles GET requests on /get-dependencies to list
+// the issues the issue depends on and the issues depending on it. The
+// `issueid` is specified as a URL parameter.
+func GetDependenciesHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	issueid, err := strconv.Atoi(r.URL.Query().Get("issueid"))
+	if err != nil {
+		http.Error(w, "Invalid issue ID", http.StatusBadRequest)
+		return
+	}
+
+	deps, err := issues.GetDeps(db, issueid, getSessionUser(r))
+	if err != nil {
+		dependencyError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(deps)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// dependencyError responds to a failed change or listing of dependencies.
+func dependencyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, issues.ErrIssueNotFound), errors.Is(err, issues.ErrDependencyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, issues.ErrInsufficientPrivileges), errors.Is(err, issues.ErrNoReadAccess):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, issues.ErrDependencyCycle), errors.Is(err, issues.ErrDependencyExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, issues.ErrSelfDependency):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+	}
+}
+
+// GetProjectGraphHandler handles GET requests on /get-project-graph to
+// analyze the dependency graph of the project `projectid`: an order to do
+// the issues in, the issues ready to start and the critical path by cost.
+// With the optional `issueid`, the issues it transitively depends on and
+// that transitively depend on it are included. Both are URL parameters.
+func GetProjectGraphHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	projectid, err := strconv.Atoi(query.Get("projectid"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	issueid := 0
+	if value := query.Get("issueid"); value != "" {
+		issueid, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid issue ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	graph, err := issues.GetGraph(db, projectid, issueid, getSessionUser(r))
+	if err != nil {
+		dependencyError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(graph)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// graphContentTypes maps the formats of ExportGraph to content types.
+var graphContentTypes = map[string]string{
+	issues.FormatDOT:     "text/vnd.graphviz; charset=utf-8",
+	issues.FormatMermaid: "text/plain; charset=utf-8",
+	issues.FormatGraphML: "application/graphml+xml; charset=utf-8",
+}
+
+// ExportProjectGraphHandler handles GET requests on /export-project-graph to
+// render the dependency graph of the project `projectid` as `format`: dot,
+// mermaid or graphml. With the optional `issueid`, only the issue and the
+// issues it transitively depends on or that depend on it are rendered. All
+// are URL parameters.
+func ExportProjectGraphHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	projectid, err := strconv.Atoi(query.Get("projectid"))
+	if err != nil {
+		http.Error(w, "Invalid project ID", http.StatusBadRequest)
+		return
+	}
+
+	issueid := 0
+	if value := query.Get("issueid"); value != "" {
+		issueid, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid issue ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	format := query.Get("format")
+	out, err := issues.ExportGraph(db, projectid, issueid, getSessionUser(r), format)
+	if errors.Is(err, issues.ErrUnknownFormat) {
+		http.Error(w, err.Error()+": "+format, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		dependencyError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", graphContentTypes[format])
+	w.WriteHeader(http.StatusOK)
+	w.Write(out)
+}
@@ -1,6 +1,7 @@
 package endpoints
 
 import (
+	"brickedup/backend/tokens"
 	"database/sql"
 	"net/http"
 )
@@ -8,39 +9,99 @@ import (
 // DBHandlerFunc is the function prototype for endpoint handlers.
 type DBHandlerFunc func(*sql.DB, http.ResponseWriter, *http.Request)
 
-// Endpoints maps URL paths to their corresponding handler functions.
-var Endpoints = map[string]DBHandlerFunc{
-	"/login":                   	LoginHandler,
-	"/signup":                  	SignupHandler,
-	"/verify":                  	VerifyHandler,
-	"/get-user":               		GetUserHandler,
-	"/get-all-users":          		GetAllUsersHandler,
-	"/delete-user":            		DeleteUserHandler,
-	"/update-user":            		UpdateUserHandler,
-	"/create-issue":           		CreateIssueHandler,
-	"/get-issue":               	GetIssueHandler,
-	"/update-issue":           		UpdateIssueHandler,
-	"/create-tag":             		CreateTagHandler,
-	"/delete-tag":             		DeleteTagHandler,
-	"/get-org":         			GetOrgHandler,
-	"/get-all-orgs":				GetAllOrgHandler,
-	"/get-org-member":    			GetOrgMemberHandler,
-	"/get-org-role":				GetOrgRoleHandler,
-	"/add-org-member":				AddOrgMemberHandler,
-	"/remove-org-member":			RemoveOrgMemberHandler,
-	"/create-org":             		CreateOrganizationHandler,
-	"/update-org":             		UpdateOrgHandler,
-	"/delete-org":             		DeleteOrganizationHandler,
-	"/withdraw-org-role":		 	WithdrawOrgRoleHandler,
-	"/assign-org-role":        		AssignOrgRoleHandler,
-	"/get-proj":					GetProjHandler,
-	"/create-proj":            		CreateProjHandler,
-	"/update-proj":            		UpdateProjHandler,
-	"/get-all-proj":				GetAllProjHandler,
-	"/get-proj-member":				GetProjMemberHandler,
-	"/get-proj-role":				GetProjRoleHandler,
-	"/add-proj-member":				AddProjMemberHandler,
-	"/remove-proj-member":			RemoveProjMemberHandler,
-	"/get-tag":						GetTagHandler,
-	"/archive-proj": 				ArchiveProjHandler,
+// Endpoint holds the handler of a route together with its metadata.
+// Public endpoints can be reached without an authenticated session.
+// AllowWithoutMFA endpoints stay reachable for users who still have to enable
+// two-factor authentication required by one of their organizations.
+// Scope is the scope a personal access token needs to call the endpoint;
+// endpoints without one can only be called with a session.
+type Endpoint struct {
+	Handler         DBHandlerFunc
+	Public          bool
+	AllowWithoutMFA bool
+	Scope           string
+}
+
+// Endpoints maps URL paths to their corresponding endpoints.
+var Endpoints = map[string]Endpoint{
+	"/login":                   	{Handler: LoginHandler, Public: true},
+	"/login/2fa":               	{Handler: LoginMFAHandler, Public: true},
+	"/magic-link":					{Handler: RequestMagicLinkHandler, Public: true},
+	"/magic-link/login":			{Handler: MagicLinkLoginHandler, Public: true},
+	"/signup":                  	{Handler: SignupHandler, Public: true},
+	"/verify":                  	{Handler: VerifyHandler, Public: true},
+	"/resend-verification":     	{Handler: ResendVerificationHandler, Public: true},
+	"/oidc/providers":				{Handler: GetOIDCProvidersHandler, Public: true},
+	"/oidc/login":					{Handler: OIDCLoginHandler, Public: true},
+	"/oidc/callback":				{Handler: OIDCCallbackHandler, Public: true},
+	"/refresh":                 	{Handler: RefreshHandler, Public: true},
+	"/forgot-password":         	{Handler: ForgotPasswordHandler, Public: true},
+	"/reset-password":          	{Handler: ResetPasswordHandler, Public: true},
+	"/request-reactivation":		{Handler: RequestReactivationHandler, Public: true},
+	"/reactivate":					{Handler: ReactivateHandler, Public: true},
+	"/logout":                  	{Handler: LogoutHandler, AllowWithoutMFA: true},
+	"/sessions":                	{Handler: GetSessionsHandler, AllowWithoutMFA: true},
+	"/revoke-session":          	{Handler: RevokeSessionHandler, AllowWithoutMFA: true},
+	"/revoke-other-sessions":   	{Handler: RevokeOtherSessionsHandler, AllowWithoutMFA: true},
+	"/2fa/enroll":              	{Handler: EnrollTOTPHandler, AllowWithoutMFA: true},
+	"/2fa/qr":                  	{Handler: TOTPQRCodeHandler, AllowWithoutMFA: true},
+	"/2fa/confirm":             	{Handler: ConfirmTOTPHandler, AllowWithoutMFA: true},
+	"/2fa/disable":             	{Handler: DisableTOTPHandler},
+	"/get-user":               		{Handler: GetUserHandler, AllowWithoutMFA: true},
+	"/tokens":						{Handler: GetTokensHandler},
+	"/create-token":				{Handler: CreateTokenHandler},
+	"/revoke-token":				{Handler: RevokeTokenHandler},
+	"/get-all-users":          		{Handler: GetAllUsersHandler},
+	"/users/search":				{Handler: SearchUsersHandler},
+	"/delete-user":            		{Handler: DeleteUserHandler},
+	"/update-user":            		{Handler: UpdateUserHandler},
+	"/upload-avatar":				{Handler: UploadAvatarHandler},
+	"/delete-avatar":				{Handler: DeleteAvatarHandler},
+	"/avatar":						{Handler: GetAvatarHandler, AllowWithoutMFA: true},
+	"/change-email":				{Handler: ChangeEmailHandler},
+	"/confirm-email":				{Handler: ConfirmEmailHandler, Public: true},
+	"/request-data-export":			{Handler: RequestDataExportHandler},
+	"/data-exports":				{Handler: GetDataExportsHandler},
+	"/download-data-export":		{Handler: DownloadDataExportHandler},
+	"/preferences":					{Handler: GetPreferencesHandler},
+	"/update-preferences":			{Handler: UpdatePreferencesHandler},
+	"/create-issue":           		{Handler: CreateIssueHandler, Scope: tokens.ScopeIssuesWrite},
+	"/get-issue":               	{Handler: GetIssueHandler, Scope: tokens.ScopeIssuesRead},
+	"/update-issue":           		{Handler: UpdateIssueHandler, Scope: tokens.ScopeIssuesWrite},
+	"/add-dependency":				{Handler: AddDependencyHandler, Scope: tokens.ScopeIssuesWrite},
+	"/remove-dependency":			{Handler: RemoveDependencyHandler, Scope: tokens.ScopeIssuesWrite},
+	"/get-dependencies":			{Handler: GetDependenciesHandler, Scope: tokens.ScopeIssuesRead},
+	"/get-project-graph":			{Handler: GetProjectGraphHandler, Scope: tokens.ScopeIssuesRead},
+	"/export-project-graph":		{Handler: ExportProjectGraphHandler, Scope: tokens.ScopeIssuesRead},
+	"/create-tag":             		{Handler: CreateTagHandler, Scope: tokens.ScopeIssuesWrite},
+	"/delete-tag":             		{Handler: DeleteTagHandler, Scope: tokens.ScopeIssuesWrite},
+	"/get-org":         			{Handler: GetOrgHandler},
+	"/get-all-orgs":				{Handler: GetAllOrgHandler},
+	"/get-org-member":    			{Handler: GetOrgMemberHandler},
+	"/get-org-role":				{Handler: GetOrgRoleHandler},
+	"/add-org-member":				{Handler: AddOrgMemberHandler},
+	"/remove-org-member":			{Handler: RemoveOrgMemberHandler},
+	"/create-org":             		{Handler: CreateOrganizationHandler},
+	"/update-org":             		{Handler: UpdateOrgHandler},
+	"/delete-org":             		{Handler: DeleteOrganizationHandler},
+	"/set-org-2fa":					{Handler: SetOrgRequire2FAHandler},
+	"/withdraw-org-role":		 	{Handler: WithdrawOrgRoleHandler},
+	"/invite":						{Handler: InviteHandler},
+	"/create-join-link":			{Handler: CreateJoinLinkHandler},
+	"/invitations":					{Handler: GetInvitationsHandler},
+	"/revoke-invitation":			{Handler: RevokeInvitationHandler},
+	"/resend-invitation":			{Handler: ResendInvitationHandler},
+	"/accept-invitation":			{Handler: AcceptInvitationHandler},
+	"/assign-org-role":        		{Handler: AssignOrgRoleHandler},
+	"/get-proj":					{Handler: GetProjHandler, Scope: tokens.ScopeIssuesRead},
+	"/create-proj":            		{Handler: CreateProjHandler, Scope: tokens.ScopeProjectsAdmin},
+	"/update-proj":            		{Handler: UpdateProjHandler, Scope: tokens.ScopeProjectsAdmin},
+	"/get-all-proj":				{Handler: GetAllProjHandler, Scope: tokens.ScopeIssuesRead},
+	"/get-proj-member":				{Handler: GetProjMemberHandler, Scope: tokens.ScopeProjectsAdmin},
+	"/get-proj-role":				{Handler: GetProjRoleHandler, Scope: tokens.ScopeProjectsAdmin},
+	"/add-proj-member":				{Handler: AddProjMemberHandler, Scope: tokens.ScopeProjectsAdmin},
+	"/remove-proj-member":			{Handler: RemoveProjMemberHandler, Scope: tokens.ScopeProjectsAdmin},
+	"/get-tag":						{Handler: GetTagHandler, Scope: tokens.ScopeIssuesRead},
+	"/archive-proj": 				{Handler: ArchiveProjHandler, Scope: tokens.ScopeProjectsAdmin},
+	"/dev/email-preview":			{Handler: EmailPreviewHandler, Public: true},
 }
@@ -0,0 +1,97 @@
+package endpoints
+
+import (
+	"brickedup/backend/exports"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// RequestDataExportHandler handles POST requests on /request-data-export to
+// queue an export of everything stored about the logged-in user. The
+// download link is emailed once the export is built; until then further
+// requests return the same export.
+func RequestDataExportHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	export, err := exports.Request(db, getSessionUser(r))
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	json, err := json.Marshal(export)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(json)
+}
+
+// GetDataExportsHandler handles GET requests on /data-exports to list the
+// logged-in user's exports, newest first.
+func GetDataExportsHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	list, err := exports.GetExports(db, getSessionUser(r))
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	json, err := json.Marshal(list)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// DownloadDataExportHandler handles GET requests on /download-data-export
+// to serve the ZIP archive of the export with the emailed `token`. The link
+// only works for the user who requested the export, until it expires.
+func DownloadDataExportHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Missing parameter token", http.StatusBadRequest)
+		return
+	}
+
+	data, err := exports.Download(db, getSessionUser(r), token)
+	if err == exports.ErrInvalidDownload {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="brickedup-export.zip"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
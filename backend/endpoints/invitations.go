@@ -0,0 +1,272 @@
+package endpoints
+
+import (
+	"brickedup/backend/invitations"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// invitationError responds with the status matching an error of the
+// invitations package.
+func invitationError(w http.ResponseWriter, err error) {
+	if validationError(w, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, invitations.ErrNotAllowed), errors.Is(err, invitations.ErrWrongInvitee):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, invitations.ErrInvitationNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, invitations.ErrInvalidInvitation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, invitations.ErrAlreadyMember), errors.Is(err, invitations.ErrAlreadyInvited),
+		errors.Is(err, invitations.ErrJoinLink):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, invitations.ErrResendThrottled):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+	}
+}
+
+// InviteHandler handles POST requests on /invite to invite `email` to the
+// organization or project `targetid` of `kind` ("org" or "project") with the
+// role `roleid`. It optionally takes `expires_in_days`.
+func InviteHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	targetid, err := strconv.Atoi(r.FormValue("targetid"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for targetid", http.StatusBadRequest)
+		return
+	}
+
+	roleid, err := strconv.Atoi(r.FormValue("roleid"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for roleid", http.StatusBadRequest)
+		return
+	}
+
+	days := invitations.DefaultLifetimeDays
+	if value := r.FormValue("expires_in_days"); value != "" {
+		days, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid parameter for expires_in_days", http.StatusBadRequest)
+			return
+		}
+	}
+
+	invitation, err := invitations.Invite(db, getSessionUser(r), r.FormValue("kind"),
+		targetid, roleid, r.FormValue("email"), days)
+
+	if err != nil {
+		invitationError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(invitation)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(json)
+}
+
+// CreateJoinLinkHandler handles POST requests on /create-join-link to create
+// a shareable link to the organization or project `targetid` of `kind` with
+// the role `roleid`, usable `max_uses` times. It optionally takes
+// `expires_in_days`. The token is part of the response and cannot be
+// retrieved again.
+func CreateJoinLinkHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	targetid, err := strconv.Atoi(r.FormValue("targetid"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for targetid", http.StatusBadRequest)
+		return
+	}
+
+	roleid, err := strconv.Atoi(r.FormValue("roleid"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for roleid", http.StatusBadRequest)
+		return
+	}
+
+	maxUses, err := strconv.Atoi(r.FormValue("max_uses"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for max_uses", http.StatusBadRequest)
+		return
+	}
+
+	days := invitations.DefaultLifetimeDays
+	if value := r.FormValue("expires_in_days"); value != "" {
+		days, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid parameter for expires_in_days", http.StatusBadRequest)
+			return
+		}
+	}
+
+	link, err := invitations.CreateJoinLink(db, getSessionUser(r), r.FormValue("kind"),
+		targetid, roleid, maxUses, days)
+
+	if err != nil {
+		invitationError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(link)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(json)
+}
+
+// GetInvitationsHandler handles GET requests on /invitations to list the
+// pending invitations and join links of the organization or project
+// `targetid` of `kind`, given as URL parameters.
+func GetInvitationsHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	targetid, err := strconv.Atoi(r.URL.Query().Get("targetid"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for targetid", http.StatusBadRequest)
+		return
+	}
+
+	list, err := invitations.GetInvitations(db, getSessionUser(r), r.URL.Query().Get("kind"), targetid)
+	if err != nil {
+		invitationError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(list)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// RevokeInvitationHandler handles DELETE requests on /revoke-invitation to
+// revoke an invitation or join link. It takes the `id` as a URL parameter.
+func RevokeInvitationHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for id", http.StatusBadRequest)
+		return
+	}
+
+	err = invitations.RevokeInvitation(db, getSessionUser(r), id)
+	if err != nil {
+		invitationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ResendInvitationHandler handles POST requests on /resend-invitation to
+// email the invitation `id` again with a new link.
+func ResendInvitationHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for id", http.StatusBadRequest)
+		return
+	}
+
+	invitation, err := invitations.ResendInvitation(db, getSessionUser(r), id)
+	if err != nil {
+		invitationError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(invitation)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// AcceptInvitationHandler handles POST requests on /accept-invitation to
+// join an organization or project with the `token` of an invitation or join
+// link. Users who have no account yet sign up or log in first.
+func AcceptInvitationHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	invitation, err := invitations.AcceptInvitation(db, getSessionUser(r), r.FormValue("token"))
+	if err != nil {
+		invitationError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(invitation)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
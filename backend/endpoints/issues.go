@@ -4,6 +4,7 @@ import (
 	"brickedup/backend/issues"
 	"brickedup/backend/utils"
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -22,12 +23,7 @@ func CreateIssueHandler (db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionID, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w,"Invalid session ID", http.StatusBadRequest)
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	// Extract and validate form values
 	projectid, err := strconv.Atoi(r.FormValue("projectid"))
@@ -64,7 +60,7 @@ func CreateIssueHandler (db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 	// Call your backend logic
 	_, err = issues.CreateIssue(
-		sessionID, 
+		sessionUserID, 
 		projectid, 
 		title, 
 		desc, 
@@ -76,6 +72,9 @@ func CreateIssueHandler (db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		db)
 
 	if err != nil {
+		if validationError(w, err) {
+			return
+		}
 		http.Error(w, "Failed to create issue: "+err.Error(), http.StatusInternalServerError)
 		log.Println(err.Error())
 		return
@@ -108,10 +107,13 @@ func GetIssueHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Fetch issue details
-	jsonStr, err := issues.GetIssue(db, issueID)
-	if err == sql.ErrNoRows {
+	jsonStr, err := issues.GetIssue(db, issueID, getSessionUser(r))
+	if err == sql.ErrNoRows || errors.Is(err, issues.ErrIssueNotFound) {
 		http.Error(w, "Issue not found", http.StatusNotFound)
 		return
+	} else if errors.Is(err, issues.ErrNoReadAccess) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
 	} else if err != nil {
 		http.Error(w, "Failed to fetch issue: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -188,9 +190,14 @@ func UpdateIssueHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
     }
 
     // Call core business logic
-    if err := issues.UpdateIssue(db, issueID, &issue); err != nil {
-        if err.Error() == "no issue found for issue ID "+issueIDStr {
+    if err := issues.UpdateIssue(db, issueID, &issue, getSessionUser(r)); err != nil {
+        if validationError(w, err) {
+            return
+        }
+        if err.Error() == "no issue found for issue ID "+issueIDStr || errors.Is(err, issues.ErrIssueNotFound) {
             http.Error(w, "Issue not found", http.StatusNotFound)
+        } else if errors.Is(err, issues.ErrInsufficientPrivileges) {
+            http.Error(w, err.Error(), http.StatusForbidden)
         } else {
             log.Println("UpdateIssue error:", err)
             http.Error(w, "Failed to update issue: "+err.Error(), http.StatusInternalServerError)
@@ -0,0 +1,112 @@
+package endpoints
+
+import (
+	"brickedup/backend/users"
+	"brickedup/backend/utils"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// MagicLinkCookie is the name of the cookie holding the secret that binds
+// login links to the browser they were requested from.
+const MagicLinkCookie = "magic_link"
+
+// RequestMagicLinkHandler handles POST requests for a passwordless login
+// link on /magic-link. It takes the `email` of the account and optionally
+// `remember`. The response is the same whether or not the email is
+// registered; the link only works in the browser that requested it.
+func RequestMagicLinkHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method unsupported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	email := r.FormValue("email")
+	remember := r.FormValue("remember") == "true"
+
+	// Reuse the secret of earlier requests, so all links sent to this
+	// browser keep working
+	var browser string
+	if cookie, err := r.Cookie(MagicLinkCookie); err == nil && cookie.Value != "" {
+		browser = cookie.Value
+	} else {
+		browser, err = utils.GenerateToken()
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Println(err.Error())
+			return
+		}
+	}
+
+	err := users.RequestMagicLink(db, email, remember, browser, clientIP(r), r.UserAgent())
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     MagicLinkCookie,
+		Value:    browser,
+		Path:     "/magic-link",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// MagicLinkLoginHandler handles POST requests to log in with the `token` of
+// a login link on /magic-link/login. It responds like /login.
+func MagicLinkLoginHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method unsupported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	token := r.FormValue("token")
+
+	var browser string
+	if cookie, err := r.Cookie(MagicLinkCookie); err == nil {
+		browser = cookie.Value
+	}
+
+	session, challenge, err := users.MagicLinkLogin(db, token, browser, clientIP(r), r.UserAgent())
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidMagicLink):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, users.ErrWrongBrowser):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Println(err.Error())
+		}
+		return
+	}
+
+	if challenge != nil {
+		json, _ := json.Marshal(challenge)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(json)
+		return
+	}
+
+	json, err := json.Marshal(session)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	setSessionCookies(w, session)
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
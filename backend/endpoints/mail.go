@@ -0,0 +1,63 @@
+package endpoints
+
+import (
+	"brickedup/backend/mail"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+)
+
+// EmailPreviewHandler handles GET requests on /dev/email-preview to render
+// an email template with sample data. It takes the `template` name and the
+// `format`, "html" (default) or "text". Without a template it lists the
+// template names. It only exists while mail.PreviewEnabled is set.
+func EmailPreviewHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if !mail.PreviewEnabled {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("template")
+	if name == "" {
+		names := make([]string, 0, len(mail.Samples))
+		for name := range mail.Samples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		json, _ := json.Marshal(names)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(json)
+		return
+	}
+
+	msg, err := mail.Preview(name)
+	if errors.Is(err, mail.ErrUnknownTemplate) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Subject: " + msg.Subject + "\n\n" + msg.Text))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(msg.HTML))
+}
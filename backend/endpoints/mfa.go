@@ -0,0 +1,184 @@
+package endpoints
+
+import (
+	"brickedup/backend/organizations"
+	"brickedup/backend/users"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// qrCodeSize is the width and height in pixels of the 2FA QR code.
+const qrCodeSize = 256
+
+// mfaError responds to the errors of the 2FA functions with the matching
+// status code.
+func mfaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, users.ErrInvalidTOTPCode), errors.Is(err, users.ErrInvalidMFAChallenge):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, users.ErrTooManyAttempts):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	case errors.Is(err, users.ErrTOTPEnabled), errors.Is(err, users.ErrTOTPNotEnrolled),
+		errors.Is(err, users.ErrTOTPRequired):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+	}
+}
+
+// EnrollTOTPHandler handles POST requests on /2fa/enroll to start setting up
+// an authenticator app for the logged-in user. It returns the secret and its
+// otpauth:// URI; the QR code of the URI is served on /2fa/qr.
+func EnrollTOTPHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	enrollment, err := users.EnrollTOTP(db, getSessionUser(r))
+	if err != nil {
+		mfaError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(enrollment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// TOTPQRCodeHandler handles GET requests on /2fa/qr and returns the QR code
+// of the secret being set up as a PNG image.
+func TOTPQRCodeHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	png, err := users.TOTPQRCode(db, getSessionUser(r), qrCodeSize)
+	if err != nil {
+		mfaError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(png)
+}
+
+// ConfirmTOTPHandler handles POST requests on /2fa/confirm to enable
+// two-factor authentication with a first `code` from the authenticator app.
+// It returns the recovery codes, which cannot be retrieved again.
+func ConfirmTOTPHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	codes, err := users.ConfirmTOTP(db, getSessionUser(r), r.FormValue("code"))
+	if err != nil {
+		mfaError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(map[string][]string{"recovery_codes": codes})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// DisableTOTPHandler handles POST requests on /2fa/disable to turn
+// two-factor authentication off. It takes a current TOTP or recovery `code`.
+func DisableTOTPHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	err := users.DisableTOTP(db, getSessionUser(r), r.FormValue("code"))
+	if err != nil {
+		mfaError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// LoginMFAHandler handles POST requests on /login/2fa to complete a login
+// with the `mfa_challenge` returned by /login and a TOTP or recovery `code`.
+func LoginMFAHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method unsupported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	challenge := r.FormValue("mfa_challenge")
+	code := r.FormValue("code")
+
+	session, err := users.VerifyMFA(db, challenge, code, clientIP(r), r.UserAgent())
+	if err != nil {
+		mfaError(w, err)
+		return
+	}
+
+	json, err := json.Marshal(session)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	setSessionCookies(w, session)
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// SetOrgRequire2FAHandler handles PATCH requests on /set-org-2fa to set
+// whether the members of organization `orgid` must use two-factor
+// authentication (`required=true`).
+func SetOrgRequire2FAHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	orgid, err := strconv.Atoi(r.FormValue("orgid"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for orgid", http.StatusBadRequest)
+		return
+	}
+
+	required := r.FormValue("required") == "true"
+
+	err = organizations.SetRequire2FA(db, getSessionUser(r), orgid, required)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
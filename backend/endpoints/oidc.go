@@ -0,0 +1,142 @@
+package endpoints
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/oidc"
+	"brickedup/backend/users"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// OIDCStateCookie is the name of the cookie that binds a single sign-on
+// login to the browser that started it.
+const OIDCStateCookie = "oidc_state"
+
+// GetOIDCProvidersHandler handles GET requests to list the names of the
+// identity providers users can log in with on /oidc/providers.
+func GetOIDCProvidersHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	json, _ := json.Marshal(oidc.ProviderNames())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// OIDCLoginHandler handles GET requests to log in with an identity provider
+// on /oidc/login. It takes the `provider` name and optionally `remember` as
+// URL parameters, and redirects the browser to the provider.
+func OIDCLoginHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	authURL, state, err := oidc.Begin(r.Context(), db, query.Get("provider"), query.Get("remember") == "true")
+	if err != nil {
+		if errors.Is(err, oidc.ErrUnknownProvider) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Identity provider unavailable", http.StatusBadGateway)
+		log.Println(err.Error())
+		return
+	}
+
+	// Lax, as the callback is a top-level navigation from the provider
+	http.SetCookie(w, &http.Cookie{
+		Name:     OIDCStateCookie,
+		Value:    state,
+		Path:     "/oidc/callback",
+		MaxAge:   10 * 60,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallbackHandler handles the GET requests identity providers redirect
+// back to on /oidc/callback, with the `state` of the login and an
+// authorization `code`. On success the session cookies are set and the
+// browser is sent to the frontend, or to its 2FA page along with an MFA
+// challenge if the user has two-factor authentication enabled.
+func OIDCCallbackHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	state := query.Get("state")
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     OIDCStateCookie,
+		Value:    "",
+		Path:     "/oidc/callback",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	cookie, err := r.Cookie(OIDCStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		http.Error(w, oidc.ErrInvalidState.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if query.Get("error") != "" {
+		http.Error(w, "Login refused by the identity provider: "+query.Get("error"), http.StatusUnauthorized)
+		return
+	}
+
+	identity, remember, err := oidc.Finish(r.Context(), db, state, query.Get("code"))
+	if err != nil {
+		oidcError(w, err)
+		return
+	}
+
+	session, challenge, err := users.LoginOIDC(db, identity, remember, clientIP(r), r.UserAgent())
+	if err != nil {
+		oidcError(w, err)
+		return
+	}
+
+	if challenge != nil {
+		fragment := url.Values{"mfa_challenge": {challenge.Challenge}}
+		http.Redirect(w, r, mail.Link("/login/2fa", nil)+"#"+fragment.Encode(), http.StatusFound)
+		return
+	}
+
+	setSessionCookies(w, session)
+	http.Redirect(w, r, mail.Link("/", nil), http.StatusFound)
+}
+
+// oidcError responds to a failed single sign-on login.
+func oidcError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, oidc.ErrInvalidState):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, oidc.ErrUnknownProvider):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, oidc.ErrInvalidIDToken):
+		http.Error(w, oidc.ErrInvalidIDToken.Error(), http.StatusUnauthorized)
+		log.Println(err.Error())
+	case errors.Is(err, users.ErrProviderEmailUnverified), errors.Is(err, users.ErrDeactivated):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, "Single sign-on failed", http.StatusBadGateway)
+		log.Println(err.Error())
+	}
+}
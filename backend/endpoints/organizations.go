@@ -74,12 +74,7 @@ func CreateOrganizationHandler(db *sql.DB, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionID, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w,"Invalid session ID", http.StatusBadRequest)
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
     // Get orgName from form
     orgName := r.FormValue("orgName")
@@ -89,8 +84,11 @@ func CreateOrganizationHandler(db *sql.DB, w http.ResponseWriter, r *http.Reques
     }
 
     // Call backend logic
-    _, err = organizations.CreateOrganization(db, sessionID, orgName)
+    _, err = organizations.CreateOrganization(db, sessionUserID, orgName)
     if err != nil {
+        if validationError(w, err) {
+            return
+        }
         http.Error(w, "Failed to create organization: "+err.Error(), http.StatusInternalServerError)
         log.Println("createOrganization error:", err)
         return
@@ -121,12 +119,7 @@ func DeleteOrganizationHandler(db *sql.DB, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionID, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w,"Invalid session ID", http.StatusBadRequest)
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
     // Parse organization ID
     orgIDStr := r.FormValue("orgid")
@@ -142,7 +135,7 @@ func DeleteOrganizationHandler(db *sql.DB, w http.ResponseWriter, r *http.Reques
     }
 
     // Call the core DeleteOrganization logic
-    err = organizations.DeleteOrganization(db, sessionID, orgID)
+    err = organizations.DeleteOrganization(db, sessionUserID, orgID)
     if err != nil {
         // Check for known error types or just return internal server error
         log.Println("deleteOrganization error:", err)
@@ -151,7 +144,6 @@ func DeleteOrganizationHandler(db *sql.DB, w http.ResponseWriter, r *http.Reques
         // - "user does not have permission" -> 403
         // Here we’ll do a generic 403 if it's a permission or existence issue:
         if err.Error() == "organization does not exist" ||
-           err.Error() == "no session exists for the provided sessionID" ||
            err.Error() == "user is not a member of this organization" ||
            err.Error() == "user does not have permission to delete the organization" {
             http.Error(w, err.Error(), http.StatusForbidden)
@@ -186,12 +178,7 @@ func WithdrawOrgRoleHandler(db *sql.DB, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionID, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w,"Invalid session ID", http.StatusBadRequest)
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
     // Parse orgMemberRoleId from the form
     roleIDStr := r.FormValue("orgMemberRoleId")
@@ -207,7 +194,7 @@ func WithdrawOrgRoleHandler(db *sql.DB, w http.ResponseWriter, r *http.Request)
     }
 
     // Call the core logic to remove the role
-    err = organizations.WithdrawOrgRole(db, sessionID, orgMemberRoleID)
+    err = organizations.WithdrawOrgRole(db, sessionUserID, orgMemberRoleID)
     if err != nil {
         // You might want more granular error handling here if needed:
         // e.g., 403 Forbidden for permission errors, 404 if role not found, etc.
@@ -222,7 +209,7 @@ func WithdrawOrgRoleHandler(db *sql.DB, w http.ResponseWriter, r *http.Request)
 
 // AssignOrgRoleHandler handles POST requests to assign a new role (newRoleID)
 // to User B (userID) in an organization (orgID) on /assign-org-role.
-// The acting user is determined by the session cookie (sessionID).
+// The acting user is the authenticated user of the request.
 func AssignOrgRoleHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
     if r.Method != http.MethodPost {
         http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -241,12 +228,7 @@ func AssignOrgRoleHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionID, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w,"Invalid session ID", http.StatusBadRequest)
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
     // Parse form fields for userID, orgID, and newRoleID (User B, organization, role)
     userIDStr := r.FormValue("userID")
@@ -283,7 +265,7 @@ func AssignOrgRoleHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
     }
 
     // Attempt to assign the role
-    err = organizations.AssignOrgRole(db, sessionID, userID, orgID, newRoleID)
+    err = organizations.AssignOrgRole(db, sessionUserID, userID, orgID, newRoleID)
     if err != nil {
         log.Println("assignOrgRole error:", err)
         // Here you could do more nuanced checks for permissions (403) vs. not found (404).
@@ -393,13 +375,7 @@ func AddOrgMemberHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
         return
     }
 
-	session := r.FormValue("sessionid")
-	sessionid, err := strconv.ParseInt(session, 10, 64)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		log.Println(err.Error())
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	user := r.FormValue("userid")
 	userid, err := strconv.Atoi(user)
@@ -425,7 +401,7 @@ func AddOrgMemberHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = organizations.AddOrgMember(db, sessionid, userid, roleid, orgid)
+	err = organizations.AddOrgMember(db, sessionUserID, userid, roleid, orgid)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		log.Println(err.Error())
@@ -448,13 +424,7 @@ func RemoveOrgMemberHandler(db *sql.DB, w http.ResponseWriter, r *http.Request)
         return
     }
 
-	session := r.FormValue("sessionid")
-	sessionid, err := strconv.ParseInt(session, 10, 64)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		log.Println(err.Error())
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	member := r.FormValue("memberid")
 	memberid, err := strconv.Atoi(member)
@@ -464,7 +434,7 @@ func RemoveOrgMemberHandler(db *sql.DB, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = organizations.RemoveOrgMember(db, sessionid, memberid)
+	err = organizations.RemoveOrgMember(db, sessionUserID, memberid)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		log.Println(err.Error())
@@ -489,13 +459,7 @@ func UpdateOrgHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionid, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		log.Println(err.Error())
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	org := r.FormValue("orgid")
 	orgid, err := strconv.Atoi(org)
@@ -512,8 +476,11 @@ func UpdateOrgHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		Name: name,
 	}
 
-	err = organizations.UpdateOrg(db, sessionid, orgid, updated_org)
+	err = organizations.UpdateOrg(db, sessionUserID, orgid, updated_org)
 	if err != nil {
+		if validationError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Println(err.Error())
 		return
@@ -0,0 +1,120 @@
+package endpoints
+
+import (
+	"brickedup/backend/preferences"
+	"brickedup/backend/utils"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GetPreferencesHandler handles GET requests on /preferences to return the
+// preferences of the logged-in user, defaults included.
+func GetPreferencesHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	prefs, err := preferences.GetPreferences(db, getSessionUser(r))
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	json, err := json.Marshal(prefs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// UpdatePreferencesHandler handles POST requests on /update-preferences to
+// change the preferences of the logged-in user. Only the form fields sent
+// are changed: `timezone`, `locale`, `date_format`, `digest`, `default_org`
+// (empty or 0 to clear it) and `notify_<event>` with a comma-separated list
+// of channels (empty to mute the event).
+func UpdatePreferencesHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	var update utils.PreferencesUpdate
+	for field, dest := range map[string]**string{
+		"timezone":    &update.TimeZone,
+		"locale":      &update.Locale,
+		"date_format": &update.DateFormat,
+		"digest":      &update.Digest,
+	} {
+		if _, ok := r.Form[field]; ok {
+			value := r.FormValue(field)
+			*dest = &value
+		}
+	}
+
+	if _, ok := r.Form["default_org"]; ok {
+		orgid := 0
+		if value := r.FormValue("default_org"); value != "" {
+			var err error
+			orgid, err = strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid default_org", http.StatusBadRequest)
+				return
+			}
+		}
+		update.DefaultOrg = &orgid
+	}
+
+	for field := range r.Form {
+		event, ok := strings.CutPrefix(field, "notify_")
+		if !ok {
+			continue
+		}
+		if update.Notifications == nil {
+			update.Notifications = map[string][]string{}
+		}
+		channels := []string{}
+		for _, channel := range strings.Split(r.FormValue(field), ",") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				channels = append(channels, channel)
+			}
+		}
+		update.Notifications[event] = channels
+	}
+
+	prefs, err := preferences.UpdatePreferences(db, getSessionUser(r), update)
+	if validationError(w, err) {
+		return
+	}
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	json, err := json.Marshal(prefs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
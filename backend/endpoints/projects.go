@@ -46,7 +46,7 @@ func GetProjHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 }
 // CreateTagHandler handles POST requests to create a new tag associated with a
 // project on /create-tag.
-// It validates the session, form inputs, and calls the CreateTag logic function.
+// It validates the form inputs and calls the CreateTag logic function.
 func CreateTagHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -64,12 +64,7 @@ func CreateTagHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionID, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w,"Invalid session ID", http.StatusBadRequest)
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 
 	// Parse form values
@@ -83,8 +78,11 @@ func CreateTagHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	tagColor := r.FormValue("color")
 
 	// Call core logic
-	_, err = projects.CreateTag(db, sessionID, projectID, tagName, tagColor)
+	_, err = projects.CreateTag(db, sessionUserID, projectID, tagName, tagColor)
 	if err != nil {
+		if validationError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Println("createTag error:", err)
 		return
@@ -95,7 +93,7 @@ func CreateTagHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 }
 
 // DeleteTagHandler handles DELETE requests to delete a tag by its ID on /delete-tag.
-// The session is used to validate whether the user has permission to delete the tag.
+// The authenticated user must have permission to delete the tag.
 func DeleteTagHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -114,12 +112,7 @@ func DeleteTagHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionID, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w,"Invalid session ID", http.StatusBadRequest)
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	// Parse tag ID from form
 	tagIDStr := r.FormValue("tagid")
@@ -135,7 +128,7 @@ func DeleteTagHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call core logic
-	err = projects.DeleteTag(db, sessionID, tagID)
+	err = projects.DeleteTag(db, sessionUserID, tagID)
 	if err != nil {
 		http.Error(w, "Failed to delete tag: "+err.Error(), http.StatusForbidden)
 		log.Println("deleteTag error:", err)
@@ -148,7 +141,7 @@ func DeleteTagHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 // ArchiveProjHandler handles POST requests to archive a project by its ID on 
 // /archive-proj.
-// The session is used to validate whether the user has the necessary permissions.
+// The authenticated user must have the necessary permissions.
 func ArchiveProjHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -166,12 +159,7 @@ func ArchiveProjHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionID, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w,"Invalid session ID", http.StatusBadRequest)
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	// Parse form values
 	projectID, err := strconv.Atoi(r.FormValue("projectid"))
@@ -180,7 +168,7 @@ func ArchiveProjHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = projects.ArchiveProj(db, sessionID, projectID)
+	err = projects.ArchiveProj(db, sessionUserID, projectID)
 	if err != nil {
 		http.Error(w, "Failed to archive project: "+err.Error(), http.StatusForbidden)
 		log.Println("ArchiveProj error:", err)
@@ -328,13 +316,7 @@ func AddProjMemberHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
         return
     }
 
-	session := r.FormValue("sessionid")
-	sessionid, err := strconv.ParseInt(session, 10, 64)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		log.Println(err.Error())
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	user := r.FormValue("userid")
 	userid, err := strconv.Atoi(user)
@@ -360,7 +342,7 @@ func AddProjMemberHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = projects.AddProjMember(db, sessionid, userid, roleid, projectid)
+	err = projects.AddProjMember(db, sessionUserID, userid, roleid, projectid)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		log.Println(err.Error())
@@ -383,13 +365,7 @@ func RemoveProjMemberHandler(db *sql.DB, w http.ResponseWriter, r *http.Request)
         return
     }
 
-	session := r.FormValue("sessionid")
-	sessionid, err := strconv.ParseInt(session, 10, 64)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		log.Println(err.Error())
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	member := r.FormValue("memberid")
 	memberid, err := strconv.Atoi(member)
@@ -399,7 +375,7 @@ func RemoveProjMemberHandler(db *sql.DB, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = projects.RemoveProjMember(db, sessionid, memberid)
+	err = projects.RemoveProjMember(db, sessionUserID, memberid)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		log.Println(err.Error())
@@ -447,13 +423,7 @@ func CreateProjHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
         return
     }
 
-	session := r.FormValue("sessionid")
-	sessionid, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		log.Println(err.Error())
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	org := r.FormValue("orgid")
 	orgid, err := strconv.Atoi(org)
@@ -474,8 +444,11 @@ func CreateProjHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = projects.CreateProj(db, sessionid, orgid, name, budget, charter)
+	err = projects.CreateProj(db, sessionUserID, orgid, name, budget, charter)
 	if err != nil {
+		if validationError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Println(err.Error())
 		return
@@ -499,13 +472,7 @@ func UpdateProjHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionid, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		log.Println(err.Error())
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	proj := r.FormValue("projectid")
 	projid, err := strconv.Atoi(proj)
@@ -533,8 +500,11 @@ func UpdateProjHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		Charter: charter,
 	}
 
-	err = projects.UpdateProject(db, sessionid, projid, updated_org)
+	err = projects.UpdateProject(db, sessionUserID, projid, updated_org)
 	if err != nil {
+		if validationError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Println(err.Error())
 		return
@@ -0,0 +1,262 @@
+package endpoints
+
+import (
+	"brickedup/backend/sessions"
+	"brickedup/backend/utils"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SessionCookie is the name of the cookie that carries the session token.
+	SessionCookie = "session"
+
+	// RefreshCookie is the name of the cookie that carries the refresh token.
+	// It is only sent along to /refresh.
+	RefreshCookie = "refresh"
+)
+
+// setSessionCookies hands the session and refresh tokens to the browser as
+// HttpOnly, Secure and SameSite cookies, so they are never exposed to page
+// scripts. Only "remember me" sessions get persistent cookies; the cookies of
+// other sessions are dropped when the browser is closed.
+func setSessionCookies(w http.ResponseWriter, session *utils.SessionData) {
+	var expires time.Time
+	if session.Remember {
+		expires = session.AbsoluteExpires
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    session.SessionID,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     RefreshCookie,
+		Value:    session.RefreshToken,
+		Path:     "/refresh",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// clearSessionCookies tells the browser to drop the session and refresh cookies.
+func clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     RefreshCookie,
+		Value:    "",
+		Path:     "/refresh",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// SessionToken returns the session token sent with the request.
+// The token is read from the session cookie. The `sessionid` form field is
+// only consulted as a fallback for older clients and is deprecated.
+func SessionToken(r *http.Request) string {
+	if cookie, err := r.Cookie(SessionCookie); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	return r.FormValue("sessionid")
+}
+
+// getSession returns the session authenticated by MainHandler.
+// It must only be called from handlers of non-public endpoints.
+func getSession(r *http.Request) *utils.Session {
+	session, ok := sessions.FromContext(r.Context())
+	if !ok {
+		return &utils.Session{}
+	}
+	return session
+}
+
+// getSessionUser returns the ID of the user authenticated by MainHandler.
+// It must only be called from handlers of non-public endpoints.
+func getSessionUser(r *http.Request) int {
+	return getSession(r).UserID
+}
+
+// clientIP returns the IP address the request was sent from.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// LogoutHandler handles POST requests to end the current session on /logout.
+func LogoutHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method unsupported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getSession(r)
+
+	err := sessions.RevokeSession(db, session.UserID, session.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	clearSessionCookies(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetSessionsHandler handles GET requests to list the active sessions of the
+// logged-in user on /sessions.
+func GetSessionsHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	current := getSession(r)
+
+	list, err := sessions.GetUserSessions(db, current.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	for i := range list {
+		list[i].Current = list[i].ID == current.ID
+	}
+
+	json, err := json.Marshal(list)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// RevokeSessionHandler handles DELETE requests to end one of the logged-in
+// user's sessions on /revoke-session.
+// It takes the `id` of the session as a URL parameter.
+func RevokeSessionHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for id", http.StatusBadRequest)
+		return
+	}
+
+	session := getSession(r)
+
+	err = sessions.RevokeSession(db, session.UserID, id)
+	if err != nil {
+		if errors.Is(err, sessions.ErrSessionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	if id == session.ID {
+		clearSessionCookies(w)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RevokeOtherSessionsHandler handles DELETE requests to end every session of
+// the logged-in user except the current one on /revoke-other-sessions.
+func RevokeOtherSessionsHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session := getSession(r)
+
+	err := sessions.RevokeOtherSessions(db, session.UserID, session.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RefreshHandler handles POST requests to exchange a refresh token for a new
+// session on /refresh. The refresh token is read from the refresh cookie or
+// the `refresh_token` form field. Reusing a spent refresh token revokes the
+// session it belongs to.
+func RefreshHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method unsupported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.FormValue("refresh_token")
+	if cookie, err := r.Cookie(RefreshCookie); err == nil && cookie.Value != "" {
+		token = cookie.Value
+	}
+
+	session, err := sessions.RefreshSession(db, token)
+	if err != nil {
+		if errors.Is(err, sessions.ErrInvalidRefreshToken) ||
+			errors.Is(err, sessions.ErrRefreshTokenReused) {
+			clearSessionCookies(w)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	json, err := json.Marshal(session)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	setSessionCookies(w, session)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
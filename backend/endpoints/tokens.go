@@ -0,0 +1,132 @@
+package endpoints
+
+import (
+	"brickedup/backend/tokens"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BearerToken returns the personal access token sent in the Authorization
+// header, and whether the request carries one at all.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// GetTokensHandler handles GET requests to list the personal access tokens
+// of the logged-in user on /tokens.
+func GetTokensHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	list, err := tokens.GetUserTokens(db, getSessionUser(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	json, err := json.Marshal(list)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// CreateTokenHandler handles POST requests to create a personal access token
+// on /create-token. It takes the `name` of the token, its `scopes` separated
+// by commas or spaces, and optionally `expires_in_days`.
+// The token is part of the response and cannot be retrieved again.
+func CreateTokenHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	days := tokens.DefaultLifetimeDays
+	if value := r.FormValue("expires_in_days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid parameter for expires_in_days", http.StatusBadRequest)
+			return
+		}
+	}
+
+	token, err := tokens.CreateToken(db, getSessionUser(r), r.FormValue("name"),
+		tokens.ParseScopes(r.FormValue("scopes")), days)
+
+	if err != nil {
+		if validationError(w, err) {
+			return
+		}
+		if errors.Is(err, tokens.ErrTokenNameTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	json, err := json.Marshal(token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(json)
+}
+
+// RevokeTokenHandler handles DELETE requests to revoke one of the logged-in
+// user's personal access tokens on /revoke-token.
+// It takes the `id` of the token as a URL parameter.
+func RevokeTokenHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Invalid parameter for id", http.StatusBadRequest)
+		return
+	}
+
+	err = tokens.RevokeToken(db, getSessionUser(r), id)
+	if err != nil {
+		if errors.Is(err, tokens.ErrTokenNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -5,12 +5,18 @@ import (
 	"brickedup/backend/utils"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // LoginHandler handles POST requests to the user logins on /login.
+// Setting `remember=true` starts a longer-lived "remember me" session.
+// Users with two-factor authentication get an MFA challenge instead of a
+// session, to be completed on /login/2fa.
 func LoginHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method unsupported", http.StatusMethodNotAllowed)
@@ -20,11 +26,31 @@ func LoginHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	email := r.FormValue("email")
 	password := r.FormValue("password")
+	remember := r.FormValue("remember") == "true"
 
-	session, err := users.Login(db, email, password)
+	session, challenge, err := users.Login(db, email, password, remember, clientIP(r), r.UserAgent())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Println(err.Error())
+		switch {
+		case errors.Is(err, users.ErrInvalidCredentials):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		case errors.Is(err, users.ErrEmailNotVerified), errors.Is(err, users.ErrDeactivated):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, users.ErrTooManyAttempts):
+			wait, _ := users.LoginRetryAfter(db, email, clientIP(r))
+			throttled(w, err, wait)
+		default:
+			// Do not leak database or hashing errors to the client
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Println(err.Error())
+		}
+		return
+	}
+
+	if challenge != nil {
+		json, _ := json.Marshal(challenge)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(json)
 		return
 	}
 
@@ -35,6 +61,7 @@ func LoginHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setSessionCookies(w, session)
 	w.WriteHeader(http.StatusOK)
 	w.Write(json)
 }
@@ -52,6 +79,17 @@ func SignupHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 	err := users.Signup(db, email, password)
 	if err != nil {
+		if validationError(w, err) {
+			return
+		}
+		if errors.Is(err, users.ErrEmailTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, users.ErrResendThrottled) {
+			throttled(w, err, users.ResendInterval)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Println(err.Error())
 		return
@@ -59,6 +97,46 @@ func SignupHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 }
 
+// ResendVerificationHandler handles POST requests on /resend-verification
+// to send a new verification code to the unverified account with the given
+// `email`. The response is the same whether or not the email is registered,
+// including 429 for emails and clients asking too often.
+func ResendVerificationHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method unsupported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	email := r.FormValue("email")
+
+	if email == "" {
+		http.Error(w, "Missing email", http.StatusBadRequest)
+		return
+	}
+
+	err := users.ResendVerification(db, email, clientIP(r), r.UserAgent())
+	if errors.Is(err, users.ErrResendThrottled) {
+		throttled(w, err, users.ResendInterval)
+		return
+	} else if err != nil {
+		log.Println(err.Error())
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// throttled responds with 429 and tells the client to retry after `wait`.
+func throttled(w http.ResponseWriter, err error, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+}
+
 // VerifyHandler handles GET requests to verify the user email on /verify.
 // Takes in `code` as a URL parameter.
 func VerifyHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
@@ -76,6 +154,10 @@ func VerifyHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 	err := users.VerifyUser(code, db)
 	if err != nil {
+		if errors.Is(err, users.ErrInvalidVerificationCode) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Println(err.Error())
 		return
@@ -86,6 +168,8 @@ func VerifyHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 
 // UpdateUserHandler handles PATCH requests to update the 
 // logged-in user's information on /update-user.
+// When the password is changed, `revoke_sessions=true` ends all other sessions.
+// The email is changed on /change-email instead.
 func UpdateUserHandler (db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPatch {
 		http.Error(w,"Method not allowed", http.StatusMethodNotAllowed)
@@ -98,12 +182,7 @@ func UpdateUserHandler (db *sql.DB, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := r.FormValue("sessionid")
-	sessionID, err := strconv.Atoi(session)
-	if err != nil {
-		http.Error(w,"Invalid session ID", http.StatusBadRequest)
-		return
-	}
+	sessionUserID := getSessionUser(r)
 
 	var user utils.User
 
@@ -111,9 +190,13 @@ func UpdateUserHandler (db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	user.Email = r.FormValue("email")
 	user.Password = r.FormValue("password")
 	user.Avatar = r.FormValue("avatar")
+	revokeSessions := r.FormValue("revoke_sessions") == "true"
 
-	err = users.UpdateUser(db, sessionID, &user) 
+	err = users.UpdateUser(db, sessionUserID, &user, revokeSessions, getSession(r).ID)
 	if err != nil {
+		if validationError(w, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Println(err.Error())
 		return
@@ -145,7 +228,7 @@ func GetUserHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	}
 
 
-	user, err := users.GetUser(db, userid)
+	user, err := users.GetUser(db, userid, getSessionUser(r))
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
@@ -158,7 +241,8 @@ func GetUserHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 }
 
 // GetAllUsersHandler handles GET requests to retrieve all verified users. 
-// on /get-all-users.
+// on /get-all-users. Only their IDs are returned; /users/search lists
+// users a page at a time with their names and avatars.
 func GetAllUsersHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	userids, err := users.GetAllUsers(db)
 	if err != nil {
@@ -179,36 +263,281 @@ func GetAllUsersHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	w.Write(json)
 }
 
-// DeleteUserHandler handles DELETE requests to 
-// delete the currently logged-in user on /delete-user.
-// It reads the session cookie, validates it, and calls DeleteUser to remove all user data.
+// SearchUsersHandler handles GET requests on /users/search to look users up
+// by name or email. It takes the search `q`, `match=prefix` to match the
+// start of names, emails and the words of names instead of anywhere, an
+// `orgid` or `projectid` to only list its members, the `cursor` of the page
+// to get and the page size `limit`. It responds with a page of user
+// summaries and the cursor of the next page.
+func SearchUsersHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	search := utils.UserSearch{
+		Query:  query.Get("q"),
+		Prefix: query.Get("match") == "prefix",
+		Cursor: query.Get("cursor"),
+	}
+
+	var err error
+	if value := query.Get("orgid"); value != "" {
+		search.OrgID, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid parameter for orgid", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("projectid"); value != "" {
+		search.ProjectID, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid parameter for projectid", http.StatusBadRequest)
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		search.Limit, err = strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid parameter for limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := users.SearchUsers(db, getSessionUser(r), search)
+	if err != nil {
+		if validationError(w, err) {
+			return
+		}
+		if errors.Is(err, users.ErrNotMember) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	json, err := json.Marshal(page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// DeleteUserHandler handles DELETE requests on /delete-user to deactivate
+// the logged-in user's account. They are logged out everywhere and emailed
+// a link to reactivate the account, which is anonymized once the grace
+// period is over. It responds with when that happens, or 409 Conflict while
+// the user is the only admin of an organization.
 func DeleteUserHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if err := r.ParseForm(); err != nil {
-        http.Error(w, "Invalid form data", http.StatusBadRequest)
-        return
-    }
+	deadline, err := users.DeactivateUser(db, getSessionUser(r))
+	if err != nil {
+		if errors.Is(err, users.ErrLastAdmin) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		// The account may be deactivated even if the email failed
+		if deadline.IsZero() {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Println(err.Error())
+			return
+		}
+		log.Println(err.Error())
+	}
 
-	err := r.ParseForm()
+	json, err := json.Marshal(map[string]time.Time{"anonymize": deadline})
 	if err != nil {
-		http.Error(w, "Invalid form", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	clearSessionCookies(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(json)
+}
+
+// RequestReactivationHandler handles POST requests on /request-reactivation
+// to email a new reactivation link to the deactivated account with the
+// given `email`. The response is the same whatever the state of the account.
+func RequestReactivationHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	email := r.FormValue("email")
+
+	if email == "" {
+		http.Error(w, "Missing email", http.StatusBadRequest)
+		return
+	}
+
+	err := users.RequestReactivation(db, email)
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ReactivateHandler handles POST requests on /reactivate to reactivate a
+// deactivated account with the `token` from the emailed link. The user can
+// log in again afterwards.
+func ReactivateHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	err := users.ReactivateUser(db, r.FormValue("token"))
+	if err != nil {
+		if errors.Is(err, users.ErrInvalidReactivation) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// ForgotPasswordHandler handles POST requests to request a password reset
+// link on /forgot-password. It takes the `email` of the account.
+// The response is the same whether or not the email is registered,
+// including 429 for emails and clients asking too often.
+func ForgotPasswordHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method unsupported", http.StatusMethodNotAllowed)
 		return
 	}
 
-	session := r.FormValue("sessionid")
+	r.ParseForm()
+	email := r.FormValue("email")
+
+	if email == "" {
+		http.Error(w, "Missing email", http.StatusBadRequest)
+		return
+	}
 
-	// Call backend logic to delete the user
-	if err := users.DeleteUser(db, session); err != nil {
-		http.Error(w, "Failed to delete user: "+err.Error(), http.StatusInternalServerError)
-		log.Println("deleteUser error:", err)
+	err := users.ForgotPassword(db, email, clientIP(r), r.UserAgent())
+	if errors.Is(err, users.ErrResetThrottled) {
+		throttled(w, err, users.ResetInterval)
 		return
+	} else if err != nil {
+		log.Println(err.Error())
 	}
 
-	// Respond with success
 	w.WriteHeader(http.StatusOK)
 }
 
+// ResetPasswordHandler handles POST requests to set a new password on
+// /reset-password. It takes the `token` from the reset link and the new `password`.
+func ResetPasswordHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method unsupported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+
+	err := users.ResetPassword(db, token, password)
+	if err != nil {
+		if validationError(w, err) {
+			return
+		}
+		if errors.Is(err, users.ErrInvalidResetToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println(err.Error())
+		return
+	}
+
+	clearSessionCookies(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+// ChangeEmailHandler handles POST requests on /change-email to change the
+// logged-in user's address to `email`, which takes the current `password`.
+// The change is pending until confirmed on /confirm-email with the link sent
+// to the new address, so the response is 202.
+func ChangeEmailHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	userid := getSessionUser(r)
+
+	err := users.RequestEmailChange(db, userid, r.FormValue("password"), r.FormValue("email"), clientIP(r), r.UserAgent())
+	if err != nil {
+		if validationError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, users.ErrInvalidCredentials):
+			http.Error(w, "Wrong password", http.StatusForbidden)
+		case errors.Is(err, users.ErrEmailTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, users.ErrTooManyAttempts):
+			wait, _ := users.EmailChangeRetryAfter(db, userid, clientIP(r))
+			throttled(w, err, wait)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Println(err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmEmailHandler handles POST requests on /confirm-email to complete an
+// email change with the `token` from the confirmation link.
+func ConfirmEmailHandler(db *sql.DB, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.ParseForm()
+	err := users.ConfirmEmailChange(db, r.FormValue("token"))
+	if err != nil {
+		switch {
+		case errors.Is(err, users.ErrInvalidEmailChange):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, users.ErrEmailTaken):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, users.ErrDeactivated):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			log.Println(err.Error())
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
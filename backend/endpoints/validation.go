@@ -0,0 +1,23 @@
+package endpoints
+
+import (
+	"brickedup/backend/validate"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// validationError responds with 422 and the rejected fields as JSON if `err`
+// is a validation error, and reports whether it did.
+func validationError(w http.ResponseWriter, err error) bool {
+	var fields validate.Errors
+	if !errors.As(err, &fields) {
+		return false
+	}
+
+	json, _ := json.Marshal(map[string]validate.Errors{"errors": fields})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	w.Write(json)
+	return true
+}
@@ -0,0 +1,205 @@
+package exports
+
+import (
+	"archive/zip"
+	"brickedup/backend/avatars"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// archive is the content of data.json. Every section lists rows as objects
+// keyed by column name.
+type archive struct {
+	Exported            time.Time        `json:"exported"`
+	Profile             []map[string]any `json:"profile"`
+	Preferences         []map[string]any `json:"preferences"`
+	Notifications       []map[string]any `json:"notification_settings"`
+	Identities          []map[string]any `json:"identities"`
+	Organizations       []map[string]any `json:"organizations"`
+	Projects            []map[string]any `json:"projects"`
+	Issues              []map[string]any `json:"issues"`
+	Reminders           []map[string]any `json:"reminders"`
+	Sessions            []map[string]any `json:"sessions"`
+	LoginAttempts       []map[string]any `json:"login_attempts"`
+	APITokens           []map[string]any `json:"api_tokens"`
+	InvitationsSent     []map[string]any `json:"invitations_sent"`
+	InvitationsReceived []map[string]any `json:"invitations_received"`
+	EmailChanges        []map[string]any `json:"email_changes"`
+	DataExports         []map[string]any `json:"data_exports"`
+}
+
+// section returns the rows of the query as objects keyed by column name.
+func section(db *sql.DB, query string, args ...any) ([]map[string]any, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// collect gathers everything stored about the user.
+func collect(db *sql.DB, userid int) (*archive, error) {
+	a := &archive{Exported: time.Now()}
+
+	sections := []struct {
+		dest  *[]map[string]any
+		query string
+	}{
+		{&a.Profile, `SELECT id, name, email, verified, created, totp_enabled,
+			password != '' AS has_password
+			FROM USER WHERE id = ?1`},
+		{&a.Preferences, `SELECT timezone, locale, date_format, default_org, digest
+			FROM USER_PREFERENCES WHERE userid = ?1`},
+		{&a.Notifications, `SELECT event, channels
+			FROM NOTIFICATION_SETTING WHERE userid = ?1 ORDER BY event`},
+		{&a.Identities, `SELECT provider, subject, email, created
+			FROM USER_IDENTITY WHERE userid = ?1`},
+		{&a.Organizations, `SELECT o.id, o.name, r.name AS role,
+			r.can_read, r.can_write, r.can_exec
+			FROM ORG_MEMBER m
+			JOIN ORGANIZATION o ON o.id = m.orgid
+			LEFT JOIN ORG_MEMBER_ROLE mr ON mr.memberid = m.id
+			LEFT JOIN ORG_ROLE r ON r.id = mr.roleid
+			WHERE m.userid = ?1 ORDER BY o.id`},
+		{&a.Projects, `SELECT p.id, p.orgid, p.name, r.name AS role,
+			r.can_read, r.can_write, r.can_exec
+			FROM PROJECT_MEMBER m
+			JOIN PROJECT p ON p.id = m.projectid
+			LEFT JOIN PROJECT_MEMBER_ROLE mr ON mr.memberid = m.id
+			LEFT JOIN PROJECT_ROLE r ON r.id = mr.roleid
+			WHERE m.userid = ?1 ORDER BY p.id`},
+		{&a.Issues, `SELECT i.id, pi.projectid, i.title, i.desc, i.created,
+			i.completed, i.cost, i.priority
+			FROM USER_ISSUES ui
+			JOIN ISSUE i ON i.id = ui.issueid
+			LEFT JOIN PROJECT_ISSUES pi ON pi.issueid = i.id
+			WHERE ui.userid = ?1 ORDER BY i.id`},
+		{&a.Reminders, `SELECT r.id, r.issueid, i.title
+			FROM REMINDER r JOIN ISSUE i ON i.id = r.issueid
+			WHERE r.userid = ?1 ORDER BY r.id`},
+		{&a.Sessions, `SELECT created, last_seen, expires, absolute_expires,
+			remember, ip, user_agent
+			FROM SESSION WHERE userid = ?1 ORDER BY id`},
+		{&a.LoginAttempts, `SELECT created, method, email, ip, user_agent,
+			success, reason
+			FROM LOGIN_ATTEMPT WHERE userid = ?1 ORDER BY id`},
+		{&a.APITokens, `SELECT name, scopes, created, expires, last_used
+			FROM API_TOKEN WHERE userid = ?1 ORDER BY id`},
+		{&a.InvitationsSent, `SELECT kind, targetid, email, max_uses, uses,
+			created, expires
+			FROM INVITATION WHERE inviter = ?1 ORDER BY id`},
+		{&a.InvitationsReceived, `SELECT i.kind, i.targetid, i.inviter,
+			i.uses > 0 AS accepted, i.created, i.expires
+			FROM INVITATION i JOIN USER u ON u.email = i.email
+			WHERE u.id = ?1 ORDER BY i.id`},
+		{&a.EmailChanges, `SELECT email, created, expires
+			FROM EMAIL_CHANGE WHERE userid = ?1`},
+		{&a.DataExports, `SELECT id, status, created, finished, expires
+			FROM DATA_EXPORT WHERE userid = ?1 ORDER BY id`},
+	}
+
+	for _, s := range sections {
+		rows, err := section(db, s.query, userid)
+		if err != nil {
+			return nil, err
+		}
+		*s.dest = rows
+	}
+
+	if len(a.Profile) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return a, nil
+}
+
+// BuildJSON returns everything stored about the user as indented JSON, the
+// data.json of the archive. It returns sql.ErrNoRows for unknown users.
+func BuildJSON(db *sql.DB, userid int) ([]byte, error) {
+	a, err := collect(db, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(a, "", "\t")
+}
+
+// Build returns the ZIP archive of everything stored about the user. It
+// returns sql.ErrNoRows for unknown users.
+func Build(db *sql.DB, userid int) ([]byte, error) {
+	data, err := BuildJSON(db, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	avatar, err := avatars.Get(db, userid, avatars.Sizes[len(avatars.Sizes)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	now := time.Now()
+	err = addFile(zw, "data.json", data, now)
+	if err == nil && avatar.Version != "" {
+		err = addFile(zw, "avatar.png", avatar.Data, avatar.Modified)
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// addFile adds a file named `name` with `content`, last modified at
+// `modified`, to the archive.
+func addFile(zw *zip.Writer, name string, content []byte, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(content)
+	return err
+}
@@ -0,0 +1,106 @@
+package exports
+
+import (
+	"archive/zip"
+	"brickedup/backend/avatars"
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/utils"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestBuildJSON(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	data, err := BuildJSON(db, 1)
+	if err != nil {
+		t.Fatalf("BuildJSON returned error: %v", err)
+	}
+
+	var a archive
+	if err = json.Unmarshal(data, &a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Exported.IsZero() {
+		t.Error("expected the export time")
+	}
+
+	if len(a.Profile) != 1 || a.Profile[0]["email"] != "john.doe@example.com" {
+		t.Errorf("unexpected profile %v", a.Profile)
+	}
+	if len(a.Organizations) != 1 || a.Organizations[0]["role"] != "Admin" {
+		t.Errorf("expected John to be an Admin of one organization, got %v", a.Organizations)
+	}
+	if len(a.Projects) != 2 {
+		t.Errorf("expected two projects, got %v", a.Projects)
+	}
+	if len(a.Issues) != 1 {
+		t.Errorf("expected one assigned issue, got %v", a.Issues)
+	}
+	if len(a.Sessions) != 2 {
+		t.Errorf("expected two sessions, got %v", a.Sessions)
+	}
+
+	// No secrets
+	for _, secret := range []string{"$2a$", `"password":`, `"token":`, "totp_secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("export contains %q", secret)
+		}
+	}
+
+	if _, err = BuildJSON(db, 42); err != sql.ErrNoRows {
+		t.Errorf("expected sql.ErrNoRows for an unknown user, got %v", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewGray(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := avatars.Upload(db, 2, img.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	for userid, want := range map[int][]string{1: {"data.json"}, 2: {"data.json", "avatar.png"}} {
+		data, err := Build(db, userid)
+		if err != nil {
+			t.Fatalf("Build returned error: %v", err)
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(want, ",") {
+			t.Errorf("user %d: expected files %v, got %v", userid, want, names)
+		}
+
+		f, err := zr.Open("data.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(f)
+		if !json.Valid(content) {
+			t.Errorf("user %d: data.json is not valid JSON", userid)
+		}
+	}
+}
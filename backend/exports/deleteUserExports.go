@@ -0,0 +1,42 @@
+package exports
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// DeleteUserExports deletes all data exports of the user within the
+// transaction. It returns the keys of their archives, to delete from the
+// blob store once the transaction is committed.
+func DeleteUserExports(tx *sql.Tx, userid int) ([]string, error) {
+	rows, err := tx.Query(
+		`SELECT blob FROM DATA_EXPORT WHERE userid = ? AND blob IS NOT NULL`,
+		userid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`DELETE FROM DATA_EXPORT WHERE userid = ?`, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
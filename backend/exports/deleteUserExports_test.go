@@ -0,0 +1,41 @@
+package exports
+
+import (
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestDeleteUserExports(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+
+	runExport(t, db, 1)
+	if _, err := Request(db, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	keys, err := DeleteUserExports(tx, 1)
+	if err != nil {
+		t.Fatalf("DeleteUserExports returned error: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "exports/1/1.zip" {
+		t.Errorf("expected the key of the built archive, got %v", keys)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if exports, _ := GetExports(db, 1); len(exports) != 0 {
+		t.Errorf("expected no exports, got %+v", exports)
+	}
+}
@@ -0,0 +1,32 @@
+package exports
+
+import (
+	"brickedup/backend/blobs"
+	"brickedup/backend/utils"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Download returns the archive the download `token` was emailed for, if it
+// belongs to the user and has not expired.
+func Download(db *sql.DB, userid int, token string) ([]byte, error) {
+	var key string
+	err := db.QueryRow(
+		`SELECT blob FROM DATA_EXPORT
+		WHERE token = ? AND userid = ? AND status = ? AND expires > ?`,
+		utils.HashToken(token), userid, StatusReady, time.Now()).Scan(&key)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidDownload
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, _, err := blobs.Get(key)
+	if err == blobs.ErrNotFound {
+		return nil, ErrInvalidDownload
+	}
+	return data, err
+}
@@ -0,0 +1,44 @@
+package exports
+
+import (
+	"archive/zip"
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/utils"
+	"bytes"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestDownload(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+
+	token := runExport(t, db, 1)
+
+	data, err := Download(db, 1, token)
+	if err != nil {
+		t.Fatalf("Download returned error: %v", err)
+	}
+	if _, err = zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Errorf("expected a ZIP archive: %v", err)
+	}
+
+	// The link only works for its user, and only until it expires
+	if _, err = Download(db, 2, token); err != ErrInvalidDownload {
+		t.Errorf("expected ErrInvalidDownload for another user, got %v", err)
+	}
+	if _, err = Download(db, 1, "unknown"); err != ErrInvalidDownload {
+		t.Errorf("expected ErrInvalidDownload for an unknown token, got %v", err)
+	}
+
+	_, err = db.Exec(`UPDATE DATA_EXPORT SET expires = ?`, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Download(db, 1, token); err != ErrInvalidDownload {
+		t.Errorf("expected ErrInvalidDownload once expired, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+// Package exports answers data-subject access requests with an archive of
+// everything stored about a user.
+//
+// An archive is a ZIP file holding data.json, with the user's profile,
+// linked identities, memberships and roles, assigned issues, reminders,
+// sessions, login history, access tokens, invitations and pending email
+// changes, and the uploaded avatar, the only file users can upload.
+// Secrets such as password hashes and token digests are left out.
+//
+// Users request archives with Request. They are built in the background by
+// RunPending, after which the user gets an email with a link to download the
+// archive that stops working after LinkLifetime.
+package exports
+
+import (
+	"brickedup/backend/utils"
+	"errors"
+	"time"
+)
+
+// Statuses of data exports.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+	StatusExpired = "expired"
+)
+
+// LinkLifetime is how long download links work, set with
+// EXPORT_LINK_LIFETIME.
+var LinkLifetime = utils.DurationFromEnv("EXPORT_LINK_LIFETIME", 24*time.Hour)
+
+// ErrInvalidDownload is returned when a download token is unknown, expired
+// or belongs to another user.
+var ErrInvalidDownload = errors.New("invalid or expired download link")
@@ -0,0 +1,60 @@
+package exports
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanExport reads an export selected as id, status, created, finished,
+// expires.
+func scanExport(row scanner) (*utils.DataExport, error) {
+	var export utils.DataExport
+	var finished, expires sql.NullTime
+
+	err := row.Scan(&export.ID, &export.Status, &export.Created, &finished, &expires)
+	if err != nil {
+		return nil, err
+	}
+
+	if finished.Valid {
+		export.Finished = &finished.Time
+	}
+	if expires.Valid {
+		export.Expires = &expires.Time
+	}
+
+	return &export, nil
+}
+
+// GetExports returns the data exports the user requested, newest first.
+func GetExports(db *sql.DB, userid int) ([]utils.DataExport, error) {
+	rows, err := db.Query(
+		`SELECT id, status, created, finished, expires
+		FROM DATA_EXPORT
+		WHERE userid = ?
+		ORDER BY created DESC, id DESC`,
+		userid)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exports := []utils.DataExport{}
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, *export)
+	}
+
+	return exports, rows.Err()
+}
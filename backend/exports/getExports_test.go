@@ -0,0 +1,42 @@
+package exports
+
+import (
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestGetExports(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+	mailtest.Record(t)
+
+	if _, err := Request(db, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunPending(db); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Request(db, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	exports, err := GetExports(db, 1)
+	if err != nil {
+		t.Fatalf("GetExports returned error: %v", err)
+	}
+	if len(exports) != 2 || exports[0].Status != StatusPending || exports[1].Status != StatusReady {
+		t.Fatalf("expected a pending and a ready export, newest first, got %+v", exports)
+	}
+	if exports[1].Finished == nil || exports[1].Expires == nil {
+		t.Errorf("expected the ready export to be finished and expire, got %+v", exports[1])
+	}
+
+	if exports, _ = GetExports(db, 2); len(exports) != 0 {
+		t.Errorf("expected no exports of another user, got %+v", exports)
+	}
+}
@@ -0,0 +1,41 @@
+package exports
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Request queues an archive of the user's personal data to be built by
+// RunPending. While one is pending or running, it is returned instead of
+// queueing another.
+func Request(db *sql.DB, userid int) (*utils.DataExport, error) {
+	export, err := scanExport(db.QueryRow(
+		`SELECT id, status, created, finished, expires
+		FROM DATA_EXPORT
+		WHERE userid = ? AND status IN (?, ?)`,
+		userid, StatusPending, StatusRunning))
+
+	if err != sql.ErrNoRows {
+		return export, err
+	}
+
+	export = &utils.DataExport{Status: StatusPending, Created: time.Now()}
+	res, err := db.Exec(
+		`INSERT INTO DATA_EXPORT (userid, status, created) VALUES (?, ?, ?)`,
+		userid, export.Status, export.Created)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	export.ID = int(id)
+
+	return export, nil
+}
@@ -0,0 +1,38 @@
+package exports
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRequest(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	export, err := Request(db, 1)
+	if err != nil {
+		t.Fatalf("Request returned error: %v", err)
+	}
+	if export.Status != StatusPending || export.Finished != nil {
+		t.Errorf("expected a pending export, got %+v", export)
+	}
+
+	// Requesting again while pending returns the same export
+	again, err := Request(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != export.ID {
+		t.Errorf("expected export %d, got %d", export.ID, again.ID)
+	}
+
+	other, err := Request(db, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == export.ID {
+		t.Error("expected another user to get their own export")
+	}
+}
@@ -0,0 +1,175 @@
+package exports
+
+import (
+	"brickedup/backend/blobs"
+	"brickedup/backend/mail"
+	"brickedup/backend/preferences"
+	"brickedup/backend/utils"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// staleAfter is how long after they started running exports are
+// considered abandoned, e.g. by a restart of the server, and marked as
+// failed.
+const staleAfter = time.Hour
+
+// run builds the archive of export `id`, stores it and emails the user the
+// link to download it. The archive is deleted again if the export cannot
+// be completed, as only ready exports are expired.
+func run(db *sql.DB, id int, userid int) error {
+	data, err := Build(db, userid)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("exports/%d/%d.zip", userid, id)
+	if err = blobs.Put(key, data); err != nil {
+		return err
+	}
+
+	if err = publish(db, id, userid, key); err != nil {
+		if err := blobs.Delete(key); err != nil {
+			log.Println(err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// publish emails the user the link to the archive stored at `key`, then
+// marks the export as ready, so no export is ready without the user knowing.
+func publish(db *sql.DB, id int, userid int, key string) error {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	var email string
+	err = db.QueryRow(`SELECT email FROM USER WHERE id = ?`, userid).Scan(&email)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expires := now.Add(LinkLifetime)
+	err = preferences.SendTemplate(db, userid, email, mail.TemplateDataExport, mail.DataExportData{
+		Link:    mail.Link("/data-export", url.Values{"token": {token}}),
+		Expires: expires,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		`UPDATE DATA_EXPORT
+		SET status = ?, token = ?, blob = ?, finished = ?, expires = ?
+		WHERE id = ?`,
+		StatusReady, utils.HashToken(token), key, now, expires, id)
+
+	return err
+}
+
+// expire deletes the archives whose download links expired.
+func expire(db *sql.DB) error {
+	rows, err := db.Query(
+		`SELECT id, blob FROM DATA_EXPORT WHERE status = ? AND expires <= ?`,
+		StatusReady, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	var keys []string
+	for rows.Next() {
+		var id int
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		keys = append(keys, key)
+	}
+	rows.Close()
+
+	for i, id := range ids {
+		if err := blobs.Delete(keys[i]); err != nil {
+			return err
+		}
+
+		_, err := db.Exec(
+			`UPDATE DATA_EXPORT SET status = ?, token = NULL, blob = NULL WHERE id = ?`,
+			StatusExpired, id)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunPending builds the archives of all pending exports, oldest first, and
+// returns how many were built. Exports that fail are marked as such and
+// logged. Archives whose links expired are deleted.
+func RunPending(db *sql.DB) (int, error) {
+	if err := expire(db); err != nil {
+		return 0, err
+	}
+
+	_, err := db.Exec(
+		`UPDATE DATA_EXPORT SET status = ? WHERE status = ? AND started < ?`,
+		StatusFailed, StatusRunning, time.Now().Add(-staleAfter))
+
+	if err != nil {
+		return 0, err
+	}
+
+	built := 0
+	for {
+		var id, userid int
+		err := db.QueryRow(
+			`SELECT id, userid FROM DATA_EXPORT
+			WHERE status = ?
+			ORDER BY created, id LIMIT 1`,
+			StatusPending).Scan(&id, &userid)
+
+		if err == sql.ErrNoRows {
+			return built, nil
+		} else if err != nil {
+			return built, err
+		}
+
+		_, err = db.Exec(
+			`UPDATE DATA_EXPORT SET status = ?, started = ? WHERE id = ?`,
+			StatusRunning, time.Now(), id)
+
+		if err != nil {
+			return built, err
+		}
+
+		if err = run(db, id, userid); err != nil {
+			log.Printf("Failed to export the data of user %d: %v\n", userid, err)
+
+			_, err = db.Exec(
+				`UPDATE DATA_EXPORT SET status = ?, finished = ? WHERE id = ?`,
+				StatusFailed, time.Now(), id)
+
+			if err != nil {
+				return built, err
+			}
+			continue
+		}
+
+		built++
+	}
+}
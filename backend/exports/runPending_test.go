@@ -0,0 +1,171 @@
+package exports
+
+import (
+	"brickedup/backend/blobs"
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/mail"
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// downloadRegex finds the download link in the text of an email.
+var downloadRegex = regexp.MustCompile(`\S+/data-export\?token=\S+`)
+
+// runExport requests and builds an export of the user and returns the
+// token of the emailed download link.
+func runExport(t *testing.T, db *sql.DB, userid int) string {
+	t.Helper()
+	outbox := mailtest.Record(t)
+
+	if _, err := Request(db, userid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunPending(db); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := outbox.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected the download link to be emailed, got %d emails", len(sent))
+	}
+	link, err := url.Parse(downloadRegex.FindString(sent[0].Text))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("no download link in %q", sent[0].Text)
+	}
+	return link.Query().Get("token")
+}
+
+func TestRunPending(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	store := blobstest.Temp(t)
+	outbox := mailtest.Record(t)
+
+	for _, userid := range []int{1, 2} {
+		if _, err := Request(db, userid); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	built, err := RunPending(db)
+	if err != nil {
+		t.Fatalf("RunPending returned error: %v", err)
+	}
+	if built != 2 {
+		t.Errorf("expected two exports to be built, got %d", built)
+	}
+	if len(outbox.Messages()) != 2 || outbox.Messages()[0].To != "john.doe@example.com" {
+		t.Errorf("expected both users to be emailed, got %+v", outbox.Messages())
+	}
+
+	if _, _, err = store.Get("exports/1/1.zip"); err != nil {
+		t.Errorf("expected the archive to be stored: %v", err)
+	}
+
+	// Nothing left to do
+	if built, err = RunPending(db); err != nil || built != 0 {
+		t.Errorf("expected nothing to be built, got %d, %v", built, err)
+	}
+}
+
+func TestRunPendingExpires(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	store := blobstest.Temp(t)
+
+	runExport(t, db, 1)
+
+	_, err := db.Exec(`UPDATE DATA_EXPORT SET expires = ?`, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = RunPending(db); err != nil {
+		t.Fatal(err)
+	}
+
+	exports, err := GetExports(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exports[0].Status != StatusExpired {
+		t.Errorf("expected the export to expire, got %s", exports[0].Status)
+	}
+	if _, _, err = store.Get("exports/1/1.zip"); err != blobs.ErrNotFound {
+		t.Errorf("expected the archive to be deleted, got %v", err)
+	}
+}
+
+func TestRunPendingStale(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+	mailtest.Record(t)
+
+	// An export abandoned while running, and one requested as long ago that
+	// only just started
+	long := time.Now().Add(-2 * staleAfter)
+	_, err := db.Exec(
+		`INSERT INTO DATA_EXPORT (userid, status, created, started) VALUES
+		(1, ?, ?, ?), (2, ?, ?, ?)`,
+		StatusRunning, long, long, StatusRunning, long, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	built, err := RunPending(db)
+	if err != nil || built != 0 {
+		t.Fatalf("expected nothing to be built, got %d, %v", built, err)
+	}
+
+	var failed int
+	err = db.QueryRow(`SELECT COUNT(*) FROM DATA_EXPORT WHERE status = ?`, StatusFailed).Scan(&failed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 1 {
+		t.Errorf("expected only the stale export to fail, got %d", failed)
+	}
+}
+
+// failingMailer fails to send any message.
+type failingMailer struct{}
+
+func (failingMailer) Send(mail.Message) error {
+	return errors.New("mail server unavailable")
+}
+
+func TestRunPendingEmailFails(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	store := blobstest.Temp(t)
+
+	previous := mail.Default
+	mail.Default = failingMailer{}
+	t.Cleanup(func() { mail.Default = previous })
+
+	if _, err := Request(db, 1); err != nil {
+		t.Fatal(err)
+	}
+	if built, err := RunPending(db); err != nil || built != 0 {
+		t.Fatalf("expected nothing to be built, got %d, %v", built, err)
+	}
+
+	exports, err := GetExports(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exports[0].Status != StatusFailed {
+		t.Errorf("expected the export to fail, got %s", exports[0].Status)
+	}
+	if _, _, err = store.Get("exports/1/1.zip"); err != blobs.ErrNotFound {
+		t.Errorf("expected the archive to be deleted, got %v", err)
+	}
+}
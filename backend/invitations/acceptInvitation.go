@@ -0,0 +1,106 @@
+package invitations
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// AcceptInvitation makes the user a member of the organization or project
+// the invitation or join link with `token` is for, with the role chosen by
+// the inviter. Invitations by email can only be accepted by the account
+// with that email (ErrWrongInvitee). Adding the member and their role and
+// using up the invitation happen atomically.
+func AcceptInvitation(db *sql.DB, userid int, token string) (*utils.Invitation, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var kindName string
+	err = tx.QueryRow(
+		`SELECT kind FROM INVITATION
+		WHERE token = ? AND uses < max_uses AND expires > ?`,
+		utils.HashToken(token), time.Now()).Scan(&kindName)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidInvitation
+	} else if err != nil {
+		return nil, err
+	}
+
+	k := kinds[kindName]
+	invitation, err := scanInvitation(tx.QueryRow(
+		k.selectInvitations()+`WHERE i.token = ?`,
+		utils.HashToken(token)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The role may have been deleted since
+	if _, err = k.roleName(tx, invitation.RoleID, invitation.TargetID); err == sql.ErrNoRows {
+		return nil, ErrInvalidInvitation
+	} else if err != nil {
+		return nil, err
+	}
+
+	var email string
+	err = tx.QueryRow(`SELECT email FROM USER WHERE id = ?`, userid).Scan(&email)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.Email != "" && invitation.Email != email {
+		return nil, ErrWrongInvitee
+	}
+
+	member, err := k.isMember(tx, email, invitation.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if member {
+		return nil, ErrAlreadyMember
+	}
+
+	// Only succeeds while uses are left, even with concurrent acceptances
+	res, err := tx.Exec(
+		`UPDATE INVITATION SET uses = uses + 1 WHERE id = ? AND uses < max_uses`,
+		invitation.ID)
+
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, ErrInvalidInvitation
+	}
+	invitation.Uses++
+
+	res, err = tx.Exec(
+		`INSERT INTO `+k.member+` (userid, `+k.column+`) VALUES (?, ?)`,
+		userid, invitation.TargetID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	memberid, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO `+k.memberRole+` (memberid, roleid) VALUES (?, ?)`,
+		memberid, invitation.RoleID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, tx.Commit()
+}
@@ -0,0 +1,80 @@
+package invitations
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestAcceptInvitation(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, token := invite(t, db, "alex.brown@example.com")
+
+	// Sarah Williams (userid 4) was not invited
+	if _, err := AcceptInvitation(db, 4, token); err != ErrWrongInvitee {
+		t.Errorf("expected ErrWrongInvitee, got %v", err)
+	}
+
+	invitation, err := AcceptInvitation(db, 5, token)
+	if err != nil {
+		t.Fatalf("AcceptInvitation returned error: %v", err)
+	}
+	if invitation.Kind != KindOrg || invitation.TargetID != 1 || invitation.Uses != 1 {
+		t.Errorf("unexpected invitation %+v", invitation)
+	}
+
+	var role string
+	err = db.QueryRow(
+		`SELECT r.name FROM ORG_MEMBER m
+		JOIN ORG_MEMBER_ROLE mr ON mr.memberid = m.id
+		JOIN ORG_ROLE r ON r.id = mr.roleid
+		WHERE m.userid = 5 AND m.orgid = 1`).Scan(&role)
+	if err != nil {
+		t.Fatalf("expected Alex Brown to be a member: %v", err)
+	}
+	if role != "Developer" {
+		t.Errorf("expected the Developer role, got %s", role)
+	}
+
+	if _, err = AcceptInvitation(db, 5, token); err != ErrInvalidInvitation {
+		t.Errorf("expected ErrInvalidInvitation on reuse, got %v", err)
+	}
+}
+
+func TestAcceptInvitationRejected(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	if _, err := AcceptInvitation(db, 5, "unknown"); err != ErrInvalidInvitation {
+		t.Errorf("expected ErrInvalidInvitation, got %v", err)
+	}
+
+	// Members keep the link for others
+	link, err := CreateJoinLink(db, 1, KindOrg, 1, 3, 1, DefaultLifetimeDays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = AcceptInvitation(db, 2, link.Token); err != ErrAlreadyMember {
+		t.Errorf("expected ErrAlreadyMember, got %v", err)
+	}
+
+	_, err = db.Exec(`UPDATE INVITATION SET expires = ? WHERE id = ?`, time.Now().Add(-time.Minute), link.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = AcceptInvitation(db, 5, link.Token); err != ErrInvalidInvitation {
+		t.Errorf("expected ErrInvalidInvitation once expired, got %v", err)
+	}
+
+	var uses int
+	if err = db.QueryRow(`SELECT uses FROM INVITATION WHERE id = ?`, link.ID).Scan(&uses); err != nil {
+		t.Fatal(err)
+	}
+	if uses != 0 {
+		t.Errorf("expected the link to be unused, got %d uses", uses)
+	}
+}
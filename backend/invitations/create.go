@@ -0,0 +1,105 @@
+package invitations
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// create stores an invitation to `targetid` made by `inviter`, after the
+// checks common to invitations by email and join links. Failed checks of
+// the caller are passed in `v`. It returns the invitation along with its
+// token, of which only the hash is stored.
+func create(db *sql.DB, v *validate.Validator, inviter int, kindName string, targetid int, roleid int, email string, maxUses int, days int) (*utils.Invitation, string, error) {
+	k, ok := kinds[kindName]
+	if !ok {
+		v.Fail("kind", validate.CodeInvalid, "must be %s or %s", KindOrg, KindProject)
+	}
+	if days < 1 || days > MaxLifetimeDays {
+		v.Fail("expires_in_days", validate.CodeInvalid, "must be between 1 and %d", MaxLifetimeDays)
+	}
+	if err := v.Err(); err != nil {
+		return nil, "", err
+	}
+
+	allowed, err := k.canManage(db, inviter, targetid)
+	if err != nil {
+		return nil, "", err
+	}
+	if !allowed {
+		return nil, "", ErrNotAllowed
+	}
+
+	role, err := k.roleName(db, roleid, targetid)
+	if err == sql.ErrNoRows {
+		v.Fail("roleid", validate.CodeInvalid, "is not a role of the %s", k.name)
+		return nil, "", v.Err()
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	if email != "" {
+		member, err := k.isMember(db, email, targetid)
+		if err != nil {
+			return nil, "", err
+		}
+		if member {
+			return nil, "", ErrAlreadyMember
+		}
+
+		// Expired invitations count as well, as they can be resent
+		var invited bool
+		err = db.QueryRow(
+			`SELECT EXISTS (
+				SELECT 1 FROM INVITATION
+				WHERE email = ? AND kind = ? AND targetid = ? AND uses < max_uses
+			)`,
+			email, kindName, targetid).Scan(&invited)
+
+		if err != nil {
+			return nil, "", err
+		}
+		if invited {
+			return nil, "", ErrAlreadyInvited
+		}
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	invitation := &utils.Invitation{
+		Kind:      kindName,
+		TargetID:  targetid,
+		RoleID:    roleid,
+		Role:      role,
+		Email:     email,
+		InviterID: inviter,
+		MaxUses:   maxUses,
+		Created:   now,
+		Expires:   now.AddDate(0, 0, days),
+	}
+
+	res, err := db.Exec(
+		`INSERT INTO INVITATION (kind, targetid, roleid, email, token, inviter, max_uses, created, expires)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		kindName, targetid, roleid, sql.NullString{String: email, Valid: email != ""},
+		utils.HashToken(token), inviter, maxUses, invitation.Created, invitation.Expires)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, "", err
+	}
+	invitation.ID = int(id)
+
+	return invitation, token, nil
+}
@@ -0,0 +1,33 @@
+package invitations
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"net/url"
+
+	_ "modernc.org/sqlite"
+)
+
+// CreateJoinLink creates a shareable link to join the organization or
+// project `targetid` of `kind` with the role `roleid`. Anyone with the link
+// can accept it, until it was used `maxUses` times or `days` days passed.
+// The returned token is the only copy of it; only its hash is stored.
+func CreateJoinLink(db *sql.DB, creator int, kind string, targetid int, roleid int, maxUses int, days int) (*utils.NewJoinLink, error) {
+	v := validate.New()
+	if maxUses < 1 || maxUses > MaxJoinLinkUses {
+		v.Fail("max_uses", validate.CodeInvalid, "must be between 1 and %d", MaxJoinLinkUses)
+	}
+
+	invitation, token, err := create(db, v, creator, kind, targetid, roleid, "", maxUses, days)
+	if err != nil {
+		return nil, err
+	}
+
+	return &utils.NewJoinLink{
+		Invitation: *invitation,
+		Token:      token,
+		Link:       mail.Link("/accept-invitation", url.Values{"token": {token}}),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package invitations
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"errors"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestCreateJoinLink(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	link, err := CreateJoinLink(db, 1, KindProject, 1, 4, 2, DefaultLifetimeDays)
+	if err != nil {
+		t.Fatalf("CreateJoinLink returned error: %v", err)
+	}
+
+	if link.Email != "" || link.MaxUses != 2 || link.Role != "Stakeholder" {
+		t.Errorf("unexpected join link %+v", link.Invitation)
+	}
+	if !strings.Contains(link.Link, "/accept-invitation?token="+link.Token) {
+		t.Errorf("expected the link to contain the token, got %s", link.Link)
+	}
+
+	// Anyone can join until the link is used up
+	for _, userid := range []int{4, 5} {
+		if _, err := AcceptInvitation(db, userid, link.Token); err != nil {
+			t.Errorf("user %d: AcceptInvitation returned error: %v", userid, err)
+		}
+	}
+	if _, err := AcceptInvitation(db, 3, link.Token); err != ErrInvalidInvitation {
+		t.Errorf("expected ErrInvalidInvitation once used up, got %v", err)
+	}
+}
+
+func TestCreateJoinLinkRejected(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	for _, uses := range []int{0, MaxJoinLinkUses + 1} {
+		_, err := CreateJoinLink(db, 1, KindProject, 1, 4, uses, DefaultLifetimeDays)
+		var errs validate.Errors
+		if !errors.As(err, &errs) || errs[0].Field != "max_uses" {
+			t.Errorf("max uses %d: expected max_uses to be rejected, got %v", uses, err)
+		}
+	}
+
+	// Jane Smith is a Developer of the project
+	if _, err := CreateJoinLink(db, 2, KindProject, 1, 4, 5, DefaultLifetimeDays); err != ErrNotAllowed {
+		t.Errorf("expected ErrNotAllowed, got %v", err)
+	}
+}
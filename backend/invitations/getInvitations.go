@@ -0,0 +1,111 @@
+package invitations
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// selectInvitations selects the columns read by scanInvitation from the
+// invitations of kind `k`, which are called i.
+func (k kind) selectInvitations() string {
+	return `SELECT i.id, i.kind, i.targetid, i.roleid, COALESCE(r.name, ''),
+		COALESCE(i.email, ''), i.inviter, i.max_uses, i.uses, i.created, i.expires
+	FROM INVITATION i
+	LEFT JOIN ` + k.role + ` r ON r.id = i.roleid `
+}
+
+// scanInvitation reads an invitation selected with selectInvitations.
+func scanInvitation(row scanner) (*utils.Invitation, error) {
+	var invitation utils.Invitation
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.Kind,
+		&invitation.TargetID,
+		&invitation.RoleID,
+		&invitation.Role,
+		&invitation.Email,
+		&invitation.InviterID,
+		&invitation.MaxUses,
+		&invitation.Uses,
+		&invitation.Created,
+		&invitation.Expires)
+
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// getManagedInvitation returns the unused invitation `id` if the user can
+// manage the organization or project it is for.
+func getManagedInvitation(db *sql.DB, userid int, id int) (*utils.Invitation, error) {
+	var kindName string
+	var targetid int
+	err := db.QueryRow(
+		`SELECT kind, targetid FROM INVITATION WHERE id = ? AND uses < max_uses`,
+		id).Scan(&kindName, &targetid)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrInvitationNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	k := kinds[kindName]
+	allowed, err := k.canManage(db, userid, targetid)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrInvitationNotFound
+	}
+
+	return scanInvitation(db.QueryRow(k.selectInvitations()+`WHERE i.id = ?`, id))
+}
+
+// GetInvitations returns the invitations and join links to the organization
+// or project `targetid` of `kind` that were not used up, newest first.
+// Expired ones are included, so invitations by email can be resent.
+func GetInvitations(db *sql.DB, userid int, kind string, targetid int) ([]utils.Invitation, error) {
+	k, ok := kinds[kind]
+	if !ok {
+		return nil, ErrNotAllowed
+	}
+
+	allowed, err := k.canManage(db, userid, targetid)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrNotAllowed
+	}
+
+	rows, err := db.Query(
+		k.selectInvitations()+`
+		WHERE i.kind = ? AND i.targetid = ? AND i.uses < i.max_uses
+		ORDER BY i.created DESC, i.id DESC`,
+		kind, targetid)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []utils.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *invitation)
+	}
+
+	return invitations, rows.Err()
+}
@@ -0,0 +1,46 @@
+package invitations
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestGetInvitations(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	invite(t, db, "first@example.com")
+	link, err := CreateJoinLink(db, 1, KindOrg, 1, 3, 1, DefaultLifetimeDays)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := GetInvitations(db, 1, KindOrg, 1)
+	if err != nil {
+		t.Fatalf("GetInvitations returned error: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != link.ID || list[1].Email != "first@example.com" {
+		t.Fatalf("expected the join link and the invitation, newest first, got %+v", list)
+	}
+	if list[0].Role != "Viewer" || list[1].Role != "Developer" {
+		t.Errorf("expected role names, got %q and %q", list[0].Role, list[1].Role)
+	}
+
+	// Used up invitations are no longer pending
+	if _, err = AcceptInvitation(db, 4, link.Token); err != nil {
+		t.Fatal(err)
+	}
+	list, err = GetInvitations(db, 1, KindOrg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Errorf("expected one pending invitation, got %d", len(list))
+	}
+
+	if _, err = GetInvitations(db, 2, KindOrg, 1); err != ErrNotAllowed {
+		t.Errorf("expected ErrNotAllowed, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+// Package invitations lets the managers of organizations and projects invite
+// people who may not have signed up yet. Invitations are either sent to an
+// email address and used once, or are join links that can be shared and used
+// a limited number of times. Accepting one makes the user a member with the
+// role chosen by the inviter.
+package invitations
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Kinds of invitations.
+const (
+	KindOrg     = "org"
+	KindProject = "project"
+)
+
+const (
+	// DefaultLifetimeDays is how long invitations are valid if no expiry
+	// is given.
+	DefaultLifetimeDays = 7
+
+	// MaxLifetimeDays is the longest an invitation can be valid.
+	MaxLifetimeDays = 30
+
+	// MaxJoinLinkUses is the most members a single join link can add.
+	MaxJoinLinkUses = 1000
+
+	// resendInterval is the minimum time between two emails of the same
+	// invitation.
+	resendInterval = time.Minute
+)
+
+var (
+	// ErrNotAllowed is returned when the user may not manage the members
+	// of the organization or project.
+	ErrNotAllowed = errors.New("you cannot manage the members of this organization or project")
+
+	// ErrInvitationNotFound is returned when an invitation does not exist,
+	// was used up, or belongs to an organization or project the user
+	// cannot manage.
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	// ErrInvalidInvitation is returned when accepting an invitation that is
+	// unknown, expired, used up or revoked.
+	ErrInvalidInvitation = errors.New("invalid or expired invitation")
+
+	// ErrWrongInvitee is returned when accepting an invitation sent to
+	// another email address.
+	ErrWrongInvitee = errors.New("invitation was sent to another email address")
+
+	// ErrAlreadyMember is returned when inviting a member, or accepting an
+	// invitation as one.
+	ErrAlreadyMember = errors.New("user is already a member")
+
+	// ErrAlreadyInvited is returned when the email has a pending invitation
+	// already. It can be resent instead.
+	ErrAlreadyInvited = errors.New("email has already been invited")
+
+	// ErrJoinLink is returned when resending a join link, which has no
+	// email address.
+	ErrJoinLink = errors.New("join links are not sent by email")
+
+	// ErrResendThrottled is returned when an invitation was sent within
+	// resendInterval.
+	ErrResendThrottled = errors.New("invitation was sent too recently")
+)
+
+// kind holds the tables behind a kind of invitation.
+type kind struct {
+	name       string // shown in messages
+	target     string // table of the organizations or projects
+	role       string // table of their roles
+	member     string // table of their members
+	memberRole string // table of the roles of their members
+	column     string // column referencing the target in the other tables
+}
+
+var kinds = map[string]kind{
+	KindOrg: {
+		name:       "organization",
+		target:     "ORGANIZATION",
+		role:       "ORG_ROLE",
+		member:     "ORG_MEMBER",
+		memberRole: "ORG_MEMBER_ROLE",
+		column:     "orgid",
+	},
+	KindProject: {
+		name:       "project",
+		target:     "PROJECT",
+		role:       "PROJECT_ROLE",
+		member:     "PROJECT_MEMBER",
+		memberRole: "PROJECT_MEMBER_ROLE",
+		column:     "projectid",
+	},
+}
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// canManage reports whether the user has a role with exec privileges in the
+// organization or project, like AddOrgMember and AddProjMember require.
+func (k kind) canManage(q querier, userid int, targetid int) (bool, error) {
+	var allowed bool
+	err := q.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM `+k.memberRole+` mr
+			JOIN `+k.role+` r ON r.id = mr.roleid
+			JOIN `+k.member+` m ON m.id = mr.memberid
+			WHERE m.userid = ? AND m.`+k.column+` = ? AND r.can_exec = 1
+		)`,
+		userid, targetid).Scan(&allowed)
+
+	return allowed, err
+}
+
+// isMember reports whether the user with the email is a member.
+func (k kind) isMember(q querier, email string, targetid int) (bool, error) {
+	var member bool
+	err := q.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM `+k.member+` m
+			JOIN USER u ON u.id = m.userid
+			WHERE u.email = ? AND m.`+k.column+` = ?
+		)`,
+		email, targetid).Scan(&member)
+
+	return member, err
+}
+
+// roleName returns the name of the role, or sql.ErrNoRows if it is not a
+// role of the organization or project.
+func (k kind) roleName(q querier, roleid int, targetid int) (string, error) {
+	var name string
+	err := q.QueryRow(
+		`SELECT name FROM `+k.role+` WHERE id = ? AND `+k.column+` = ?`,
+		roleid, targetid).Scan(&name)
+
+	return name, err
+}
@@ -0,0 +1,70 @@
+package invitations
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/preferences"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sendInvitation emails the invitation with its token to the invitee,
+// showing times the way they prefer if they are registered.
+func sendInvitation(db *sql.DB, invitation *utils.Invitation, token string) error {
+	k := kinds[invitation.Kind]
+
+	var inviter, target string
+	err := db.QueryRow(
+		`SELECT u.name, t.name
+		FROM USER u, `+k.target+` t
+		WHERE u.id = ? AND t.id = ?`,
+		invitation.InviterID, invitation.TargetID).Scan(&inviter, &target)
+
+	if err != nil {
+		return err
+	}
+
+	// Unregistered invitees get the default preferences
+	var invitee int
+	err = db.QueryRow(`SELECT id FROM USER WHERE email = ?`, invitation.Email).Scan(&invitee)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	err = preferences.SendTemplate(db, invitee, invitation.Email, mail.TemplateInvitation, mail.InvitationData{
+		Inviter: inviter,
+		Target:  target,
+		Role:    invitation.Role,
+		Link:    mail.Link("/accept-invitation", url.Values{"token": {token}}),
+		Expires: invitation.Expires,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`UPDATE INVITATION SET sent = ? WHERE id = ?`, time.Now(), invitation.ID)
+	return err
+}
+
+// Invite emails an invitation to join the organization or project
+// `targetid` of `kind` with the role `roleid` to `email`, which does not
+// have to be registered yet. The invitation can be accepted once within
+// `days` days. The inviter needs exec privileges in the organization or
+// project.
+func Invite(db *sql.DB, inviter int, kind string, targetid int, roleid int, email string, days int) (*utils.Invitation, error) {
+	email = validate.NormalizeEmail(email)
+
+	v := validate.New()
+	v.Email("email", email)
+
+	invitation, token, err := create(db, v, inviter, kind, targetid, roleid, email, 1, days)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, sendInvitation(db, invitation, token)
+}
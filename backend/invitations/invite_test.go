@@ -0,0 +1,144 @@
+package invitations
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// invitationRegex finds the link to accept an invitation in the text of an
+// email.
+var invitationRegex = regexp.MustCompile(`\S+/accept-invitation\?token=\S+`)
+
+// invitationToken returns the token of the link in the message.
+func invitationToken(t *testing.T, msg mail.Message) string {
+	t.Helper()
+	link, err := url.Parse(invitationRegex.FindString(msg.Text))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("no invitation link in %q", msg.Text)
+	}
+	return link.Query().Get("token")
+}
+
+// invite has John Doe, an admin of TechCorp Solutions, invite `email` as a
+// Developer and returns the invitation and its token.
+func invite(t *testing.T, db *sql.DB, email string) (*utils.Invitation, string) {
+	t.Helper()
+	outbox := mailtest.Record(t)
+	invitation, err := Invite(db, 1, KindOrg, 1, 2, email, DefaultLifetimeDays)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return invitation, invitationToken(t, outbox.Messages()[0])
+}
+
+func TestInvite(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	invitation, err := Invite(db, 1, KindOrg, 1, 2, " New.Hire@Example.com", 3)
+	if err != nil {
+		t.Fatalf("Invite returned error: %v", err)
+	}
+
+	if invitation.Email != "new.hire@example.com" || invitation.Role != "Developer" || invitation.MaxUses != 1 {
+		t.Errorf("unexpected invitation %+v", invitation)
+	}
+
+	sent := outbox.Messages()
+	if len(sent) != 1 || sent[0].To != "new.hire@example.com" {
+		t.Fatalf("expected an email to the invitee, got %+v", sent)
+	}
+	for _, want := range []string{"John Doe", "TechCorp Solutions", "Developer"} {
+		if !strings.Contains(sent[0].Text, want) {
+			t.Errorf("expected the email to mention %q, got %q", want, sent[0].Text)
+		}
+	}
+
+	// Only the hash is stored
+	token := invitationToken(t, sent[0])
+	var exists bool
+	err = db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM INVITATION WHERE id = ? AND token = ?)`,
+		invitation.ID, utils.HashToken(token)).Scan(&exists)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Error("expected the token hash to be stored")
+	}
+}
+
+func TestInviteRejected(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	mailtest.Record(t)
+
+	invite(t, db, "new.hire@example.com")
+
+	tests := []struct {
+		name    string
+		inviter int
+		kind    string
+		target  int
+		role    int
+		email   string
+		days    int
+		want    error
+		field   string
+	}{
+		{"no exec privileges", 2, KindOrg, 1, 2, "someone@example.com", 7, ErrNotAllowed, ""},
+		{"role of another organization", 1, KindOrg, 1, 4, "someone@example.com", 7, nil, "roleid"},
+		{"unknown kind", 1, "team", 1, 2, "someone@example.com", 7, nil, "kind"},
+		{"invalid email", 1, KindOrg, 1, 2, "someone", 7, nil, "email"},
+		{"too long", 1, KindOrg, 1, 2, "someone@example.com", MaxLifetimeDays + 1, nil, "expires_in_days"},
+		{"member", 1, KindOrg, 1, 2, "jane.smith@example.com", 7, ErrAlreadyMember, ""},
+		{"invited", 1, KindOrg, 1, 3, "new.hire@example.com", 7, ErrAlreadyInvited, ""},
+	}
+	for _, tt := range tests {
+		_, err := Invite(db, tt.inviter, tt.kind, tt.target, tt.role, tt.email, tt.days)
+		var errs validate.Errors
+		if tt.field != "" {
+			if !errors.As(err, &errs) || errs[0].Field != tt.field {
+				t.Errorf("%s: expected %s to be rejected, got %v", tt.name, tt.field, err)
+			}
+		} else if err != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+// TestInvitePreferences checks that registered invitees see times the way
+// they prefer.
+func TestInvitePreferences(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	_, err := db.Exec(
+		`INSERT INTO USER_PREFERENCES (userid, timezone, locale, date_format, digest)
+		VALUES (5, 'UTC', 'en-US', 'iso', 'never')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invitation, err := Invite(db, 1, KindOrg, 1, 2, "alex.brown@example.com", 3)
+	if err != nil {
+		t.Fatalf("Invite returned error: %v", err)
+	}
+
+	want := invitation.Expires.UTC().Format("2006-01-02")
+	if sent := outbox.Messages(); len(sent) != 1 || !strings.Contains(sent[0].Text, want) {
+		t.Errorf("expected the expiry as %s, got %+v", want, sent)
+	}
+}
@@ -0,0 +1,55 @@
+package invitations
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ResendInvitation emails the invitation `id` again with a new token, valid
+// for as long as the invitation originally was. The previous email's link
+// stops working.
+func ResendInvitation(db *sql.DB, userid int, id int) (*utils.Invitation, error) {
+	invitation, err := getManagedInvitation(db, userid, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.Email == "" {
+		return nil, ErrJoinLink
+	}
+
+	now := time.Now()
+
+	var recent bool
+	err = db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM INVITATION WHERE id = ? AND sent > ?)`,
+		id, now.Add(-resendInterval)).Scan(&recent)
+
+	if err != nil {
+		return nil, err
+	}
+	if recent {
+		return nil, ErrResendThrottled
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.Expires = now.Add(invitation.Expires.Sub(invitation.Created))
+	invitation.Created = now
+
+	_, err = db.Exec(
+		`UPDATE INVITATION SET token = ?, created = ?, expires = ? WHERE id = ?`,
+		utils.HashToken(token), invitation.Created, invitation.Expires, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, sendInvitation(db, invitation, token)
+}
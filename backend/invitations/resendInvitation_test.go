@@ -0,0 +1,63 @@
+package invitations
+
+import (
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestResendInvitation(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	invitation, oldToken := invite(t, db, "alex.brown@example.com")
+
+	if _, err := ResendInvitation(db, 1, invitation.ID); err != ErrResendThrottled {
+		t.Fatalf("expected ErrResendThrottled, got %v", err)
+	}
+
+	// The invitation expired a while after it was sent
+	_, err := db.Exec(
+		`UPDATE INVITATION SET created = ?, expires = ?, sent = ? WHERE id = ?`,
+		time.Now().AddDate(0, 0, -10), time.Now().AddDate(0, 0, -3),
+		time.Now().AddDate(0, 0, -10), invitation.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outbox := mailtest.Record(t)
+	resent, err := ResendInvitation(db, 1, invitation.ID)
+	if err != nil {
+		t.Fatalf("ResendInvitation returned error: %v", err)
+	}
+	if d := time.Until(resent.Expires); d < 6*24*time.Hour || d > 7*24*time.Hour {
+		t.Errorf("expected the invitation to be valid for 7 more days, got %v", resent.Expires)
+	}
+	if len(outbox.Messages()) != 1 || outbox.Messages()[0].To != "alex.brown@example.com" {
+		t.Fatalf("expected the invitation to be resent, got %+v", outbox.Messages())
+	}
+
+	if _, err = AcceptInvitation(db, 5, oldToken); err != ErrInvalidInvitation {
+		t.Errorf("expected the old link to stop working, got %v", err)
+	}
+	if _, err = AcceptInvitation(db, 5, invitationToken(t, outbox.Messages()[0])); err != nil {
+		t.Errorf("AcceptInvitation returned error: %v", err)
+	}
+}
+
+func TestResendJoinLink(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	link, err := CreateJoinLink(db, 1, KindOrg, 1, 3, 5, DefaultLifetimeDays)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = ResendInvitation(db, 1, link.ID); err != ErrJoinLink {
+		t.Errorf("expected ErrJoinLink, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package invitations
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// RevokeInvitation deletes the invitation or join link `id`, which then can
+// no longer be accepted.
+func RevokeInvitation(db *sql.DB, userid int, id int) error {
+	invitation, err := getManagedInvitation(db, userid, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM INVITATION WHERE id = ?`, invitation.ID)
+	return err
+}
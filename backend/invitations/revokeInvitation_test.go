@@ -0,0 +1,32 @@
+package invitations
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRevokeInvitation(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	invitation, token := invite(t, db, "alex.brown@example.com")
+
+	// Only managers of the organization can revoke it
+	if err := RevokeInvitation(db, 2, invitation.ID); err != ErrInvitationNotFound {
+		t.Errorf("expected ErrInvitationNotFound, got %v", err)
+	}
+
+	if err := RevokeInvitation(db, 1, invitation.ID); err != nil {
+		t.Fatalf("RevokeInvitation returned error: %v", err)
+	}
+
+	if _, err := AcceptInvitation(db, 5, token); err != ErrInvalidInvitation {
+		t.Errorf("expected ErrInvalidInvitation, got %v", err)
+	}
+
+	if err := RevokeInvitation(db, 1, invitation.ID); err != ErrInvitationNotFound {
+		t.Errorf("expected ErrInvitationNotFound, got %v", err)
+	}
+}
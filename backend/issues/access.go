@@ -0,0 +1,53 @@
+package issues
+
+import (
+	"database/sql"
+	"errors"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrNoReadAccess is returned when the user may not read the project of an
+// issue.
+var ErrNoReadAccess = errors.New("user does not have read privileges for this project")
+
+// queryer is what access needs of *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// access returns the project of the issue and whether the user may read
+// and write it. It returns ErrIssueNotFound for issues outside of projects.
+func access(q queryer, userid int, issueid int) (projectid int, canRead bool, canWrite bool, err error) {
+	err = q.QueryRow(
+		`SELECT pi.projectid,
+			COALESCE(MAX(pr.can_read), 0),
+			COALESCE(MAX(pr.can_write), 0)
+		FROM PROJECT_ISSUES pi
+		LEFT JOIN PROJECT_MEMBER pm ON pm.projectid = pi.projectid AND pm.userid = ?
+		LEFT JOIN PROJECT_MEMBER_ROLE pmr ON pmr.memberid = pm.id
+		LEFT JOIN PROJECT_ROLE pr ON pr.id = pmr.roleid
+		WHERE pi.issueid = ?
+		GROUP BY pi.projectid`,
+		userid, issueid).Scan(&projectid, &canRead, &canWrite)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, false, ErrIssueNotFound
+	}
+
+	return projectid, canRead, canWrite, err
+}
+
+// canReadProject reports whether the user may read the project.
+func canReadProject(q queryer, userid int, projectid int) (bool, error) {
+	var canRead bool
+	err := q.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM PROJECT_MEMBER pm
+			JOIN PROJECT_MEMBER_ROLE pmr ON pmr.memberid = pm.id
+			JOIN PROJECT_ROLE pr ON pr.id = pmr.roleid
+			WHERE pm.projectid = ? AND pm.userid = ? AND pr.can_read = 1)`,
+		projectid, userid).Scan(&canRead)
+
+	return canRead, err
+}
@@ -0,0 +1,53 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestAccess(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	tests := []struct {
+		userid   int
+		canRead  bool
+		canWrite bool
+	}{
+		{1, true, true},
+		{3, true, true},
+		{4, false, false},
+	}
+	for _, tt := range tests {
+		projectid, canRead, canWrite, err := access(db, tt.userid, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if projectid != 1 || canRead != tt.canRead || canWrite != tt.canWrite {
+			t.Errorf("user %d: expected %v, %v, got %d, %v, %v",
+				tt.userid, tt.canRead, tt.canWrite, projectid, canRead, canWrite)
+		}
+	}
+
+	if _, _, _, err := access(db, 1, 999); !errors.Is(err, ErrIssueNotFound) {
+		t.Errorf("expected ErrIssueNotFound, got %v", err)
+	}
+}
+
+func TestCanReadProject(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	for userid, want := range map[int]bool{1: true, 3: true, 4: false} {
+		canRead, err := canReadProject(db, userid, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if canRead != want {
+			t.Errorf("user %d: expected %v, got %v", userid, want, canRead)
+		}
+	}
+}
@@ -7,30 +7,15 @@ import (
 
 // Error definitions
 var (
-	ErrInvalidSession         = errors.New("invalid session")
-	ErrSessionExpired         = errors.New("session expired")
 	ErrIssueNotFound          = errors.New("issue not found")
 	ErrInsufficientPrivileges = errors.New("user does not have write privileges for this project")
 )
 
 // CloseIssue marks an issue as completed by the current user
-func CloseIssue(db *sql.DB, sessionID int, issueID int) error {
-	// First check if the session is valid
-	var userID int
-	err := db.QueryRow(`
-		SELECT userid FROM SESSION 
-		WHERE id = ? AND expires > datetime('now')
-	`, sessionID).Scan(&userID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return ErrInvalidSession
-		}
-		return err
-	}
-
-	// Next check if the issue exists
+func CloseIssue(db *sql.DB, userID int, issueID int) error {
+	// First check if the issue exists
 	var exists bool
-	err = db.QueryRow(`SELECT EXISTS(SELECT 1 FROM ISSUE WHERE id = ?)`, issueID).Scan(&exists)
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM ISSUE WHERE id = ?)`, issueID).Scan(&exists)
 	if err != nil {
 		return err
 	}
@@ -13,43 +13,17 @@ import (
 func TestCloseIssue(t *testing.T) {
 	db := utils.SetupTest(t)
 
-	// Create fresh sessions that won't be expired for our test
-	setupTestSessions := `
-		UPDATE SESSION SET expires = datetime('now', '+1 day') WHERE id IN (1, 2, 3, 4, 5);
-	`
-	_, err := db.Exec(setupTestSessions)
-	if err != nil {
-		t.Fatalf("failed to set up test sessions: %v", err)
-	}
-
-	// Find a valid admin session (a session with write privileges)
-	var validAdminSessionID int
-	err = db.QueryRow(`
-		SELECT s.id FROM SESSION s
-		JOIN USER u ON s.userid = u.id
-		JOIN PROJECT_MEMBER pm ON u.id = pm.userid
+	// Find a user with write privileges
+	var adminUserID int
+	err := db.QueryRow(`
+		SELECT pm.userid FROM PROJECT_MEMBER pm
 		JOIN PROJECT_MEMBER_ROLE pmr ON pm.id = pmr.memberid
 		JOIN PROJECT_ROLE pr ON pmr.roleid = pr.id
-		WHERE s.expires > datetime('now') AND pr.can_write = 1
+		WHERE pr.can_write = 1
 		LIMIT 1
-	`).Scan(&validAdminSessionID)
+	`).Scan(&adminUserID)
 	if err != nil {
-		t.Log("Could not find a session with write access, setting up our own test data")
-		setupOwnData := `
-			INSERT INTO USER (id, email, password, name, verified) 
-			VALUES (999, 'test@example.com', 'password', 'Test User', 1);
-			
-			INSERT INTO SESSION (id, userid, expires) 
-			VALUES (999, 999, datetime('now', '+1 day'));
-			
-			INSERT INTO PROJECT_MEMBER (id, userid, projectid) VALUES (999, 999, 1);
-			INSERT INTO PROJECT_MEMBER_ROLE (id, memberid, roleid) VALUES (999, 999, 1);
-		`
-		_, err = db.Exec(setupOwnData)
-		if err != nil {
-			t.Fatalf("failed to set up own test data: %v", err)
-		}
-		validAdminSessionID = 999
+		t.Fatalf("could not find a user with write access: %v", err)
 	}
 
 	// Find a valid issue ID
@@ -59,61 +33,37 @@ func TestCloseIssue(t *testing.T) {
 		t.Fatalf("could not find a valid issue to test with: %v", err)
 	}
 
-	// Find a session without write privileges
-	var nonAdminSessionID int
-	err = db.QueryRow(`
-		SELECT s.id FROM SESSION s
-		JOIN USER u ON s.userid = u.id
-		LEFT JOIN PROJECT_MEMBER pm ON u.id = pm.userid
-		LEFT JOIN PROJECT_MEMBER_ROLE pmr ON pm.id = pmr.memberid
-		LEFT JOIN PROJECT_ROLE pr ON pmr.roleid = pr.id
-		WHERE s.expires > datetime('now') AND (pr.can_write IS NULL OR pr.can_write = 0)
-		LIMIT 1
-	`).Scan(&nonAdminSessionID)
+	// Create a non-admin user without any write privileges
+	_, err = db.Exec(`
+		INSERT INTO USER (id, email, password, name, verified) 
+		VALUES (998, 'nonadmin@example.com', 'password', 'Non-Admin User', 1);
+	`)
 	if err != nil {
-		// Create a non-admin user without any write privileges
-		setupNonAdminUser := `
-			INSERT INTO USER (id, email, password, name, verified) 
-			VALUES (998, 'nonadmin@example.com', 'password', 'Non-Admin User', 1);
-			
-			INSERT INTO SESSION (id, userid, expires) 
-			VALUES (998, 998, datetime('now', '+1 day'));
-		`
-		_, err = db.Exec(setupNonAdminUser)
-		if err != nil {
-			t.Fatalf("failed to set up non-admin user: %v", err)
-		}
-		nonAdminSessionID = 998
+		t.Fatalf("failed to set up non-admin user: %v", err)
 	}
 
 	// Test cases
 	testCases := []struct {
 		name          string
-		sessionID     int
+		userID        int
 		issueID       int
 		expectedError error
 	}{
 		{
 			name:          "Successful Issue Closure",
-			sessionID:     validAdminSessionID,
+			userID:        adminUserID,
 			issueID:       validIssueID,
 			expectedError: nil,
 		},
 		{
 			name:          "Issue Does Not Exist",
-			sessionID:     validAdminSessionID,
+			userID:        adminUserID,
 			issueID:       999, // Non-existent issue ID
 			expectedError: ErrIssueNotFound,
 		},
-		{
-			name:          "Invalid Session",
-			sessionID:     9999, // Invalid session ID
-			issueID:       validIssueID,
-			expectedError: ErrInvalidSession,
-		},
 		{
 			name:          "User Without Write Privileges",
-			sessionID:     nonAdminSessionID,
+			userID:        998,
 			issueID:       validIssueID,
 			expectedError: ErrInsufficientPrivileges,
 		},
@@ -131,7 +81,7 @@ func TestCloseIssue(t *testing.T) {
 			}
 
 			// Call the function
-			err := CloseIssue(db, tc.sessionID, tc.issueID)
+			err := CloseIssue(db, tc.userID, tc.issueID)
 
 			// Check if the error matches what we expect
 			if !errors.Is(err, tc.expectedError) && (err != nil || tc.expectedError != nil) {
@@ -1,7 +1,7 @@
 package issues
 
 import (
-	"brickedup/backend/utils"
+	"brickedup/backend/validate"
 	"database/sql"
 	"time"
 
@@ -10,7 +10,7 @@ import (
 
 // CreateIssue creates a new issue in the database with the given parameters.
 func CreateIssue(
-	sessionid int,
+	userID int,
 	projectid int,
 	title string, 
 	desc string, 
@@ -21,17 +21,6 @@ func CreateIssue(
 	assignee int, 
 	db *sql.DB) (int64, error) {
 
-		var userID int
-		var sessionExpires time.Time
-		err := db.QueryRow(`
-		SELECT userid, expires FROM SESSION 
-		WHERE id = ? AND expires > ?
-		`, sessionid, time.Now()).Scan(&userID, &sessionExpires)
-
-		if err != nil {
-			return -1, err
-		}
-
 		// var hasWritePrivilege bool
 		// err = db.QueryRow(`
 		// 	SELECT EXISTS (
@@ -50,8 +39,13 @@ func CreateIssue(
 		// 	return -1, sql.ErrNoRows // Indicates no matching privileges found
 		// }
 		//
-		title = utils.SanitizeText(title, utils.TEXT)
-		desc = utils.SanitizeText(desc, utils.TEXT)
+		v := validate.New()
+		v.Line("title", title, validate.MaxTitleLength)
+		v.Text("desc", desc, validate.MaxTextLength)
+		if err := v.Err(); err != nil {
+			return -1, err
+		}
+
 		issue, err := db.Exec(
 			`INSERT INTO issue (title, "desc", tagid, priority, created, cost) 
 			VALUES (?, ?, ?, ?, ?, ?)`,
@@ -21,8 +21,7 @@ func TestCreateNewIssue(t *testing.T) {
 	cost := 500
 	createdDate := time.Now()
 
-	// Create a session with a valid user who has exec privileges
-	// First find a user with exec privileges for this project
+	// Find a user with write privileges for this project
 	var userID int
 	err := db.QueryRow(`
 		SELECT pm.userid FROM PROJECT_MEMBER pm
@@ -34,21 +33,9 @@ func TestCreateNewIssue(t *testing.T) {
 		t.Fatalf("Could not find a user with write privileges: %v", err)
 	}
 
-	// Create a session for this user
-	result, err := db.Exec("INSERT INTO SESSION (userid, expires) VALUES (?, ?)",
-		userID, time.Now().Add(1*time.Hour))
-	if err != nil {
-		t.Fatalf("Failed to create test session: %v", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		t.Fatalf("Failed to get last insert ID: %v", err)
-	}
-	sessionID := int(id)
-
 	// Call the function to test
 	_, err = CreateIssue(
-		sessionID, 
+		userID, 
 		projectid, 
 		title, 
 		desc, 
@@ -63,28 +50,10 @@ func TestCreateNewIssue(t *testing.T) {
 		t.Fatalf("Failed to create new issue: %v", err)
 	}
 
-	// Non-existant sessionid
-	nonExistentSession := 999999
-	_, err = CreateIssue(
-		nonExistentSession, 
-		projectid, 
-		title, 
-		desc, 
-		tagid, 
-		priority, 
-		cost, 
-		createdDate,
-		userID, 
-		db)
-
-	if err == nil {
-		t.Fatalf("Invalid sessionid should fail.")
-	}
-
 	// Non-existant projct
 	nonExistentProject := 999999
 	_, err = CreateIssue(
-		sessionID, 
+		userID, 
 		nonExistentProject, 
 		title, 
 		desc, 
@@ -118,7 +87,7 @@ func TestCreateNewIssue(t *testing.T) {
 	}
 
 	_, err = CreateIssue(
-		sessionID, 
+		userID, 
 		nonExistentProject, 
 		title, 
 		desc, 
@@ -0,0 +1,91 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrUnknownFormat is returned for graph formats ExportGraph cannot write.
+var ErrUnknownFormat = errors.New("unknown graph format")
+
+// subgraph returns the issues to export and the dependencies among them:
+// all of them, or the issue with the issues it transitively depends on and
+// that transitively depend on it if `issueid` is not 0.
+func (g *graph) subgraph(issueid int) ([]*utils.GraphIssue, []graphEdge) {
+	include := func(id int) bool { return true }
+	if issueid != 0 {
+		around := map[int]bool{issueid: true}
+		for _, id := range reach(func(id int) []int { return g.issues[id].Dependencies }, issueid) {
+			around[id] = true
+		}
+		for _, id := range reach(func(id int) []int { return g.dependents[id] }, issueid) {
+			around[id] = true
+		}
+		include = func(id int) bool { return around[id] }
+	}
+
+	var nodes []*utils.GraphIssue
+	var edges []graphEdge
+	for _, id := range g.ids {
+		if !include(id) {
+			continue
+		}
+		nodes = append(nodes, g.issues[id])
+		for _, dep := range g.issues[id].Dependencies {
+			if include(dep) {
+				edges = append(edges, graphEdge{from: dep, to: id})
+			}
+		}
+	}
+
+	return nodes, edges
+}
+
+// ExportGraph renders the dependency graph of the project in `format`:
+// FormatDOT, FormatMermaid or FormatGraphML. Issues are labeled with their
+// titles and colored by their tags, and completed issues are drawn dashed
+// and grayed out. Arrows point from dependencies to the issues waiting on
+// them. If `issueid` is not 0, only the issue, the issues it transitively
+// depends on and those transitively depending on it are exported. The user
+// needs read access to the project.
+func ExportGraph(db *sql.DB, projectid int, issueid int, userid int, format string) ([]byte, error) {
+	if format != FormatDOT && format != FormatMermaid && format != FormatGraphML {
+		return nil, ErrUnknownFormat
+	}
+
+	canRead, err := canReadProject(db, userid, projectid)
+	if err != nil {
+		return nil, err
+	}
+	if !canRead {
+		return nil, ErrNoReadAccess
+	}
+
+	g, err := loadGraph(db, projectid)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := g.issues[issueid]; issueid != 0 && !ok {
+		return nil, ErrIssueNotFound
+	}
+
+	nodes, edges := g.subgraph(issueid)
+	name := "project-" + strconv.Itoa(projectid)
+	if issueid != 0 {
+		name = "issue-" + strconv.Itoa(issueid)
+	}
+
+	switch format {
+	case FormatDOT:
+		return renderDOT(name, nodes, edges), nil
+	case FormatMermaid:
+		return renderMermaid(nodes, edges), nil
+	default:
+		return renderGraphML(name, nodes, edges)
+	}
+}
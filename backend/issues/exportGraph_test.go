@@ -0,0 +1,62 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"errors"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestExportGraph(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	out, err := ExportGraph(db, 1, 0, 1, FormatDOT)
+	if err != nil {
+		t.Fatalf("ExportGraph returned error: %v", err)
+	}
+	got := string(out)
+	for _, want := range []string{
+		`1 [label="Setup Development Environment", fillcolor="#4287f5"];`,
+		"\t1 -> 3;\n", "\t2 -> 4;\n", "\t2 -> 5;\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+
+	// Issue 2 depends on nothing, so only the issues depending on it are around it
+	out, err = ExportGraph(db, 1, 2, 1, FormatMermaid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = string(out)
+	if !strings.Contains(got, "i2 --> i4") || !strings.Contains(got, "i2 --> i5") || strings.Contains(got, "i1") {
+		t.Errorf("expected the neighbourhood of issue 2, got:\n%s", got)
+	}
+
+	// Cycles stored before they were refused still export
+	if _, err = db.Exec(`INSERT INTO DEPENDENCY (issueid, dependency) VALUES (1, 3)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ExportGraph(db, 1, 0, 1, FormatGraphML); err != nil {
+		t.Errorf("expected a graph with a cycle to export, got %v", err)
+	}
+}
+
+func TestExportGraphErrors(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	if _, err := ExportGraph(db, 1, 0, 1, "svg"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+	if _, err := ExportGraph(db, 1, 0, 4, FormatDOT); !errors.Is(err, ErrNoReadAccess) {
+		t.Errorf("expected ErrNoReadAccess, got %v", err)
+	}
+	if _, err := ExportGraph(db, 1, 999, 1, FormatDOT); !errors.Is(err, ErrIssueNotFound) {
+		t.Errorf("expected ErrIssueNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// dependencySummaries returns the issues matching the `join` condition on
+// the DEPENDENCY rows of the issue, leaving out those in projects the user
+// cannot read.
+func dependencySummaries(db *sql.DB, join string, issueid int, userid int) ([]utils.IssueSummary, error) {
+	rows, err := db.Query(
+		`SELECT i.id, pi.projectid, i.title, i.completed
+		FROM DEPENDENCY d
+		JOIN ISSUE i ON i.id = `+join+`
+		JOIN PROJECT_ISSUES pi ON pi.issueid = i.id
+		WHERE EXISTS (
+			SELECT 1 FROM PROJECT_MEMBER pm
+			JOIN PROJECT_MEMBER_ROLE pmr ON pmr.memberid = pm.id
+			JOIN PROJECT_ROLE pr ON pr.id = pmr.roleid
+			WHERE pm.projectid = pi.projectid AND pm.userid = ?2 AND pr.can_read = 1)
+		ORDER BY i.id`,
+		issueid, userid)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := []utils.IssueSummary{}
+	for rows.Next() {
+		var s utils.IssueSummary
+		if err := rows.Scan(&s.ID, &s.ProjectID, &s.Title, &s.Completed); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+
+	return summaries, rows.Err()
+}
+
+// GetDeps lists the issues the issue depends on and the issues depending
+// on it. The user needs read access to the project of the issue; issues
+// in other projects are only listed if the user can read those too.
+func GetDeps(db *sql.DB, issueid int, userid int) (*utils.IssueDependencies, error) {
+	_, canRead, _, err := access(db, userid, issueid)
+	if err != nil {
+		return nil, err
+	}
+	if !canRead {
+		return nil, ErrNoReadAccess
+	}
+
+	dependencies, err := dependencySummaries(db, `d.dependency AND d.issueid = ?1`, issueid, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	dependents, err := dependencySummaries(db, `d.issueid AND d.dependency = ?1`, issueid, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &utils.IssueDependencies{Dependencies: dependencies, Dependents: dependents}, nil
+}
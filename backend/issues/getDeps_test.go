@@ -0,0 +1,72 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"errors"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func ids(summaries []utils.IssueSummary) []int {
+	result := []int{}
+	for _, s := range summaries {
+		result = append(result, s.ID)
+	}
+	return result
+}
+
+func TestGetDeps(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	deps, err := GetDeps(db, 2, 1)
+	if err != nil {
+		t.Fatalf("GetDeps returned error: %v", err)
+	}
+	if len(deps.Dependencies) != 0 || !slices.Equal(ids(deps.Dependents), []int{4, 5}) {
+		t.Errorf("expected issues 4 and 5 to depend on issue 2, got %+v", deps)
+	}
+
+	deps, err = GetDeps(db, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids(deps.Dependencies), []int{1}) || deps.Dependencies[0].Title != "Setup Development Environment" {
+		t.Errorf("expected issue 3 to depend on issue 1, got %+v", deps)
+	}
+
+	if _, err = GetDeps(db, 3, 4); !errors.Is(err, ErrNoReadAccess) {
+		t.Errorf("expected ErrNoReadAccess, got %v", err)
+	}
+}
+
+func TestGetDepsCrossProject(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, err := db.Exec(
+		`INSERT INTO ISSUE (id, title, desc, tagid, created, cost, priority)
+		VALUES (6, 'Build Mobile Login', '', 1, '2023-01-06 10:00:00', 400, 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec(`INSERT INTO PROJECT_ISSUES (projectid, issueid) VALUES (2, 6)`); err != nil {
+		t.Fatal(err)
+	}
+	if err = SetDep(db, 3, 6, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mike cannot read project 2, so issue 6 is left out for him
+	for userid, want := range map[int][]int{1: {1, 6}, 3: {1}} {
+		deps, err := GetDeps(db, 3, userid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(deps.Dependencies); !slices.Equal(got, want) {
+			t.Errorf("user %d: expected %v, got %v", userid, want, got)
+		}
+	}
+}
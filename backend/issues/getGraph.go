@@ -0,0 +1,264 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"container/heap"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	_ "modernc.org/sqlite"
+)
+
+// idHeap is a min-heap of issue ids, so ties in the order go to older issues.
+type idHeap []int
+
+func (h idHeap) Len() int           { return len(h) }
+func (h idHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h idHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *idHeap) Push(x any)        { *h = append(*h, x.(int)) }
+func (h *idHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// graph is the dependency graph of a project in memory.
+type graph struct {
+	issues     map[int]*utils.GraphIssue
+	ids        []int
+	dependents map[int][]int
+	// blockedOutside holds the issues waiting on unfinished issues of
+	// other projects.
+	blockedOutside map[int]bool
+}
+
+// loadGraph reads the issues of the project and their dependencies in a
+// single query.
+func loadGraph(db *sql.DB, projectid int) (*graph, error) {
+	rows, err := db.Query(
+		`SELECT i.id, i.title, i.cost, COALESCE(t.color, ''), i.completed IS NOT NULL,
+			d.dependency, dp.projectid = pi.projectid, dep.completed IS NOT NULL
+		FROM PROJECT_ISSUES pi
+		JOIN ISSUE i ON i.id = pi.issueid
+		LEFT JOIN TAG t ON t.id = i.tagid
+		LEFT JOIN DEPENDENCY d ON d.issueid = i.id
+		LEFT JOIN ISSUE dep ON dep.id = d.dependency
+		LEFT JOIN PROJECT_ISSUES dp ON dp.issueid = d.dependency
+		WHERE pi.projectid = ?
+		ORDER BY i.id, d.dependency`,
+		projectid)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	g := &graph{
+		issues:         map[int]*utils.GraphIssue{},
+		dependents:     map[int][]int{},
+		blockedOutside: map[int]bool{},
+	}
+	for rows.Next() {
+		var issue utils.GraphIssue
+		var dependency sql.NullInt64
+		var sameProject sql.NullBool
+		var dependencyDone bool
+		err := rows.Scan(&issue.ID, &issue.Title, &issue.Cost, &issue.Color, &issue.Completed,
+			&dependency, &sameProject, &dependencyDone)
+		if err != nil {
+			return nil, err
+		}
+
+		node, ok := g.issues[issue.ID]
+		if !ok {
+			issue.Dependencies = []int{}
+			node = &issue
+			g.issues[issue.ID] = node
+			g.ids = append(g.ids, issue.ID)
+		}
+
+		if !dependency.Valid {
+			continue
+		}
+		dep := int(dependency.Int64)
+		if sameProject.Valid && sameProject.Bool {
+			if !slices.Contains(node.Dependencies, dep) {
+				node.Dependencies = append(node.Dependencies, dep)
+				g.dependents[dep] = append(g.dependents[dep], node.ID)
+			}
+		} else if !dependencyDone {
+			g.blockedOutside[node.ID] = true
+		}
+	}
+
+	return g, rows.Err()
+}
+
+// order returns the issues with every issue after its dependencies, or
+// ErrDependencyCycle naming the issues on cycles.
+func (g *graph) order() ([]int, error) {
+	waiting := make(map[int]int, len(g.ids))
+	ready := &idHeap{}
+	for _, id := range g.ids {
+		waiting[id] = len(g.issues[id].Dependencies)
+		if waiting[id] == 0 {
+			*ready = append(*ready, id)
+		}
+	}
+	heap.Init(ready)
+
+	order := make([]int, 0, len(g.ids))
+	for ready.Len() > 0 {
+		id := heap.Pop(ready).(int)
+		order = append(order, id)
+		for _, dependent := range g.dependents[id] {
+			waiting[dependent]--
+			if waiting[dependent] == 0 {
+				heap.Push(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) < len(g.ids) {
+		var stuck []int
+		for _, id := range g.ids {
+			if waiting[id] > 0 {
+				stuck = append(stuck, id)
+			}
+		}
+		return nil, fmt.Errorf("%w among issues %v", ErrDependencyCycle, stuck)
+	}
+
+	return order, nil
+}
+
+// ready returns the unfinished issues whose dependencies are all finished.
+func (g *graph) ready() []int {
+	ready := []int{}
+	for _, id := range g.ids {
+		issue := g.issues[id]
+		if issue.Completed || g.blockedOutside[id] {
+			continue
+		}
+		blocked := slices.ContainsFunc(issue.Dependencies, func(dep int) bool {
+			return !g.issues[dep].Completed
+		})
+		if !blocked {
+			ready = append(ready, id)
+		}
+	}
+	return ready
+}
+
+// criticalPath returns the chain of unfinished issues with the highest
+// total cost, in the given topological order, and that cost.
+func (g *graph) criticalPath(order []int) ([]int, int) {
+	total := make(map[int]int, len(order))
+	previous := map[int]int{}
+	end, best := 0, 0
+
+	for _, id := range order {
+		issue := g.issues[id]
+		if issue.Completed {
+			continue
+		}
+
+		longest := 0
+		for _, dep := range issue.Dependencies {
+			if !g.issues[dep].Completed && total[dep] > longest {
+				longest = total[dep]
+				previous[id] = dep
+			}
+		}
+
+		total[id] = longest + issue.Cost
+		if end == 0 || total[id] > best {
+			end, best = id, total[id]
+		}
+	}
+
+	path := []int{}
+	for id, ok := end, end != 0; ok; id, ok = previous[id] {
+		path = append(path, id)
+	}
+	slices.Reverse(path)
+	return path, best
+}
+
+// reach returns the issues reachable from the issue along `edges`, sorted.
+func reach(edges func(id int) []int, from int) []int {
+	seen := map[int]bool{from: true}
+	queue := []int{from}
+	result := []int{}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range edges(id) {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+				result = append(result, next)
+			}
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// GetGraph analyzes the dependency graph of the project: the order to do
+// the issues in, the issues ready to start and the critical path of the
+// remaining work. Completed issues weigh nothing on the critical path.
+// Issues waiting on unfinished issues of other projects are not ready.
+// If `issueid` is not 0, the issues it transitively depends on and the
+// issues transitively depending on it within the project are included.
+// The user needs read access to the project.
+func GetGraph(db *sql.DB, projectid int, issueid int, userid int) (*utils.IssueGraph, error) {
+	canRead, err := canReadProject(db, userid, projectid)
+	if err != nil {
+		return nil, err
+	}
+	if !canRead {
+		return nil, ErrNoReadAccess
+	}
+
+	g, err := loadGraph(db, projectid)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := g.issues[issueid]; issueid != 0 && !ok {
+		return nil, ErrIssueNotFound
+	}
+
+	order, err := g.order()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &utils.IssueGraph{
+		ProjectID: projectid,
+		Issues:    make([]utils.GraphIssue, 0, len(g.ids)),
+		Order:     order,
+		Ready:     g.ready(),
+	}
+	for _, id := range g.ids {
+		result.Issues = append(result.Issues, *g.issues[id])
+	}
+	result.CriticalPath, result.CriticalCost = g.criticalPath(order)
+
+	if issueid != 0 {
+		result.Issue = &utils.GraphFocus{
+			ID: issueid,
+			Blockers: reach(func(id int) []int {
+				return g.issues[id].Dependencies
+			}, issueid),
+			Dependents: reach(func(id int) []int {
+				return g.dependents[id]
+			}, issueid),
+		}
+	}
+
+	return result, nil
+}
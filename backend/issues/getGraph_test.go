@@ -0,0 +1,100 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"errors"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestGetGraph(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Issue 3 depends on 1, issues 4 and 5 on 2
+	graph, err := GetGraph(db, 1, 2, 1)
+	if err != nil {
+		t.Fatalf("GetGraph returned error: %v", err)
+	}
+	if len(graph.Issues) != 5 || !slices.Equal(graph.Issues[2].Dependencies, []int{1}) {
+		t.Errorf("expected the five issues of project 1, got %+v", graph.Issues)
+	}
+	if !slices.Equal(graph.Order, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected order [1 2 3 4 5], got %v", graph.Order)
+	}
+	if !slices.Equal(graph.Ready, []int{1, 2}) {
+		t.Errorf("expected issues 1 and 2 to be ready, got %v", graph.Ready)
+	}
+	if !slices.Equal(graph.CriticalPath, []int{1, 3}) || graph.CriticalCost != 2000 {
+		t.Errorf("expected critical path [1 3] costing 2000, got %v costing %d", graph.CriticalPath, graph.CriticalCost)
+	}
+	if len(graph.Issue.Blockers) != 0 || !slices.Equal(graph.Issue.Dependents, []int{4, 5}) {
+		t.Errorf("expected issues 4 and 5 to depend on issue 2, got %+v", graph.Issue)
+	}
+
+	// Finished work drops off the critical path and unblocks its dependents
+	if _, err = db.Exec(`UPDATE ISSUE SET completed = '2023-02-01 10:00:00' WHERE id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	graph, err = GetGraph(db, 1, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(graph.Ready, []int{2, 3}) {
+		t.Errorf("expected issues 2 and 3 to be ready, got %v", graph.Ready)
+	}
+	if !slices.Equal(graph.CriticalPath, []int{2, 4}) || graph.CriticalCost != 1800 {
+		t.Errorf("expected critical path [2 4] costing 1800, got %v costing %d", graph.CriticalPath, graph.CriticalCost)
+	}
+	if !slices.Equal(graph.Issue.Blockers, []int{1}) || len(graph.Issue.Dependents) != 0 {
+		t.Errorf("expected issue 3 to depend on issue 1, got %+v", graph.Issue)
+	}
+}
+
+func TestGetGraphOutsideDependency(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, err := db.Exec(
+		`INSERT INTO ISSUE (id, title, desc, tagid, created, cost, priority)
+		VALUES (6, 'Build Mobile Login', '', 1, '2023-01-06 10:00:00', 400, 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(
+		`INSERT INTO PROJECT_ISSUES (projectid, issueid) VALUES (2, 6);
+		INSERT INTO DEPENDENCY (issueid, dependency) VALUES (2, 6)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	graph, err := GetGraph(db, 1, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(graph.Ready, []int{1}) || len(graph.Issues[1].Dependencies) != 0 || graph.Issue != nil {
+		t.Errorf("expected issue 2 to wait on issue 6 outside the graph, got %+v", graph)
+	}
+}
+
+func TestGetGraphErrors(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	if _, err := GetGraph(db, 1, 0, 4); !errors.Is(err, ErrNoReadAccess) {
+		t.Errorf("expected ErrNoReadAccess, got %v", err)
+	}
+	if _, err := GetGraph(db, 1, 999, 1); !errors.Is(err, ErrIssueNotFound) {
+		t.Errorf("expected ErrIssueNotFound, got %v", err)
+	}
+
+	// Cycles stored before they were refused
+	if _, err := db.Exec(`INSERT INTO DEPENDENCY (issueid, dependency) VALUES (1, 3)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetGraph(db, 1, 0, 1); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("expected ErrDependencyCycle, got %v", err)
+	}
+}
@@ -8,11 +8,20 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-// GetIssueDep fetches all issues that the issue depends on.
-func getIssueDep(db *sql.DB, issue *utils.Issue) error {
+// GetIssueDep fetches all issues that the issue depends on, leaving out
+// those in projects the user cannot read.
+func getIssueDep(db *sql.DB, issue *utils.Issue, userid int) error {
 	rows, err := db.Query(
-		`SELECT dependency FROM DEPENDENCY WHERE issueid = ?`,
-		issue.ID)
+		`SELECT d.dependency
+		FROM DEPENDENCY d
+		JOIN PROJECT_ISSUES pi ON pi.issueid = d.dependency
+		WHERE d.issueid = ? AND EXISTS (
+			SELECT 1 FROM PROJECT_MEMBER pm
+			JOIN PROJECT_MEMBER_ROLE pmr ON pmr.memberid = pm.id
+			JOIN PROJECT_ROLE pr ON pr.id = pmr.roleid
+			WHERE pm.projectid = pi.projectid AND pm.userid = ? AND pr.can_read = 1)
+		ORDER BY d.dependency`,
+		issue.ID, userid)
 
 	if err != nil {
 		return err
@@ -20,6 +29,7 @@ func getIssueDep(db *sql.DB, issue *utils.Issue) error {
 
 	defer rows.Close()
 
+	issue.Dependencies = []int{}
 	for rows.Next() {
 		var dep int
 
@@ -31,18 +41,27 @@ func getIssueDep(db *sql.DB, issue *utils.Issue) error {
 		issue.Dependencies = append(issue.Dependencies, dep)
 	}
 
-	return nil
+	return rows.Err()
 }
 
-// GetIssue fetches issue details and returns them as a JSON string
-func GetIssue(db *sql.DB, issueid int) (string, error) {
+// GetIssue fetches issue details for the user and returns them as a JSON string.
+// The user needs read access to the project of the issue.
+func GetIssue(db *sql.DB, issueid int, userid int) (string, error) {
+	_, canRead, _, err := access(db, userid, issueid)
+	if err != nil {
+		return "", err
+	}
+	if !canRead {
+		return "", ErrNoReadAccess
+	}
+
 	row := db.QueryRow("SELECT title, desc, tagid, priority, created, completed, cost FROM ISSUE WHERE id = ?", issueid)
 
 	var issue utils.Issue
 	issue.ID = issueid
 
 	// Scan row into variables
-	err := row.Scan(
+	err = row.Scan(
 		&issue.Title, 
 		&issue.Desc, 
 		&issue.TagID, 
@@ -55,6 +74,11 @@ func GetIssue(db *sql.DB, issueid int) (string, error) {
 		return "", err
 	}
 
+	err = getIssueDep(db, &issue, userid)
+	if err != nil {
+		return "", err
+	}
+
 	// Convert map to JSON
 	jsonData, err := json.Marshal(issue)
 	if err != nil {
@@ -2,7 +2,9 @@ package issues
 
 import (
 	"brickedup/backend/utils"
+	"errors"
 	"strconv"
+	"strings"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -23,10 +25,64 @@ func TestGetIssue(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run("Testing issue ID "+strconv.Itoa(tt.issueID), func(t *testing.T) {
-			_, err := GetIssue(db, tt.issueID)
+			_, err := GetIssue(db, tt.issueID, 1)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getIssueDetails() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestGetIssueDependencies(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	for issueID, want := range map[int]string{1: `"dependencies":[]`, 4: `"dependencies":[2]`} {
+		jsonStr, err := GetIssue(db, issueID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(jsonStr, want) {
+			t.Errorf("issue %d: expected %s, got %s", issueID, want, jsonStr)
+		}
+	}
+}
+
+func TestGetIssueDependenciesCrossProject(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, err := db.Exec(
+		`INSERT INTO ISSUE (id, title, desc, tagid, created, cost, priority)
+		VALUES (6, 'Build Mobile Login', '', 1, '2023-01-06 10:00:00', 400, 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec(`INSERT INTO PROJECT_ISSUES (projectid, issueid) VALUES (2, 6)`); err != nil {
+		t.Fatal(err)
+	}
+	if err = SetDep(db, 3, 6, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mike cannot read project 2, so issue 6 is left out for him
+	for userid, want := range map[int]string{1: `"dependencies":[1,6]`, 3: `"dependencies":[1]`} {
+		jsonStr, err := GetIssue(db, 3, userid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(jsonStr, want) {
+			t.Errorf("user %d: expected %s, got %s", userid, want, jsonStr)
+		}
+	}
+}
+
+func TestGetIssueNoReadAccess(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Sarah is not a member of project 1
+	if _, err := GetIssue(db, 1, 4); !errors.Is(err, ErrNoReadAccess) {
+		t.Errorf("expected ErrNoReadAccess, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Formats the dependency graph can be exported to.
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatGraphML = "graphml"
+)
+
+// completedColor is the outline and text color of completed issues.
+const completedColor = "#888888"
+
+// graphEdge points from a dependency to the issue depending on it, the
+// direction the work flows in.
+type graphEdge struct {
+	from int
+	to   int
+}
+
+// tagColor returns the color if it is a hex color, which keeps stored
+// colors from breaking out of the exported formats.
+func tagColor(color string) string {
+	v := validate.New()
+	v.HexColor("color", color)
+	if v.Failed("color") {
+		return ""
+	}
+	return color
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "")
+
+// renderDOT renders the graph in the Graphviz DOT language.
+func renderDOT(name string, nodes []*utils.GraphIssue, edges []graphEdge) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "digraph \"%s\" {\n", dotEscaper.Replace(name))
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\"];\n")
+
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "\t%d [label=\"%s\"", n.ID, dotEscaper.Replace(n.Title))
+		if color := tagColor(n.Color); color != "" {
+			fmt.Fprintf(&b, ", fillcolor=\"%s\"", color)
+		}
+		if n.Completed {
+			fmt.Fprintf(&b, ", style=\"rounded,filled,dashed\", color=\"%s\", fontcolor=\"%s\"", completedColor, completedColor)
+		}
+		b.WriteString("];\n")
+	}
+
+	for _, e := range edges {
+		fmt.Fprintf(&b, "\t%d -> %d;\n", e.from, e.to)
+	}
+
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// Mermaid reads #...; as entity codes, and labels may be rendered as HTML.
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "#", "#35;", "<", "#lt;", ">", "#gt;", "\n", " ", "\r", "")
+
+// renderMermaid renders the graph as a Mermaid flowchart.
+func renderMermaid(nodes []*utils.GraphIssue, edges []graphEdge) []byte {
+	var b bytes.Buffer
+	b.WriteString("flowchart LR\n")
+
+	var completed []string
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "    i%d[\"%s\"]\n", n.ID, mermaidEscaper.Replace(n.Title))
+		if n.Completed {
+			completed = append(completed, "i"+strconv.Itoa(n.ID))
+		}
+	}
+
+	for _, e := range edges {
+		fmt.Fprintf(&b, "    i%d --> i%d\n", e.from, e.to)
+	}
+
+	for _, n := range nodes {
+		if color := tagColor(n.Color); color != "" {
+			fmt.Fprintf(&b, "    style i%d fill:%s\n", n.ID, color)
+		}
+	}
+
+	if len(completed) > 0 {
+		fmt.Fprintf(&b, "    classDef completed stroke-dasharray:5 5,color:%s\n", completedColor)
+		fmt.Fprintf(&b, "    class %s completed\n", strings.Join(completed, ","))
+	}
+
+	return b.Bytes()
+}
+
+// graphML is the document written by renderGraphML.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+// renderGraphML renders the graph as GraphML. GraphML has no styles, so the
+// title, tag color, cost and completion of issues are node data.
+func renderGraphML(name string, nodes []*utils.GraphIssue, edges []graphEdge) ([]byte, error) {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "title", For: "node", Name: "title", Type: "string"},
+			{ID: "color", For: "node", Name: "color", Type: "string"},
+			{ID: "cost", For: "node", Name: "cost", Type: "int"},
+			{ID: "completed", For: "node", Name: "completed", Type: "boolean"},
+		},
+	}
+	doc.Graph.ID = name
+	doc.Graph.EdgeDefault = "directed"
+
+	for _, n := range nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: "i" + strconv.Itoa(n.ID),
+			Data: []graphMLData{
+				{Key: "title", Value: n.Title},
+				{Key: "color", Value: tagColor(n.Color)},
+				{Key: "cost", Value: strconv.Itoa(n.Cost)},
+				{Key: "completed", Value: strconv.FormatBool(n.Completed)},
+			},
+		})
+	}
+
+	for _, e := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: "i" + strconv.Itoa(e.from),
+			Target: "i" + strconv.Itoa(e.to),
+		})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
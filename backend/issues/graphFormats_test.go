@@ -0,0 +1,85 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// sampleGraph is a small graph: issue 2 depends on issue 1, which is done.
+func sampleGraph() ([]*utils.GraphIssue, []graphEdge) {
+	nodes := []*utils.GraphIssue{
+		{ID: 1, Title: `Say "hi" #1`, Cost: 100, Color: "#4287f5", Completed: true},
+		{ID: 2, Title: `Back\slash <b>`, Cost: 200, Color: `red"];`},
+	}
+	return nodes, []graphEdge{{from: 1, to: 2}}
+}
+
+func TestTagColor(t *testing.T) {
+	tests := map[string]string{"#4287f5": "#4287f5", "#fff": "#fff", "red": "", `#fff"]`: "", "": ""}
+	for color, want := range tests {
+		if got := tagColor(color); got != want {
+			t.Errorf("%q: expected %q, got %q", color, want, got)
+		}
+	}
+}
+
+func TestRenderDOT(t *testing.T) {
+	nodes, edges := sampleGraph()
+	got := string(renderDOT("project-1", nodes, edges))
+
+	for _, want := range []string{
+		"digraph \"project-1\" {\n",
+		`1 [label="Say \"hi\" #1", fillcolor="#4287f5", style="rounded,filled,dashed", color="#888888", fontcolor="#888888"];`,
+		`2 [label="Back\\slash <b>"];`,
+		"\t1 -> 2;\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestRenderMermaid(t *testing.T) {
+	nodes, edges := sampleGraph()
+	got := string(renderMermaid(nodes, edges))
+
+	for _, want := range []string{
+		"flowchart LR\n",
+		`i1["Say #quot;hi#quot; #35;1"]`,
+		`i2["Back\slash #lt;b#gt;"]`,
+		"i1 --> i2\n",
+		"style i1 fill:#4287f5\n",
+		"class i1 completed\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "style i2") {
+		t.Errorf("expected no style for an invalid color:\n%s", got)
+	}
+}
+
+func TestRenderGraphML(t *testing.T) {
+	nodes, edges := sampleGraph()
+	out, err := renderGraphML("project-1", nodes, edges)
+	if err != nil {
+		t.Fatalf("renderGraphML returned error: %v", err)
+	}
+
+	var doc graphML
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("expected valid XML: %v\n%s", err, out)
+	}
+	if len(doc.Graph.Nodes) != 2 || len(doc.Graph.Edges) != 1 || doc.Graph.EdgeDefault != "directed" {
+		t.Fatalf("expected two nodes and an edge, got %+v", doc.Graph)
+	}
+	if data := doc.Graph.Nodes[0].Data; data[0].Value != `Say "hi" #1` || data[3].Value != "true" {
+		t.Errorf("expected the title and completion of issue 1, got %+v", data)
+	}
+	if edge := doc.Graph.Edges[0]; edge.Source != "i1" || edge.Target != "i2" {
+		t.Errorf("expected an edge from i1 to i2, got %+v", edge)
+	}
+}
@@ -0,0 +1,37 @@
+package issues
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// RemoveDep removes the dependency of the issue on `dependency`. The user
+// needs write access to the project of the issue.
+func RemoveDep(db *sql.DB, issueid int, dependency int, userid int) error {
+	_, _, canWrite, err := access(db, userid, issueid)
+	if err != nil {
+		return err
+	}
+	if !canWrite {
+		return ErrInsufficientPrivileges
+	}
+
+	result, err := db.Exec(
+		`DELETE FROM DEPENDENCY WHERE issueid = ? AND dependency = ?`,
+		issueid, dependency)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrDependencyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package issues
+
+import (
+	"brickedup/backend/utils"
+	"errors"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRemoveDep(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// User 4 is not a member of project 1
+	if err := RemoveDep(db, 3, 1, 4); !errors.Is(err, ErrInsufficientPrivileges) {
+		t.Errorf("expected ErrInsufficientPrivileges, got %v", err)
+	}
+
+	if err := RemoveDep(db, 3, 1, 1); err != nil {
+		t.Fatalf("RemoveDep returned error: %v", err)
+	}
+
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM DEPENDENCY WHERE issueid = 3`).Scan(&count)
+	if count != 0 {
+		t.Errorf("expected the dependency to be removed, %d left", count)
+	}
+
+	if err := RemoveDep(db, 3, 1, 1); !errors.Is(err, ErrDependencyNotFound) {
+		t.Errorf("expected ErrDependencyNotFound, got %v", err)
+	}
+	if err := RemoveDep(db, 999, 1, 1); !errors.Is(err, ErrIssueNotFound) {
+		t.Errorf("expected ErrIssueNotFound, got %v", err)
+	}
+}
@@ -3,101 +3,153 @@ package issues
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 
 	_ "modernc.org/sqlite"
 )
 
-// SetDep assigns the dependency to the issue.
-func SetDep(db *sql.DB, issueid int, dependency int, sessionid int) error {
+// Errors returned when changing dependencies.
+var (
+	ErrSelfDependency     = errors.New("issue cannot depend on itself")
+	ErrDependencyExists   = errors.New("dependency already exists")
+	ErrDependencyNotFound = errors.New("dependency not found")
+	ErrDependencyCycle    = errors.New("dependency would create a cycle")
+)
 
-	// Look up the userID in the SESSION table.
-	var userid int
-	err := db.QueryRow("SELECT userid FROM SESSION WHERE id = ?", sessionid).Scan(&userid)
+// dependencyPath returns the issues leading from `from` to `to` through
+// dependencies, both included, or nil if `to` cannot be reached.
+func dependencyPath(tx *sql.Tx, from int, to int) ([]int, error) {
+	rows, err := tx.Query(`SELECT issueid, dependency FROM DEPENDENCY`)
 	if err != nil {
-		// If no row is found, return a custom error message.
-		if err == sql.ErrNoRows {
-			return errors.New("no session found for session ID " + strconv.Itoa(sessionid))
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := map[int][]int{}
+	for rows.Next() {
+		var issueid, dependency int
+		if err := rows.Scan(&issueid, &dependency); err != nil {
+			return nil, err
 		}
-		// Otherwise, return the original error from the DB.
-		return err
+		edges[issueid] = append(edges[issueid], dependency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	// Check if the issues exist
-	var existsA, existsB bool
+	// Breadth first, so the shortest path is named
+	previous := map[int]int{from: from}
+	queue := []int{from}
+	for len(queue) > 0 {
+		issue := queue[0]
+		queue = queue[1:]
 
-	err = db.QueryRow("SELECT COUNT(*) > 0 FROM ISSUE WHERE id = ?", issueid).Scan(&existsA)
-	if err != nil {
-		return err
+		if issue == to {
+			path := []int{to}
+			for issue != from {
+				issue = previous[issue]
+				path = append([]int{issue}, path...)
+			}
+			return path, nil
+		}
+
+		for _, next := range edges[issue] {
+			if _, seen := previous[next]; !seen {
+				previous[next] = issue
+				queue = append(queue, next)
+			}
+		}
 	}
-	if !existsA {
-		return errors.New("issue does not exist: " + strconv.Itoa(issueid))
+
+	return nil, nil
+}
+
+// cycleName names the issues of the cycle for the user, hiding the ids of
+// issues in projects the user cannot read.
+func cycleName(tx *sql.Tx, userid int, cycle []int) (string, error) {
+	names := []string{}
+	for _, issue := range cycle {
+		_, canRead, _, err := access(tx, userid, issue)
+		if err != nil && !errors.Is(err, ErrIssueNotFound) {
+			return "", err
+		}
+
+		if canRead {
+			names = append(names, strconv.Itoa(issue))
+		} else {
+			names = append(names, "?")
+		}
 	}
 
-	err = db.QueryRow("SELECT COUNT(*) > 0 FROM ISSUE WHERE id = ?", dependency).Scan(&existsB)
+	return strings.Join(names, " -> "), nil
+}
+
+// SetDep makes the issue depend on `dependency`. The user needs write
+// access to the project of the issue and read access to the project of the
+// dependency, which may be another project. Dependencies that would close
+// a cycle are refused with ErrDependencyCycle naming the cycle, as far as
+// the user can read it.
+func SetDep(db *sql.DB, issueid int, dependency int, userid int) error {
+	if issueid == dependency {
+		return ErrSelfDependency
+	}
+
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	if !existsB {
-		return errors.New("issue does not exist: " + strconv.Itoa(dependency))
-	}
-
-	// Check if the user has write access to both issues
-	query := `
-        SELECT pi.issueid, pr.can_write
-        FROM PROJECT_ISSUES pi
-        JOIN PROJECT_MEMBER pm ON pi.projectid = pm.projectid
-        JOIN PROJECT_MEMBER_ROLE pmr ON pm.id = pmr.memberid
-        JOIN PROJECT_ROLE pr ON pmr.roleid = pr.id
-        WHERE pi.issueid IN (?, ?)
-          AND pm.userid = ?;
-    `
-	rows, err := db.Query(query, dependency, issueid, userid)
+	defer tx.Rollback()
+
+	_, _, canWrite, err := access(tx, userid, issueid)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-
-	access := map[int]bool{}
-	for rows.Next() {
-		var issueID int
-		var canWrite bool
-		if err := rows.Scan(&issueID, &canWrite); err != nil {
-			return err
-		}
-		if canWrite {
-			access[issueID] = true
-		}
+	if !canWrite {
+		return ErrInsufficientPrivileges
 	}
 
-	// Ensure access exists for both issues
-	if !access[issueid] || !access[dependency] {
-		return errors.New("user lacks write access to one or both issues")
+	_, canRead, _, err := access(tx, userid, dependency)
+	if err != nil {
+		return err
+	}
+	if !canRead {
+		return ErrNoReadAccess
 	}
 
-	// Check for existing dependency
-	var alreadyExists bool
-	err = db.QueryRow(
-		`SELECT COUNT(*) > 0 
-		FROM DEPENDENCY 
-		WHERE issueid = ? AND dependency = ?`, 
-		dependency, issueid).Scan(&alreadyExists)
+	var exists bool
+	err = tx.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM DEPENDENCY WHERE issueid = ? AND dependency = ?)`,
+		issueid, dependency).Scan(&exists)
 
 	if err != nil {
 		return err
 	}
-	if alreadyExists {
-		return errors.New("dependency already exists")
+	if exists {
+		return ErrDependencyExists
+	}
+
+	// The new dependency closes a cycle if it leads back to the issue
+	path, err := dependencyPath(tx, dependency, issueid)
+	if err != nil {
+		return err
+	}
+	if path != nil {
+		cycle, err := cycleName(tx, userid, append([]int{issueid}, path...))
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrDependencyCycle, cycle)
 	}
 
-	// Insert the dependency
-	_, err = db.Exec(
-		`INSERT INTO DEPENDENCY (issueid, dependency) 
-		VALUES (?, ?)`, dependency, issueid)
+	_, err = tx.Exec(
+		`INSERT INTO DEPENDENCY (issueid, dependency) VALUES (?, ?)`,
+		issueid, dependency)
 
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
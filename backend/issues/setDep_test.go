@@ -2,6 +2,8 @@ package issues
 
 import (
 	"brickedup/backend/utils"
+	"errors"
+	"strings"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -21,8 +23,8 @@ func TestSetDep(t *testing.T) {
 	}{
 		{
 			name:         "Already Inserted dependency relation",
-			issueBid:     3,
-			issueAid:     1,
+			issueBid:     1,
+			issueAid:     3,
 			userid:       1,
 			wantErr:      true,
 			wantInserted: false,
@@ -72,7 +74,7 @@ func TestSetDep(t *testing.T) {
 					`SELECT COUNT(*) 
 					FROM DEPENDENCY 
 					WHERE issueid = ? AND dependency = ?`,
-					tc.issueBid, tc.issueAid).Scan(&count)
+					tc.issueAid, tc.issueBid).Scan(&count)
 
 				if err != nil {
 					t.Fatalf("failed to query DEPENDENCY table: %v", err)
@@ -84,3 +86,83 @@ func TestSetDep(t *testing.T) {
 		})
 	}
 }
+
+func TestSetDepCycle(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	if err := SetDep(db, 2, 2, 1); !errors.Is(err, ErrSelfDependency) {
+		t.Errorf("expected ErrSelfDependency, got %v", err)
+	}
+
+	// Issue 3 already depends on issue 1
+	err := SetDep(db, 1, 3, 1)
+	if !errors.Is(err, ErrDependencyCycle) || !strings.HasSuffix(err.Error(), ": 1 -> 3 -> 1") {
+		t.Errorf("expected the cycle 1 -> 3 -> 1, got %v", err)
+	}
+
+	if err = SetDep(db, 1, 5, 1); err != nil {
+		t.Fatal(err)
+	}
+	err = SetDep(db, 2, 3, 1)
+	if !errors.Is(err, ErrDependencyCycle) || !strings.HasSuffix(err.Error(), ": 2 -> 3 -> 1 -> 5 -> 2") {
+		t.Errorf("expected the cycle 2 -> 3 -> 1 -> 5 -> 2, got %v", err)
+	}
+}
+
+func TestSetDepCrossProject(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Issue 6 is in project 2, which Mike is not a member of
+	_, err := db.Exec(
+		`INSERT INTO ISSUE (id, title, desc, tagid, created, cost, priority)
+		VALUES (6, 'Build Mobile Login', '', 1, '2023-01-06 10:00:00', 400, 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec(`INSERT INTO PROJECT_ISSUES (projectid, issueid) VALUES (2, 6)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = SetDep(db, 3, 6, 3); !errors.Is(err, ErrNoReadAccess) {
+		t.Errorf("expected ErrNoReadAccess, got %v", err)
+	}
+	if err = SetDep(db, 6, 3, 3); !errors.Is(err, ErrInsufficientPrivileges) {
+		t.Errorf("expected ErrInsufficientPrivileges, got %v", err)
+	}
+	if err = SetDep(db, 3, 6, 1); err != nil {
+		t.Errorf("expected John to link both projects, got %v", err)
+	}
+}
+
+// TestSetDepCycleHidden checks that cycles do not name issues the user
+// cannot read.
+func TestSetDepCycleHidden(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Issue 6 in project 2, which Mike cannot read, links issues 2 and 3
+	_, err := db.Exec(
+		`INSERT INTO ISSUE (id, title, desc, tagid, created, cost, priority)
+		VALUES (6, 'Build Mobile Login', '', 1, '2023-01-06 10:00:00', 400, 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec(`INSERT INTO PROJECT_ISSUES (projectid, issueid) VALUES (2, 6)`); err != nil {
+		t.Fatal(err)
+	}
+	if err = SetDep(db, 2, 6, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = SetDep(db, 6, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for userid, want := range map[int]string{1: ": 1 -> 4 -> 2 -> 6 -> 3 -> 1", 3: ": 1 -> 4 -> 2 -> ? -> 3 -> 1"} {
+		err = SetDep(db, 1, 4, userid)
+		if !errors.Is(err, ErrDependencyCycle) || !strings.HasSuffix(err.Error(), want) {
+			t.Errorf("user %d: expected the cycle%s, got %v", userid, want, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package issues
 
 import (
     "brickedup/backend/utils"
+    "brickedup/backend/validate"
     "database/sql"
     "errors"
     "strconv"
@@ -9,12 +10,17 @@ import (
     _ "modernc.org/sqlite"
 )
 
-// UpdateIssue retrieves the issue by ID, sanitizes its Title & Desc,
+// UpdateIssue retrieves the issue by ID, validates its Title & Desc,
 // and writes the new values back to the ISSUE table in one shot (autocommit).
-func UpdateIssue(db *sql.DB, issueID int, issue *utils.Issue) error {
-    // 1) Sanitize free-text fields
-    issue.Title = utils.SanitizeText(issue.Title, utils.TEXT)
-    issue.Desc  = utils.SanitizeText(issue.Desc,  utils.TEXT)
+// The user needs write access to the project of the issue.
+func UpdateIssue(db *sql.DB, issueID int, issue *utils.Issue, userID int) error {
+    // 1) Validate free-text fields
+    v := validate.New()
+    v.Line("title", issue.Title, validate.MaxTitleLength)
+    v.Text("desc", issue.Desc, validate.MaxTextLength)
+    if err := v.Err(); err != nil {
+        return err
+    }
 
     // 2) Verify the issue exists
     var id int
@@ -29,7 +35,16 @@ func UpdateIssue(db *sql.DB, issueID int, issue *utils.Issue) error {
         return err
     }
 
-    // 3) Perform the UPDATE (autocommitted)
+    // 3) Check the user may edit the project of the issue
+    _, _, canWrite, err := access(db, userID, issueID)
+    if err != nil {
+        return err
+    }
+    if !canWrite {
+        return ErrInsufficientPrivileges
+    }
+
+    // 4) Perform the UPDATE (autocommitted)
     const q = `
         UPDATE ISSUE
            SET title     = ?,
@@ -17,6 +17,7 @@ func TestUpdateIssue(t *testing.T) {
         name     string
         seed     bool
         issueID  int
+        userID   int
         update   *utils.Issue
         wantErr  bool
         errMsg   string
@@ -25,6 +26,7 @@ func TestUpdateIssue(t *testing.T) {
             name:    "success",
             seed:    true,
             issueID: 1,
+            userID:  1,
             update: &utils.Issue{
                 Title:    "New Title",
                 Desc:     "New Desc",
@@ -39,6 +41,7 @@ func TestUpdateIssue(t *testing.T) {
             name:    "not found",
             seed:    false,
             issueID: 999,
+            userID:  1,
             update: &utils.Issue{
                 Title:    "X",
                 Desc:     "Y",
@@ -50,6 +53,23 @@ func TestUpdateIssue(t *testing.T) {
             wantErr: true,
             errMsg:  "no issue found for issue ID 999",
         },
+        {
+            // Sarah is not a member of project 1
+            name:    "no write access",
+            seed:    true,
+            issueID: 1,
+            userID:  4,
+            update: &utils.Issue{
+                Title:    "X",
+                Desc:     "Y",
+                Cost:     1,
+                TagID:    1,
+                Priority: 1,
+                Completed: sql.NullTime{Valid: false},
+            },
+            wantErr: true,
+            errMsg:  ErrInsufficientPrivileges.Error(),
+        },
     }
 
     for _, tc := range tests {
@@ -76,9 +96,13 @@ func TestUpdateIssue(t *testing.T) {
                 if err != nil {
                     t.Fatalf("seeding ISSUE failed: %v", err)
                 }
+                _, err = db.Exec(`INSERT INTO PROJECT_ISSUES (projectid, issueid) VALUES (1, ?)`, tc.issueID)
+                if err != nil {
+                    t.Fatalf("seeding PROJECT_ISSUES failed: %v", err)
+                }
             }
 
-            err := UpdateIssue(db, tc.issueID, tc.update)
+            err := UpdateIssue(db, tc.issueID, tc.update, tc.userID)
             if tc.wantErr {
                 if err == nil {
                     t.Fatalf("expected error but got nil")
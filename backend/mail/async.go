@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"log"
+	"sync"
+)
+
+// Async sends messages in the background through another mailer, so the
+// request that triggered an email does not wait for it. Failures are logged.
+type Async struct {
+	mailer Mailer
+	wg     sync.WaitGroup
+}
+
+// NewAsync wraps the mailer so it sends in the background.
+func NewAsync(mailer Mailer) *Async {
+	return &Async{mailer: mailer}
+}
+
+// Send queues the message and returns immediately.
+func (a *Async) Send(msg Message) error {
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		if err := a.mailer.Send(msg); err != nil {
+			log.Printf("Failed to send email to %s: %s\n", msg.To, err)
+		}
+	}()
+
+	return nil
+}
+
+// Wait blocks until all queued messages were handed to the mailer.
+func (a *Async) Wait() {
+	a.wg.Wait()
+}
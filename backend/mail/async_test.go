@@ -0,0 +1,35 @@
+package mail
+
+import (
+	"errors"
+	"testing"
+)
+
+type failingMailer struct{}
+
+func (failingMailer) Send(msg Message) error {
+	return errors.New("unreachable")
+}
+
+func TestAsync(t *testing.T) {
+	recorder := &Recorder{}
+	async := NewAsync(recorder)
+
+	for i := 0; i < 3; i++ {
+		if err := async.Send(Message{To: "john.doe@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	async.Wait()
+
+	if n := len(recorder.Messages()); n != 3 {
+		t.Errorf("expected 3 messages, got %d", n)
+	}
+
+	// Delivery failures are logged, not returned
+	failing := NewAsync(failingMailer{})
+	if err := failing.Send(Message{To: "john.doe@example.com"}); err != nil {
+		t.Errorf("Send returned a delivery error: %v", err)
+	}
+	failing.Wait()
+}
@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"log"
+	"regexp"
+)
+
+// secretParamRegex matches the values of the URL parameters that carry
+// tokens in links, like reset, login and verification links.
+var secretParamRegex = regexp.MustCompile(`([?&](?:amp;)?(?:token|code)=)[^&\s"'<>]+`)
+
+// redactLinks replaces the tokens in the links of `body`, so logged emails
+// cannot be used to log in or take over an account.
+func redactLinks(body string) string {
+	return secretParamRegex.ReplaceAllString(body, "${1}REDACTED")
+}
+
+// ConsoleMailer writes messages to the log instead of sending them.
+// It is meant for development.
+type ConsoleMailer struct {
+	// Redact hides the tokens of links in the logged messages.
+	Redact bool
+}
+
+// NewConsole returns a ConsoleMailer and warns in the log that emails are
+// not delivered.
+func NewConsole(redact bool) *ConsoleMailer {
+	if redact {
+		log.Println("WARNING: emails are not delivered but logged, with the tokens of links redacted. Set MAIL_DRIVER to send them.")
+	} else {
+		log.Println("WARNING: emails are not delivered but logged, including live login and password reset links. Only use MAIL_DRIVER=console for development.")
+	}
+	return &ConsoleMailer{Redact: redact}
+}
+
+// Send logs the message.
+func (c *ConsoleMailer) Send(msg Message) error {
+	body := msg.Text
+	if body == "" {
+		body = msg.HTML
+	}
+	if c.Redact {
+		body = redactLinks(body)
+	}
+
+	log.Printf("Email to %s: %s\n%s\n", msg.To, msg.Subject, body)
+	return nil
+}
@@ -0,0 +1,41 @@
+package mail
+
+import "testing"
+
+func TestRedactLinks(t *testing.T) {
+	tests := map[string]string{
+		"Reset it: https://example.com/reset-password?token=abc-123_x\n": "Reset it: https://example.com/reset-password?token=REDACTED\n",
+		`<a href="https://example.com/verify?code=0f3a">Verify</a>`:      `<a href="https://example.com/verify?code=REDACTED">Verify</a>`,
+		"https://example.com/join?kind=org&amp;token=t0k3n&x=1":          "https://example.com/join?kind=org&amp;token=REDACTED&x=1",
+		"Your code is valid for 10 minutes":                              "Your code is valid for 10 minutes",
+	}
+	for body, want := range tests {
+		if got := redactLinks(body); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestFromEnvConsoleRedacts(t *testing.T) {
+	defer func(previous string) { BaseURL = previous }(BaseURL)
+
+	tests := []struct {
+		driver  string
+		baseURL string
+		redact  bool
+	}{
+		{"", "http://localhost:3000", false},
+		{"", "https://brickedup.example.com", true},
+		{"console", "https://brickedup.example.com", false},
+		{"carrier-pigeon", "http://localhost:3000", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("MAIL_DRIVER", tt.driver)
+		BaseURL = tt.baseURL
+
+		console, ok := FromEnv().(*ConsoleMailer)
+		if !ok || console.Redact != tt.redact {
+			t.Errorf("%q on %s: expected the console driver redacting %v, got %#v", tt.driver, tt.baseURL, tt.redact, console)
+		}
+	}
+}
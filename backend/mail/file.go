@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// fileCounter keeps the names of files written in the same instant unique.
+var fileCounter atomic.Uint64
+
+// FileMailer stores every message as a file in the maildir `Dir`, where it
+// can be read with any mail client that supports maildirs.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send writes the message to the "new" directory of the maildir.
+func (f *FileMailer) Send(msg Message) error {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		err := os.MkdirAll(filepath.Join(f.Dir, sub), 0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	var body bytes.Buffer
+	err := writeMessage(&body, f.From, msg)
+	if err != nil {
+		return err
+	}
+
+	hostname, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%d_%d.%s",
+		time.Now().Unix(), os.Getpid(), fileCounter.Add(1), hostname)
+
+	// Messages are moved to "new" only once they are complete.
+	tmp := filepath.Join(f.Dir, "tmp", name)
+	err = os.WriteFile(tmp, body.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(f.Dir, "new", name))
+}
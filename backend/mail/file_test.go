@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := &FileMailer{Dir: dir, From: "noreply@example.com"}
+
+	for i := 0; i < 2; i++ {
+		err := mailer.Send(Message{To: "jane.smith@example.com", Subject: "Hi", Text: "body"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("expected 2 messages in the maildir, got %d", len(files))
+	}
+
+	leftover, _ := os.ReadDir(filepath.Join(dir, "tmp"))
+	if len(leftover) != 0 {
+		t.Errorf("messages were left in tmp")
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "new", files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(raw), "To: jane.smith@example.com") {
+		t.Errorf("unexpected message:\n%s", raw)
+	}
+}
@@ -0,0 +1,21 @@
+package mail
+
+import (
+	"net/url"
+	"strings"
+)
+
+// BaseURL is the public address of the frontend that links in emails point
+// to. It is configured with FRONTEND_URL and is independent of the address
+// the backend listens on.
+var BaseURL = envOr("FRONTEND_URL", "http://localhost:3000")
+
+// Link returns the absolute URL of the frontend page at `path` with the
+// given query parameters.
+func Link(path string, query url.Values) string {
+	link := strings.TrimRight(BaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
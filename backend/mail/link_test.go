@@ -0,0 +1,25 @@
+package mail
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestLink(t *testing.T) {
+	previous := BaseURL
+	defer func() { BaseURL = previous }()
+
+	BaseURL = "https://brickedup.example.com/"
+
+	got := Link("/verify", url.Values{"code": {"a b&c"}})
+	want := "https://brickedup.example.com/verify?code=a+b%26c"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got = Link("reset-password", nil)
+	want = "https://brickedup.example.com/reset-password"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+// Package mail delivers the emails sent by the backend.
+//
+// Emails are handed to a Mailer. Which driver is used is configured with the
+// MAIL_DRIVER environment variable: "smtp", "file" or "console" (the
+// default). Unless chosen explicitly, the console driver redacts the tokens
+// of links when FRONTEND_URL is served over https, as that is no
+// development setup. Code that sends email uses Send, which goes through Default,
+// usually by way of SendTemplate.
+package mail
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"gopkg.in/gomail.v2"
+)
+
+// Message is an email to a single recipient. HTML and Text are the two
+// alternative bodies of the email; either may be left empty.
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Default is the mailer used by Send. Tests can replace it with a Recorder.
+var Default Mailer = FromEnv()
+
+// Send delivers the message with the Default mailer.
+func Send(msg Message) error {
+	return Default.Send(msg)
+}
+
+// FromEnv returns the mailer configured by the environment. The SMTP and
+// file drivers send in the background so callers are not held up by them.
+func FromEnv() Mailer {
+	from := envOr("MAIL_FROM", os.Getenv("EMAIL"))
+
+	// Logged links are live secrets outside of development
+	redact := os.Getenv("MAIL_DRIVER") == "" && strings.HasPrefix(BaseURL, "https://")
+
+	switch driver := envOr("MAIL_DRIVER", "console"); driver {
+	case "smtp":
+		return NewAsync(SMTPFromEnv(from))
+	case "file":
+		return NewAsync(&FileMailer{
+			Dir:  envOr("MAIL_DIR", "maildir"),
+			From: from,
+		})
+	case "console":
+		return NewConsole(redact)
+	default:
+		log.Printf("Unknown MAIL_DRIVER %q, falling back to console\n", driver)
+		return NewConsole(true)
+	}
+}
+
+// envOr returns the environment variable `name`, or `fallback` if it is unset.
+func envOr(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// writeMessage writes the message in RFC 5322 format. If both bodies are
+// set, the email is multipart/alternative with the plaintext part first.
+func writeMessage(w io.Writer, from string, msg Message) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", from)
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+
+	switch {
+	case msg.Text != "" && msg.HTML != "":
+		m.SetBody("text/plain", msg.Text)
+		m.AddAlternative("text/html", msg.HTML)
+	case msg.HTML != "":
+		m.SetBody("text/html", msg.HTML)
+	default:
+		m.SetBody("text/plain", msg.Text)
+	}
+
+	_, err := m.WriteTo(w)
+	return err
+}
@@ -0,0 +1,65 @@
+package mail
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteMessage(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeMessage(&buf, "noreply@example.com", Message{
+		To:      "john.doe@example.com",
+		Subject: "Hello",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := buf.String()
+	for _, want := range []string{
+		"From: noreply@example.com",
+		"To: john.doe@example.com",
+		"Subject: Hello",
+		"multipart/alternative",
+		"plain body",
+		"<p>html body</p>",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("message does not contain %q:\n%s", want, raw)
+		}
+	}
+
+	if strings.Index(raw, "plain body") > strings.Index(raw, "html body") {
+		t.Error("plaintext part should come before the HTML part")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("MAIL_DRIVER", "console")
+	if _, ok := FromEnv().(*ConsoleMailer); !ok {
+		t.Error("expected the console driver")
+	}
+
+	t.Setenv("MAIL_DRIVER", "file")
+	if _, ok := FromEnv().(*Async); !ok {
+		t.Error("expected the file driver to send in the background")
+	}
+
+	t.Setenv("MAIL_DRIVER", "smtp")
+	t.Setenv("SMTP_HOST", "mail.example.com")
+	t.Setenv("SMTP_PORT", "465")
+	t.Setenv("SMTP_TLS", TLSImplicit)
+	async, ok := FromEnv().(*Async)
+	if !ok {
+		t.Fatal("expected the SMTP driver to send in the background")
+	}
+
+	smtp, ok := async.mailer.(*SMTPMailer)
+	if !ok || smtp.Host != "mail.example.com" || smtp.Port != 465 || smtp.TLS != TLSImplicit {
+		t.Errorf("unexpected SMTP configuration %+v", async.mailer)
+	}
+}
@@ -0,0 +1,19 @@
+// Package mailtest helps tests check the emails sent by the backend.
+package mailtest
+
+import (
+	"brickedup/backend/mail"
+	"testing"
+)
+
+// Record makes mail.Default a new mail.Recorder for the duration of the
+// test and returns it.
+func Record(t *testing.T) *mail.Recorder {
+	previous := mail.Default
+	recorder := &mail.Recorder{}
+
+	mail.Default = recorder
+	t.Cleanup(func() { mail.Default = previous })
+
+	return recorder
+}
@@ -0,0 +1,24 @@
+package mailtest
+
+import (
+	"brickedup/backend/mail"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	previous := mail.Default
+
+	t.Run("recording", func(t *testing.T) {
+		recorder := Record(t)
+		if err := mail.Send(mail.Message{To: "john.doe@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+		if sent := recorder.Messages(); len(sent) != 1 || sent[0].To != "john.doe@example.com" {
+			t.Errorf("expected the message to be recorded, got %v", sent)
+		}
+	})
+
+	if mail.Default != previous {
+		t.Error("expected the mailer to be restored after the test")
+	}
+}
@@ -0,0 +1,74 @@
+package mail
+
+import (
+	"net/url"
+	"os"
+	"time"
+)
+
+// PreviewEnabled reports whether templates may be previewed over HTTP.
+// It is set with MAIL_PREVIEW=true and must stay off in production.
+var PreviewEnabled = os.Getenv("MAIL_PREVIEW") == "true"
+
+// Samples holds the sample data used to preview each template.
+var Samples = map[string]any{
+	TemplateVerification: VerificationData{
+		Link: Link("/verify", url.Values{"code": {"sample-code"}}),
+	},
+	TemplateReset: ResetData{
+		Link:    Link("/reset-password", url.Values{"token": {"sample-token"}}),
+		Expires: time.Now().Add(time.Hour),
+	},
+	TemplateMagicLink: MagicLinkData{
+		Link:    Link("/magic-link", url.Values{"token": {"sample-token"}}),
+		Expires: time.Now().Add(10 * time.Minute),
+	},
+	TemplateEmailChange: EmailChangeData{
+		Link:    Link("/confirm-email", url.Values{"token": {"sample-token"}}),
+		Expires: time.Now().Add(24 * time.Hour),
+	},
+	TemplateEmailNotice: EmailNoticeData{
+		Email: "jane.doe@example.org",
+		Link:  Link("/forgot-password", nil),
+	},
+	TemplateInvitation: InvitationData{
+		Inviter: "John Doe",
+		Target:  "Bricked Up Inc.",
+		Role:    "Member",
+		Link:    Link("/accept-invitation", url.Values{"token": {"sample-token"}}),
+		Expires: time.Now().Add(7 * 24 * time.Hour),
+	},
+	TemplateDataExport: DataExportData{
+		Link:    Link("/data-export", url.Values{"token": {"sample-token"}}),
+		Expires: time.Now().Add(24 * time.Hour),
+	},
+	TemplateDeactivated: DeactivatedData{
+		Link:     Link("/reactivate", url.Values{"token": {"sample-token"}}),
+		Deadline: time.Now().AddDate(0, 0, 30),
+	},
+	TemplateReminder: ReminderData{
+		Name: "Jane Smith",
+		Issues: []IssueSummary{
+			{"Fix login bug", "Website", Link("/issue", url.Values{"id": {"1"}}), time.Now().Add(24 * time.Hour)},
+			{"Write release notes", "Website", Link("/issue", url.Values{"id": {"2"}}), time.Now().Add(48 * time.Hour)},
+		},
+	},
+	TemplateDigest: DigestData{
+		Name:   "Jane Smith",
+		Period: "weekly",
+		Issues: []IssueSummary{
+			{"Fix login bug", "Website", Link("/issue", url.Values{"id": {"1"}}), time.Now().Add(24 * time.Hour)},
+			{"Design new logo", "Branding", Link("/issue", url.Values{"id": {"3"}}), time.Time{}},
+		},
+	},
+}
+
+// Preview renders the template `name` with its sample data.
+func Preview(name string) (Message, error) {
+	data, ok := Samples[name]
+	if !ok {
+		return Message{}, ErrUnknownTemplate
+	}
+
+	return Render(name, data)
+}
@@ -0,0 +1,27 @@
+package mail
+
+import "sync"
+
+// Recorder keeps the messages in memory instead of sending them.
+// It is meant for tests, see mailtest.Record.
+type Recorder struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Send records the message.
+func (r *Recorder) Send(msg Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+// Messages returns the messages recorded so far.
+func (r *Recorder) Messages() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Message(nil), r.messages...)
+}
@@ -0,0 +1,124 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+)
+
+// TLS modes of the SMTP driver.
+const (
+	// TLSStartTLS connects in plaintext and requires the STARTTLS upgrade.
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects over TLS right away (usually port 465).
+	TLSImplicit = "tls"
+	// TLSNone never encrypts. Only meant for local mail catchers.
+	TLSNone = "none"
+)
+
+// SMTPMailer sends messages through an SMTP server.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	TLS      string
+}
+
+// SMTPFromEnv returns an SMTPMailer configured by the SMTP_HOST, SMTP_PORT,
+// SMTP_USERNAME, SMTP_PASSWORD and SMTP_TLS environment variables. The
+// credentials fall back to the EMAIL and PASS variables.
+func SMTPFromEnv(from string) *SMTPMailer {
+	port, err := strconv.Atoi(envOr("SMTP_PORT", "587"))
+	if err != nil {
+		port = 587
+	}
+
+	return &SMTPMailer{
+		Host:     envOr("SMTP_HOST", "localhost"),
+		Port:     port,
+		Username: envOr("SMTP_USERNAME", os.Getenv("EMAIL")),
+		Password: envOr("SMTP_PASSWORD", os.Getenv("PASS")),
+		From:     from,
+		TLS:      envOr("SMTP_TLS", TLSStartTLS),
+	}
+}
+
+// Send delivers the message to the SMTP server.
+func (s *SMTPMailer) Send(msg Message) error {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	tlsConfig := &tls.Config{ServerName: s.Host}
+
+	var conn net.Conn
+	var err error
+	if s.TLS == TLSImplicit {
+		conn, err = tls.Dial("tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	switch s.TLS {
+	case TLSImplicit, TLSNone:
+	case TLSStartTLS, "":
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp server does not support STARTTLS")
+		}
+		if err = c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	default:
+		return errors.New("unknown SMTP TLS mode: " + s.TLS)
+	}
+
+	if s.Username != "" {
+		err = c.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host))
+		if err != nil {
+			return err
+		}
+	}
+
+	var body bytes.Buffer
+	err = writeMessage(&body, s.From, msg)
+	if err != nil {
+		return err
+	}
+
+	if err = c.Mail(s.From); err != nil {
+		return err
+	}
+
+	if err = c.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err = w.Write(body.Bytes()); err != nil {
+		return err
+	}
+
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
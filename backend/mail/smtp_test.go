@@ -0,0 +1,84 @@
+package mail
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer accepts one plaintext SMTP session and returns the data
+// of the message it received on the channel.
+func fakeSMTPServer(t *testing.T) (int, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ready")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotLines()
+				received <- strings.Join(data, "\n")
+				tp.PrintfLine("250 ok")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("250 ok")
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port, received
+}
+
+func TestSMTPMailer(t *testing.T) {
+	port, received := fakeSMTPServer(t)
+
+	mailer := &SMTPMailer{
+		Host: "127.0.0.1",
+		Port: port,
+		From: "noreply@example.com",
+		TLS:  TLSNone,
+	}
+
+	err := mailer.Send(Message{To: "john.doe@example.com", Subject: "Hi", Text: "body"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := <-received
+	if !strings.Contains(data, "To: john.doe@example.com") || !strings.Contains(data, "body") {
+		t.Errorf("unexpected message:\n%s", data)
+	}
+}
+
+func TestSMTPMailerRequiresStartTLS(t *testing.T) {
+	port, _ := fakeSMTPServer(t)
+
+	mailer := &SMTPMailer{Host: "127.0.0.1", Port: port, TLS: TLSStartTLS}
+	err := mailer.Send(Message{To: "john.doe@example.com"})
+	if err == nil {
+		t.Error("expected an error from a server without STARTTLS")
+	}
+}
@@ -0,0 +1,237 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Names of the email templates.
+const (
+	TemplateVerification = "verification"
+	TemplateReset        = "reset"
+	TemplateMagicLink    = "magic-link"
+	TemplateEmailChange  = "email-change"
+	TemplateEmailNotice  = "email-change-notice"
+	TemplateInvitation   = "invitation"
+	TemplateDataExport   = "data-export"
+	TemplateDeactivated  = "deactivated"
+	TemplateReminder     = "reminder"
+	TemplateDigest       = "digest"
+)
+
+// Every template <name> consists of <name>.txt, which defines the subject
+// and the plaintext body, and <name>.html, which defines the "content" of
+// layout.html.
+//
+//go:embed templates
+var embedded embed.FS
+
+// ErrUnknownTemplate is returned when no template has the requested name.
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// TemplateDir is a directory, configured with MAIL_TEMPLATES, whose files
+// replace the built-in templates of the same name.
+var TemplateDir = os.Getenv("MAIL_TEMPLATES")
+
+// VerificationData is the data of the verification email.
+type VerificationData struct {
+	Link string
+}
+
+// ResetData is the data of the password reset email.
+type ResetData struct {
+	Link    string
+	Expires time.Time
+}
+
+// MagicLinkData is the data of the email with a passwordless login link.
+type MagicLinkData struct {
+	Link    string
+	Expires time.Time
+}
+
+// EmailChangeData is the data of the email asking to confirm a new address.
+type EmailChangeData struct {
+	Link    string
+	Expires time.Time
+}
+
+// EmailNoticeData is the data of the email telling the old address about a
+// requested change to `Email`. `Link` leads to the password reset.
+type EmailNoticeData struct {
+	Email string
+	Link  string
+}
+
+// InvitationData is the data of an invitation to an organization or project.
+type InvitationData struct {
+	Inviter string
+	Target  string
+	Role    string
+	Link    string
+	Expires time.Time
+}
+
+// DataExportData is the data of the email with the link to download an
+// archive of the user's personal data.
+type DataExportData struct {
+	Link    string
+	Expires time.Time
+}
+
+// DeactivatedData is the data of the email confirming the deactivation of
+// an account. `Link` reactivates it until `Deadline`, when the account is
+// anonymized.
+type DeactivatedData struct {
+	Link     string
+	Deadline time.Time
+}
+
+// IssueSummary is an issue listed in reminders and digests.
+type IssueSummary struct {
+	Title   string
+	Project string
+	Link    string
+	Due     time.Time
+}
+
+// ReminderData is the data of the due-date reminder email.
+type ReminderData struct {
+	Name   string
+	Issues []IssueSummary
+}
+
+// DigestData is the data of the periodic digest email.
+type DigestData struct {
+	Name   string
+	Period string
+	Issues []IssueSummary
+}
+
+// TimeFormat is how the `date` and `datetime` functions of templates show
+// times: in Location, with the layouts Date and DateTime of package time.
+type TimeFormat struct {
+	Location *time.Location
+	Date     string
+	DateTime string
+}
+
+// DefaultTimeFormat shows times in the server's time zone. It is used for
+// recipients without preferences.
+var DefaultTimeFormat = TimeFormat{
+	Location: time.Local,
+	Date:     "January 2, 2006",
+	DateTime: "January 2, 2006 at 15:04 MST",
+}
+
+// funcs returns the functions available in all templates.
+func (f TimeFormat) funcs() map[string]any {
+	return map[string]any{
+		"date": func(t time.Time) string {
+			return t.In(f.Location).Format(f.Date)
+		},
+		"datetime": func(t time.Time) string {
+			return t.In(f.Location).Format(f.DateTime)
+		},
+	}
+}
+
+// readTemplate returns the contents of the template file, preferring the
+// one in TemplateDir.
+func readTemplate(file string) (string, error) {
+	if TemplateDir != "" {
+		content, err := os.ReadFile(filepath.Join(TemplateDir, file))
+		if err == nil {
+			return string(content), nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	content, err := fs.ReadFile(embedded, "templates/"+file)
+	return string(content), err
+}
+
+// Render fills in the template `name` with `data` and returns the resulting
+// message, without a recipient. Times are shown in DefaultTimeFormat.
+func Render(name string, data any) (Message, error) {
+	return RenderFormatted(name, data, DefaultTimeFormat)
+}
+
+// RenderFormatted is like Render, showing times in `format`.
+func RenderFormatted(name string, data any, format TimeFormat) (Message, error) {
+	var msg Message
+
+	text, err := readTemplate(name + ".txt")
+	if errors.Is(err, fs.ErrNotExist) {
+		return msg, ErrUnknownTemplate
+	} else if err != nil {
+		return msg, err
+	}
+
+	textTmpl, err := texttemplate.New(name).Funcs(format.funcs()).Parse(text)
+	if err != nil {
+		return msg, err
+	}
+
+	var subject, body bytes.Buffer
+	if err = textTmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return msg, err
+	}
+	if err = textTmpl.Execute(&body, data); err != nil {
+		return msg, err
+	}
+
+	layout, err := readTemplate("layout.html")
+	if err != nil {
+		return msg, err
+	}
+
+	content, err := readTemplate(name + ".html")
+	if err != nil {
+		return msg, err
+	}
+
+	htmlTmpl, err := htmltemplate.New("layout").Funcs(format.funcs()).Parse(layout)
+	if err == nil {
+		_, err = htmlTmpl.Parse(content)
+	}
+	if err != nil {
+		return msg, err
+	}
+
+	var html bytes.Buffer
+	if err = htmlTmpl.Execute(&html, data); err != nil {
+		return msg, err
+	}
+
+	msg.Subject = strings.TrimSpace(subject.String())
+	msg.Text = body.String()
+	msg.HTML = html.String()
+	return msg, nil
+}
+
+// SendTemplate renders the template `name` with `data` and sends it to `to`.
+func SendTemplate(to string, name string, data any) error {
+	return SendTemplateFormatted(to, name, data, DefaultTimeFormat)
+}
+
+// SendTemplateFormatted is like SendTemplate, showing times in `format`,
+// usually the recipient's.
+func SendTemplateFormatted(to string, name string, data any, format TimeFormat) error {
+	msg, err := RenderFormatted(name, data, format)
+	if err != nil {
+		return err
+	}
+
+	msg.To = to
+	return Send(msg)
+}
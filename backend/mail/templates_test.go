@@ -0,0 +1,95 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRender renders every template with its sample data.
+func TestRender(t *testing.T) {
+	for name := range Samples {
+		msg, err := Preview(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if msg.Subject == "" || msg.Text == "" || msg.HTML == "" {
+			t.Errorf("%s: incomplete message %+v", name, msg)
+		}
+
+		if strings.Contains(msg.Subject, "\n") {
+			t.Errorf("%s: subject spans several lines: %q", name, msg.Subject)
+		}
+
+		if !strings.Contains(msg.HTML, "<html>") {
+			t.Errorf("%s: HTML part does not use the layout", name)
+		}
+	}
+
+	if _, err := Render("unknown", nil); err != ErrUnknownTemplate {
+		t.Errorf("expected ErrUnknownTemplate, got %v", err)
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	msg, err := Render(TemplateReminder, ReminderData{Name: "<script>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(msg.HTML, "<script>") {
+		t.Error("HTML part was not escaped")
+	}
+	if !strings.Contains(msg.Text, "<script>") {
+		t.Error("plaintext part was escaped")
+	}
+}
+
+func TestRenderFormatted(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	format := TimeFormat{Location: berlin, Date: "2006-01-02", DateTime: "2006-01-02 15:04 MST"}
+	expires := time.Date(2025, 3, 1, 23, 30, 0, 0, time.UTC)
+	msg, err := RenderFormatted(TemplateReset, ResetData{Link: "https://example.com", Expires: expires}, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Past midnight in Berlin
+	if !strings.Contains(msg.Text, "2025-03-02 00:30 CET") {
+		t.Errorf("expected the time in the given format, got %q", msg.Text)
+	}
+}
+
+func TestTemplateDir(t *testing.T) {
+	previous := TemplateDir
+	defer func() { TemplateDir = previous }()
+
+	TemplateDir = t.TempDir()
+	err := os.WriteFile(filepath.Join(TemplateDir, "verification.txt"),
+		[]byte(`{{define "subject"}}Custom subject{{end}}Custom {{.Link}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := Render(TemplateVerification, VerificationData{Link: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Subject != "Custom subject" || msg.Text != "Custom https://example.com" {
+		t.Errorf("override was not used: %+v", msg)
+	}
+
+	// Files that are not overridden still come from the built-in templates
+	if !strings.Contains(msg.HTML, "Verify") {
+		t.Errorf("built-in HTML part was not used: %q", msg.HTML)
+	}
+}
@@ -0,0 +1,132 @@
+// Package oidc signs users in with external OpenID Connect identity
+// providers, using the authorization code flow with PKCE.
+//
+// Providers are configured with environment variables. OIDC_PROVIDERS lists
+// their names, separated by commas, and each provider NAME is set up with
+//
+//	OIDC_NAME_ISSUER         issuer URL, used for discovery
+//	OIDC_NAME_CLIENT_ID      client ID registered with the provider
+//	OIDC_NAME_CLIENT_SECRET  client secret, empty for public clients
+//	OIDC_NAME_SCOPES         requested scopes, "openid email profile" by default
+//
+// All providers redirect back to OIDC_REDIRECT_URL, the public URL of the
+// /oidc/callback endpoint.
+package oidc
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownProvider is returned for provider names that are not configured.
+	ErrUnknownProvider = errors.New("unknown identity provider")
+
+	// ErrInvalidState is returned when the state of a callback is unknown,
+	// expired, was already used or does not belong to the browser.
+	ErrInvalidState = errors.New("invalid or expired login state")
+
+	// ErrInvalidIDToken is returned when the ID token of the provider fails
+	// validation.
+	ErrInvalidIDToken = errors.New("invalid ID token")
+)
+
+// Provider is an OpenID Connect identity provider.
+type Provider struct {
+	// Name identifies the provider in URLs and linked identities.
+	Name string
+
+	// Issuer is the issuer URL of the provider. Its discovery document is
+	// fetched from Issuer + "/.well-known/openid-configuration".
+	Issuer string
+
+	ClientID     string
+	ClientSecret string
+
+	// RedirectURL is the URL of the callback endpoint, as registered with
+	// the provider.
+	RedirectURL string
+
+	// Scopes are the requested scopes. "openid" is always included.
+	Scopes []string
+
+	// Client makes the requests to the provider. Nil means a client with a
+	// 10 second timeout.
+	Client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     *keySet
+}
+
+// Identity is the user an identity provider vouches for.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// defaultClient is used for providers without a Client.
+var defaultClient = &http.Client{Timeout: 10 * time.Second}
+
+func (p *Provider) client() *http.Client {
+	if p.Client == nil {
+		return defaultClient
+	}
+	return p.Client
+}
+
+// Providers holds the configured providers by name. Tests can replace it.
+var Providers = ProvidersFromEnv()
+
+// ProvidersFromEnv returns the providers configured by the environment.
+// Providers without an issuer or a client ID are skipped.
+func ProvidersFromEnv() map[string]*Provider {
+	providers := map[string]*Provider{}
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		p := &Provider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{"openid", "email", "profile"}
+		}
+
+		if p.Issuer == "" || p.ClientID == "" {
+			log.Printf("Skipping OIDC provider %q without %sISSUER or %sCLIENT_ID\n", name, prefix, prefix)
+			continue
+		}
+		providers[name] = p
+	}
+
+	return providers
+}
+
+// ProviderNames returns the names of the configured providers, sorted.
+func ProviderNames() []string {
+	names := []string{}
+	for name := range Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
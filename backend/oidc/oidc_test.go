@@ -0,0 +1,223 @@
+package oidc_test
+
+import (
+	"brickedup/backend/oidc"
+	"brickedup/backend/oidc/oidctest"
+	"brickedup/backend/utils"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// useProvider configures the mock provider as the only provider for the test.
+func useProvider(t *testing.T, idp *oidctest.IdP) *oidc.Provider {
+	previous := oidc.Providers
+	t.Cleanup(func() { oidc.Providers = previous })
+
+	p := idp.Provider("corp", "http://localhost:3100/oidc/callback")
+	oidc.Providers = map[string]*oidc.Provider{"corp": p}
+	return p
+}
+
+// login runs a login at the mock provider up to the callback.
+func login(t *testing.T, idp *oidctest.IdP, authURL string) url.Values {
+	t.Helper()
+	callback, err := idp.Login(authURL)
+	if err != nil {
+		t.Fatalf("login at the provider failed: %v", err)
+	}
+	return callback.Query()
+}
+
+func TestLogin(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	idp := oidctest.New(t)
+	useProvider(t, idp)
+	ctx := context.Background()
+
+	authURL, state, err := oidc.Begin(ctx, db, "corp", true)
+	if err != nil {
+		t.Fatalf("Begin returned error: %v", err)
+	}
+
+	params, _ := url.Parse(authURL)
+	if params.Query().Get("state") != state || params.Query().Get("code_challenge_method") != "S256" {
+		t.Errorf("unexpected authorization URL %s", authURL)
+	}
+
+	callback := login(t, idp, authURL)
+	if callback.Get("state") != state {
+		t.Fatalf("expected state %q, got %q", state, callback.Get("state"))
+	}
+
+	identity, remember, err := oidc.Finish(ctx, db, state, callback.Get("code"))
+	if err != nil {
+		t.Fatalf("Finish returned error: %v", err)
+	}
+	want := oidc.Identity{
+		Provider:      "corp",
+		Subject:       idp.User.Subject,
+		Email:         idp.User.Email,
+		EmailVerified: true,
+		Name:          idp.User.Name,
+	}
+	if *identity != want || !remember {
+		t.Errorf("expected %+v (remember), got %+v (%v)", want, *identity, remember)
+	}
+
+	// States are single-use
+	_, _, err = oidc.Finish(ctx, db, state, callback.Get("code"))
+	if err != oidc.ErrInvalidState {
+		t.Errorf("expected ErrInvalidState on reuse, got %v", err)
+	}
+}
+
+func TestFinishInvalid(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	idp := oidctest.New(t)
+	useProvider(t, idp)
+	ctx := context.Background()
+
+	if _, _, err := oidc.Begin(ctx, db, "other", false); err != oidc.ErrUnknownProvider {
+		t.Errorf("expected ErrUnknownProvider, got %v", err)
+	}
+
+	if _, _, err := oidc.Finish(ctx, db, "not-a-state", "code"); err != oidc.ErrInvalidState {
+		t.Errorf("expected ErrInvalidState, got %v", err)
+	}
+
+	// Expired state
+	authURL, state, _ := oidc.Begin(ctx, db, "corp", false)
+	callback := login(t, idp, authURL)
+	db.Exec("UPDATE OIDC_STATE SET expires = ?", time.Now().Add(-time.Second))
+	if _, _, err := oidc.Finish(ctx, db, state, callback.Get("code")); err != oidc.ErrInvalidState {
+		t.Errorf("expected ErrInvalidState for expired state, got %v", err)
+	}
+
+	// A code for another login fails PKCE
+	authURL, _, _ = oidc.Begin(ctx, db, "corp", false)
+	_, state, _ = oidc.Begin(ctx, db, "corp", false)
+	callback = login(t, idp, authURL)
+	if _, _, err := oidc.Finish(ctx, db, state, callback.Get("code")); err == nil {
+		t.Error("expected code of another login to be refused")
+	}
+}
+
+func TestInvalidIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(claims map[string]any)
+	}{
+		{"wrong issuer", func(c map[string]any) { c["iss"] = "https://evil.example.com" }},
+		{"wrong audience", func(c map[string]any) { c["aud"] = "other-client" }},
+		{"foreign azp", func(c map[string]any) { c["aud"] = []string{"brickedup", "other"}; c["azp"] = "other" }},
+		{"expired", func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"issued in the future", func(c map[string]any) { c["iat"] = time.Now().Add(time.Hour).Unix() }},
+		{"nonce mismatch", func(c map[string]any) { c["nonce"] = "replayed" }},
+		{"no subject", func(c map[string]any) { delete(c, "sub") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := utils.SetupTest(t)
+			defer db.Close()
+			idp := oidctest.New(t)
+			useProvider(t, idp)
+			idp.Claims = tt.change
+
+			authURL, state, err := oidc.Begin(context.Background(), db, "corp", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			callback := login(t, idp, authURL)
+
+			_, _, err = oidc.Finish(context.Background(), db, state, callback.Get("code"))
+			if !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Errorf("expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestAudienceList(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	idp := oidctest.New(t)
+	useProvider(t, idp)
+	idp.Claims = func(c map[string]any) {
+		c["aud"] = []string{"other", idp.ClientID}
+		c["azp"] = idp.ClientID
+		c["email_verified"] = "true"
+	}
+
+	authURL, state, _ := oidc.Begin(context.Background(), db, "corp", false)
+	callback := login(t, idp, authURL)
+
+	identity, _, err := oidc.Finish(context.Background(), db, state, callback.Get("code"))
+	if err != nil {
+		t.Fatalf("Finish returned error: %v", err)
+	}
+	if !identity.EmailVerified {
+		t.Error("expected email_verified as a string to be accepted")
+	}
+}
+
+func TestBadSignature(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	idp := oidctest.New(t)
+	useProvider(t, idp)
+	ctx := context.Background()
+
+	// Fetch the key set with a valid login first
+	authURL, state, _ := oidc.Begin(ctx, db, "corp", false)
+	callback := login(t, idp, authURL)
+	if _, _, err := oidc.Finish(ctx, db, state, callback.Get("code")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sign with a key that is not in the key set under the same key ID
+	idp.Key, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+	authURL, state, _ = oidc.Begin(ctx, db, "corp", false)
+	callback = login(t, idp, authURL)
+	if _, _, err := oidc.Finish(ctx, db, state, callback.Get("code")); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("expected ErrInvalidIDToken, got %v", err)
+	}
+}
+
+func TestProvidersFromEnv(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", "corp, google ,broken")
+	t.Setenv("OIDC_REDIRECT_URL", "https://api.example.com/oidc/callback")
+	t.Setenv("OIDC_CORP_ISSUER", "https://sso.example.com")
+	t.Setenv("OIDC_CORP_CLIENT_ID", "brickedup")
+	t.Setenv("OIDC_CORP_CLIENT_SECRET", "secret")
+	t.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "id.apps.googleusercontent.com")
+	t.Setenv("OIDC_GOOGLE_SCOPES", "openid email")
+	t.Setenv("OIDC_BROKEN_ISSUER", "https://broken.example.com")
+
+	providers := oidc.ProvidersFromEnv()
+	if len(providers) != 2 {
+		t.Fatalf("expected 2 providers, got %v", providers)
+	}
+
+	corp := providers["corp"]
+	if corp == nil || corp.Issuer != "https://sso.example.com" || corp.ClientSecret != "secret" ||
+		corp.RedirectURL != "https://api.example.com/oidc/callback" || len(corp.Scopes) != 3 {
+		t.Errorf("unexpected corp provider %+v", corp)
+	}
+
+	google := providers["google"]
+	if google == nil || google.ClientSecret != "" || len(google.Scopes) != 2 {
+		t.Errorf("unexpected google provider %+v", google)
+	}
+}
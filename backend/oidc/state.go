@@ -0,0 +1,93 @@
+package oidc
+
+import (
+	"brickedup/backend/utils"
+	"context"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// stateLifetime is how long the user has to log in at the provider.
+const stateLifetime = 10 * time.Minute
+
+// Begin starts a login with the named provider. It returns the URL to send
+// the browser to and the state of the login, which has to be bound to the
+// browser, e.g. in a cookie, and passed to Finish along with the code.
+// The state, nonce and PKCE verifier are kept in the database until then.
+func Begin(ctx context.Context, db *sql.DB, provider string, remember bool) (string, string, error) {
+	p, ok := Providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	// Remove logins that were never finished
+	_, err := db.Exec(`DELETE FROM OIDC_STATE WHERE expires <= ?`, time.Now())
+	if err != nil {
+		return "", "", err
+	}
+
+	var secrets [3]string
+	for i := range secrets {
+		secrets[i], err = utils.GenerateToken()
+		if err != nil {
+			return "", "", err
+		}
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	authURL, err := p.AuthURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO OIDC_STATE (state, provider, nonce, verifier, remember, expires)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		utils.HashToken(state), provider, nonce, verifier, remember,
+		time.Now().Add(stateLifetime))
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+// Finish completes the login the state belongs to by redeeming the code
+// the provider redirected back with. Each state can only be used once.
+// It returns the identity of the user and whether they asked to be
+// remembered.
+func Finish(ctx context.Context, db *sql.DB, state string, code string) (*Identity, bool, error) {
+	var id int
+	var provider, nonce, verifier string
+	var remember bool
+	err := db.QueryRow(
+		`SELECT id, provider, nonce, verifier, remember
+		FROM OIDC_STATE
+		WHERE state = ? AND expires > ?`,
+		utils.HashToken(state), time.Now()).Scan(&id, &provider, &nonce, &verifier, &remember)
+
+	if err == sql.ErrNoRows {
+		return nil, false, ErrInvalidState
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	res, err := db.Exec(`DELETE FROM OIDC_STATE WHERE id = ?`, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return nil, false, ErrInvalidState
+	}
+
+	p, ok := Providers[provider]
+	if !ok {
+		return nil, false, ErrUnknownProvider
+	}
+
+	identity, err := p.Exchange(ctx, code, verifier, nonce)
+	return identity, remember, err
+}
@@ -6,20 +6,11 @@ import (
 )
 
 // AddOrgMember adds a user to a organization. The user is given a role within the organization.
-func AddOrgMember(db *sql.DB, sessionid int64, userid int, roleid int, orgid int) error {
-	var manager int
+func AddOrgMember(db *sql.DB, manager int, userid int, roleid int, orgid int) error {
 	var has_exec bool
 	var user_exists bool
 
-	err := db.QueryRow(
-		`SELECT userid FROM SESSION WHERE id = ?`,
-		sessionid).Scan(&manager)
-
-	if err != nil {
-		return err
-	}
-
-	err = db.QueryRow(`
+	err := db.QueryRow(`
 		SELECT EXISTS(
 			SELECT 1 FROM ORG_MEMBER_ROLE omr
 			JOIN ORG_ROLE orgr ON omr.roleid = orgr.id
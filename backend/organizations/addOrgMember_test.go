@@ -3,30 +3,15 @@ package organizations
 import (
 	"brickedup/backend/utils"
 	"testing"
-	"time"
 )
 
 func TestAddProjMember(t *testing.T) {
 	db := utils.SetupTest(t)
 	defer db.Close()
 
-	res, err := db.Exec(`
-		INSERT INTO SESSION(userid, expires)
-		VALUES(1, ?)
-	`, time.Now().Add(24 * time.Hour))
-
-	if err != nil {
-		t.Fatal(err.Error())
-	}
-
-	session, err := res.LastInsertId()
-	if err != nil {
-		t.Fatal(err.Error())
-	}
-
 	tests := []struct {
 		name string // description of this test case
-		sessionid int64
+		manager   int
 		userid    int
 		roleid    int
 		orgid int
@@ -34,7 +19,7 @@ func TestAddProjMember(t *testing.T) {
 	}{
 		{
 			name: "Successful",
-			sessionid: session,
+			manager: 1,
 			userid: 1,
 			roleid: 1,
 			orgid: 1,
@@ -42,7 +27,7 @@ func TestAddProjMember(t *testing.T) {
 		},
 		{
 			name: "Inexistant Organization",
-			sessionid: session,
+			manager: 1,
 			userid: 1,
 			roleid: 1,
 			orgid: 1000,
@@ -50,7 +35,7 @@ func TestAddProjMember(t *testing.T) {
 		},
 		{
 			name: "Inexistant Role",
-			sessionid: session,
+			manager: 1,
 			userid: 1,
 			roleid: 1000,
 			orgid: 1,
@@ -58,7 +43,7 @@ func TestAddProjMember(t *testing.T) {
 		},
 		{
 			name: "Inexistant User",
-			sessionid: session,
+			manager: 1,
 			userid: 1000,
 			roleid: 1,
 			orgid: 1,
@@ -66,7 +51,7 @@ func TestAddProjMember(t *testing.T) {
 		},
 		{
 			name: "Inexistant Organization Manager",
-			sessionid: 1000,
+			manager: 1000,
 			userid: 1,
 			roleid: 1,
 			orgid: 1,
@@ -75,7 +60,7 @@ func TestAddProjMember(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotErr := AddOrgMember(db, tt.sessionid, tt.userid, tt.roleid, tt.orgid)
+			gotErr := AddOrgMember(db, tt.manager, tt.userid, tt.roleid, tt.orgid)
 			if gotErr != nil {
 				if !tt.wantErr {
 					t.Errorf("AddOrgMember() failed: %v", gotErr)
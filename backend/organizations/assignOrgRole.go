@@ -7,16 +7,16 @@ import (
 )
 
 // AssignOrgRole promotes User B to a role within an organization.
-// User A (acting user) is referenced by sessionID.
+// User A (acting user) is referenced by sessionUserID.
 // User B (target user) is referenced by userID.
-func AssignOrgRole(db *sql.DB, sessionID, userID, orgID, newRoleID int) error {
+func AssignOrgRole(db *sql.DB, sessionUserID, userID, orgID, newRoleID int) error {
 	// Get User A's member ID and role in the organization
 	var sessionMemberID, sessionRoleID int
 	err := db.QueryRow(
 		`SELECT id 
 		FROM ORG_MEMBER 
 		WHERE userid = ? AND orgid = ?`, 
-		sessionID, orgID).Scan(&sessionMemberID)
+		sessionUserID, orgID).Scan(&sessionMemberID)
 
 	if err != nil {
 		return err
@@ -90,7 +90,7 @@ func TestAssignOrgRole_NoError(t *testing.T) {
 	}
 
 	// Call the function under test with correct arguments
-	// assignOrgRole(db, sessionID, userID, orgID, newRoleID)
+	// assignOrgRole(db, sessionUserID, userID, orgID, newRoleID)
 	err = AssignOrgRole(db, adminUserID, targetUserID, orgID, roleID)
 
 	// Assert no error occurred
@@ -1,27 +1,21 @@
 package organizations
 
 import (
-	"brickedup/backend/utils"
+	"brickedup/backend/validate"
 	"database/sql"
 	"errors"
 
 	_ "modernc.org/sqlite"
 )
 
-// CreateOrganization creates a new organization and assigns the user (from the session) to it as an admin.
-// It takes sessionID (int) and orgName (string) as parameters.
-func CreateOrganization(db *sql.DB, sessionID int, orgName string) (int, error) {
-	// Check if orgName is provided
-	if orgName == "" {
-		return 0, errors.New("missing orgName")
-	}
-
-	// In the function
-	sanitizedOrgName := utils.SanitizeText(orgName, utils.TEXT)
-
-	// If sanitization removed all characters, reject the input
-	if sanitizedOrgName == "" {
-		return 0, errors.New("organization name contains only invalid characters")
+// CreateOrganization creates a new organization and assigns the user to it as an admin.
+// It takes the user ID (int) and orgName (string) as parameters.
+func CreateOrganization(db *sql.DB, userID int, orgName string) (int, error) {
+	// Check if orgName is valid
+	v := validate.New()
+	v.Line("name", orgName, validate.MaxNameLength)
+	if err := v.Err(); err != nil {
+		return 0, err
 	}
 
 	// Begin transaction to ensure data consistency
@@ -29,25 +23,11 @@ func CreateOrganization(db *sql.DB, sessionID int, orgName string) (int, error)
 	if err != nil {
 		return 0, err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Get the user ID from the session
-	var userID int
-	err = tx.QueryRow("SELECT userid FROM SESSION WHERE id = ?", sessionID).Scan(&userID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, errors.New("no session exists for the provided sessionID")
-		}
-		return 0, err
-	}
+	defer tx.Rollback()
 
 	// Check if the organization name already exists
 	var existingOrgID int
-	err = tx.QueryRow("SELECT id FROM ORGANIZATION WHERE name = ?", sanitizedOrgName).Scan(&existingOrgID)
+	err = tx.QueryRow("SELECT id FROM ORGANIZATION WHERE name = ?", orgName).Scan(&existingOrgID)
 	if err == nil {
 		return 0, errors.New("organization name already exists")
 	}
@@ -56,7 +36,7 @@ func CreateOrganization(db *sql.DB, sessionID int, orgName string) (int, error)
 	}
 
 	// Insert new organization and get its ID
-	result, err := tx.Exec("INSERT INTO ORGANIZATION(name) VALUES(?)", sanitizedOrgName)
+	result, err := tx.Exec("INSERT INTO ORGANIZATION(name) VALUES(?)", orgName)
 	if err != nil {
 		return 0, err
 	}
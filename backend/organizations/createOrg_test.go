@@ -11,18 +11,13 @@ func TestCreateOrganization(t *testing.T) {
 	db := utils.SetupTest(t)
 	defer db.Close()
 
-	// Get an existing session ID from the database
-	var sessionID int
-	err := db.QueryRow("SELECT id FROM SESSION LIMIT 1").Scan(&sessionID)
-	if err != nil {
-		t.Fatalf("failed to get session ID: %v", err)
-	}
+	// An existing user from populate.sql
+	userID := 1
 
 	// Test valid organization creation
 	orgName := "Test Organization Name"
-	expectedSanitizedName := utils.SanitizeText(orgName, utils.TEXT)
 
-	orgID, err := CreateOrganization(db, sessionID, orgName)
+	orgID, err := CreateOrganization(db, userID, orgName)
 	if err != nil {
 		t.Errorf("CreateOrganization returned error: %v", err)
 	}
@@ -31,33 +26,31 @@ func TestCreateOrganization(t *testing.T) {
 		t.Errorf("expected valid organization ID, got %d", orgID)
 	}
 
-	// Verify organization was created with sanitized name
+	// Verify organization was stored verbatim
 	var retrievedName string
 	err = db.QueryRow("SELECT name FROM ORGANIZATION WHERE id = ?", orgID).Scan(&retrievedName)
 	if err != nil {
 		t.Errorf("failed to retrieve organization: %v", err)
 	}
-	if retrievedName != expectedSanitizedName {
-		t.Errorf("expected organization name %s, got %s", expectedSanitizedName, retrievedName)
+	if retrievedName != orgName {
+		t.Errorf("expected organization name %s, got %s", orgName, retrievedName)
 	}
 
 	// Test duplicate organization name
-	_, err = CreateOrganization(db, sessionID, orgName)
+	_, err = CreateOrganization(db, userID, orgName)
 	if err == nil {
 		t.Errorf("expected error for duplicate organization name, got nil")
 	}
 
-	// Test with potentially dangerous input
-	maliciousName := "Dangerous'; DROP TABLE ORGANIZATION; --"
-	sanitizedMalicious := utils.SanitizeText(maliciousName, utils.TEXT)
-
-	if sanitizedMalicious == maliciousName {
-		t.Errorf("sanitization failed to clean malicious input")
+	// Digits and punctuation are kept as typed
+	_, err = CreateOrganization(db, userID, "Team 42 (R&D)")
+	if err != nil {
+		t.Errorf("CreateOrganization returned error: %v", err)
 	}
 
-	// Test with empty string after sanitization
-	_, err = CreateOrganization(db, sessionID, "12345")
-	if err == nil && utils.SanitizeText("12345", utils.TEXT) == "" {
-		t.Errorf("should reject input that becomes empty after sanitization")
+	// Blank names are rejected
+	_, err = CreateOrganization(db, userID, "   ")
+	if err == nil {
+		t.Errorf("expected error for blank organization name, got nil")
 	}
 }
@@ -8,36 +8,18 @@ import (
 )
 
 // DeleteOrganization deletes an organization if the user has the necessary permissions.
-func DeleteOrganization(db *sql.DB, sessionID int, orgID int) error {
+func DeleteOrganization(db *sql.DB, userID int, orgID int) error {
 	// Input validation
 	if db == nil {
 		return errors.New("database connection is nil")
 	}
-	if sessionID <= 0 {
-		return errors.New("invalid session ID")
-	}
 	if orgID <= 0 {
 		return errors.New("invalid organization ID")
 	}
 
-	// Get the user ID from the session
-	var userID int
-	var expires string
-	err := db.QueryRow(
-		`SELECT userid, expires 
-		FROM SESSION WHERE id = ?`,
-		sessionID).Scan(&userID, &expires)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return errors.New("no session exists for the provided sessionID")
-		}
-		return err
-	}
-
 	// Check if the organization exists
 	var existingOrgID int
-	err = db.QueryRow(
+	err := db.QueryRow(
 		`SELECT id 
 		FROM ORGANIZATION WHERE id = ?`,
 		orgID).Scan(&existingOrgID)
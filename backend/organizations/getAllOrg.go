@@ -7,7 +7,7 @@ func GetAllOrg(db *sql.DB) ([]int, error) {
 	var orgids []int
 
 	res, err := db.Query(`
-		SELECT id FROM ORGANIZATION
+		SELECT id FROM ORGANIZATION ORDER BY id
 		`)
 
 	if err != nil {
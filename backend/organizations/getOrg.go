@@ -102,10 +102,10 @@ func getOrgRoles(db *sql.DB, org *utils.Organization) error {
 
 // GetOrg returns an organization entry.
 func GetOrg(db *sql.DB, orgid int) (*utils.Organization, error) {
-	row := db.QueryRow(`SELECT id, name FROM organization where id = ?`, orgid)
+	row := db.QueryRow(`SELECT id, name, require_2fa FROM organization where id = ?`, orgid)
 
 	org := &utils.Organization{}
-	if err := row.Scan(&org.ID, &org.Name); err != nil {
+	if err := row.Scan(&org.ID, &org.Name, &org.Require2FA); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("Organization not found")
 		}
@@ -7,32 +7,19 @@ import (
 
 // RemoveOrgMember removes a user from an organization.
 // The user is given a role within the organization.
-func RemoveOrgMember(db *sql.DB, sessionid int64, memberid int) error {
-	var manager int
+func RemoveOrgMember(db *sql.DB, manager int, memberid int) error {
 	var has_exec bool
 
-	err := db.QueryRow(
-		`SELECT userid FROM SESSION WHERE id = ?`,
-		sessionid).Scan(&manager)
-
-	if err != nil {
-		return err
-	}
-
-	err = db.QueryRow(`
+	err := db.QueryRow(`
 		SELECT EXISTS(
 			SELECT * FROM ORG_MEMBER_ROLE omr
 			JOIN ORG_ROLE orgr ON omr.roleid = orgr.id
 			WHERE omr.memberid = (
 				SELECT id
 				FROM ORG_MEMBER
-				WHERE userid = (
-					SELECT userid
-					FROM SESSION
-					WHERE id = ?
-				)
+				WHERE userid = ?
 			) AND orgr.can_exec = 1
-		)`, sessionid).Scan(&has_exec)
+		)`, manager).Scan(&has_exec)
 
 	if err != nil {
 		return err
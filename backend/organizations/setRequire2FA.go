@@ -0,0 +1,39 @@
+package organizations
+
+import (
+	"database/sql"
+	"errors"
+
+	_ "modernc.org/sqlite"
+)
+
+// SetRequire2FA sets whether the members of an organization must use
+// two-factor authentication. Only members with executive privileges can
+// change it.
+func SetRequire2FA(db *sql.DB, userID int, orgID int, required bool) error {
+	var canExec bool
+	err := db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM ORG_MEMBER om
+			JOIN ORG_MEMBER_ROLE omr ON om.id = omr.memberid
+			JOIN ORG_ROLE orgr ON omr.roleid = orgr.id
+			WHERE om.userid = ? AND om.orgid = ? AND orgr.can_exec = 1
+		)
+	`, userID, orgID).Scan(&canExec)
+
+	if err != nil {
+		return err
+	}
+
+	if !canExec {
+		return errors.New("user does not have executive privileges in this organization")
+	}
+
+	_, err = db.Exec(`
+		UPDATE ORGANIZATION
+		SET require_2fa = ?
+		WHERE id = ?
+	`, required, orgID)
+
+	return err
+}
@@ -0,0 +1,33 @@
+package organizations
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSetRequire2FA(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// John Doe (1) is an admin of organization 1, Jane Smith (2) a developer
+	err := SetRequire2FA(db, 2, 1, true)
+	if err == nil {
+		t.Error("Expected an error for a member without exec privileges")
+	}
+
+	err = SetRequire2FA(db, 1, 1, true)
+	if err != nil {
+		t.Fatalf("Expected SetRequire2FA to succeed, got error: %v", err)
+	}
+
+	org, err := GetOrg(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !org.Require2FA {
+		t.Error("Expected organization 1 to require 2FA")
+	}
+}
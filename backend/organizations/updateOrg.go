@@ -3,29 +3,18 @@ package organizations
 import (
 	"database/sql"
 	"errors"
-	"time"
 
 	"brickedup/backend/utils"
+	"brickedup/backend/validate"
 
 	_ "modernc.org/sqlite"
 )
 
 // UpdateOrg updates an organization if the user has executive privileges.
-func UpdateOrg(db *sql.DB, sessionID, orgID int, org utils.Organization) error {
-	// First, validate the session and check execution privileges
-	var userID int
+func UpdateOrg(db *sql.DB, userID int, orgID int, org utils.Organization) error {
 	var org_exists bool
 
-	var sessionExpires time.Time
 	err := db.QueryRow(`
-		SELECT userid, expires FROM SESSION 
-		WHERE id = ? AND expires > ?
-	`, sessionID, time.Now()).Scan(&userID, &sessionExpires)
-	if err != nil {
-		return err
-	}
-
-	err = db.QueryRow(`
 		SELECT EXISTS (
 			SELECT * FROM ORGANIZATION
 			WHERE id = ?
@@ -57,10 +46,16 @@ func UpdateOrg(db *sql.DB, sessionID, orgID int, org utils.Organization) error {
 	// 	return sql.ErrNoRows // Indicates no matching privileges found
 	// }
 
-	// Sanitize org fields
+	// Validate org fields
+	v := validate.New()
+	v.Line("name", org.Name, validate.MaxNameLength)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
 	sanitizedOrg := utils.Organization{
 		ID:       orgID,
-		Name:     utils.SanitizeText(org.Name, utils.TEXT),
+		Name:     org.Name,
 	}
 
 	// Update the org in the database
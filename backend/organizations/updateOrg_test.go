@@ -3,7 +3,6 @@ package organizations
 import (
 	"brickedup/backend/utils"
 	"testing"
-	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -27,8 +26,7 @@ func TestUpdateOrg(t *testing.T) {
 		t.Fatalf("Failed to get original organization data: %v", err)
 	}
 
-	// Create a session with a valid user who has exec privileges
-	// First find a user with exec privileges for this project
+	// Find a user with exec privileges for this organization
 	var userID int
 	err = db.QueryRow(`
 		SELECT om.userid FROM ORG_MEMBER om
@@ -40,18 +38,6 @@ func TestUpdateOrg(t *testing.T) {
 		t.Fatalf("Could not find a user with exec privileges: %v", err)
 	}
 
-	// Create a session for this user
-	result, err := db.Exec("INSERT INTO SESSION (userid, expires) VALUES (?, ?)",
-		userID, time.Now().Add(1*time.Hour))
-	if err != nil {
-		t.Fatalf("Failed to create test session: %v", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		t.Fatalf("Failed to get last insert ID: %v", err)
-	}
-	sessionID := int(id)
-
 	// Create updated org data
 	updatedOrg := utils.Organization{
 		ID:       originalOrg.ID,
@@ -59,7 +45,7 @@ func TestUpdateOrg(t *testing.T) {
 	}
 
 	// Test successful update
-	err = UpdateOrg(db, sessionID, orgId, updatedOrg)
+	err = UpdateOrg(db, userID, orgId, updatedOrg)
 	if err != nil {
 		t.Errorf("Expected organization update to succeed, got error: %v", err)
 	}
@@ -79,33 +65,8 @@ func TestUpdateOrg(t *testing.T) {
 
 	// Test with non-existent org ID
 	nonExistentOrgID := 99999
-	err = UpdateOrg(db, sessionID, nonExistentOrgID, updatedOrg)
+	err = UpdateOrg(db, userID, nonExistentOrgID, updatedOrg)
 	if err == nil {
 		t.Errorf("Expected error for non-existent org ID, but got nil")
 	}
-
-	// Test with invalid session ID
-	invalidSessionID := 99999
-	err = UpdateOrg(db, invalidSessionID, orgId, updatedOrg)
-	if err == nil {
-		t.Errorf("Expected error for invalid session ID, but got nil")
-	}
-
-	// Test with expired session
-	// Create an expired session for testing
-	expiredResult, err := db.Exec("INSERT INTO SESSION (userid, expires) VALUES (?, ?)",
-		userID, time.Now().Add(-1*time.Hour))
-	if err != nil {
-		t.Fatalf("Failed to create expired test session: %v", err)
-	}
-	expiredID, err := expiredResult.LastInsertId()
-	if err != nil {
-		t.Fatalf("Failed to get last insert ID: %v", err)
-	}
-	expiredSessionID := int(expiredID)
-
-	err = UpdateOrg(db, expiredSessionID, orgId, updatedOrg)
-	if err == nil {
-		t.Errorf("Expected error for expired session, but got nil")
-	}
 }
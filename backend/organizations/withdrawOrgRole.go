@@ -7,17 +7,10 @@ import (
 )
 
 // WithdrawOrgRole withdraws a role from a user within an organization.
-func WithdrawOrgRole(db *sql.DB, sessionid int, orgMemberRoleId int) error {
-	// Validate user A's session and get their user ID
-	var userAID int
-	err := db.QueryRow("SELECT userid FROM SESSION WHERE id = ?", sessionid).Scan(&userAID)
-	if err != nil {
-		return err
-	}
-
+func WithdrawOrgRole(db *sql.DB, userAID int, orgMemberRoleId int) error {
 	// Get the organization ID for the role being removed
 	var orgID int
-	err = db.QueryRow(`
+	err := db.QueryRow(`
         SELECT o.id 
         FROM ORG_MEMBER_ROLE omr
         JOIN ORG_ROLE r ON omr.roleid = r.id
@@ -24,8 +24,8 @@ func TestWithdrawOrgRole(t *testing.T) {
 		t.Fatalf("Could not find role to remove: %v", err)
 	}
 
-	// Valid session ID for user A
-	validSessionID := 1
+	// User A is an admin of the organization
+	userAID := 1
 
 	// Verify the role exists before removal
 	var countBefore int
@@ -38,7 +38,7 @@ func TestWithdrawOrgRole(t *testing.T) {
 	}
 
 	// Try to remove the role
-	err = WithdrawOrgRole(db, validSessionID, roleIDToRemove)
+	err = WithdrawOrgRole(db, userAID, roleIDToRemove)
 	if err != nil {
 		t.Errorf("Expected role removal to succeed, got error: %v", err)
 	}
@@ -53,10 +53,9 @@ func TestWithdrawOrgRole(t *testing.T) {
 		t.Errorf("Role should be removed, but count is %d", countAfter)
 	}
 
-	// Test with an invalid session ID
-	invalidSessionID := 9999
-	err = WithdrawOrgRole(db, invalidSessionID, roleIDToRemove)
+	// Test with a user outside the organization
+	err = WithdrawOrgRole(db, 9999, roleIDToRemove)
 	if err == nil {
-		t.Errorf("Expected error for invalid session ID, but got nil")
+		t.Errorf("Expected error for a user without permission, but got nil")
 	}
 }
@@ -0,0 +1,107 @@
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// argon2idPrefix starts every argon2id hash.
+const argon2idPrefix = "$argon2id$"
+
+// Lengths of the salt and the derived key, in bytes.
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// Argon2id hashes passwords with argon2id. Hashes are encoded in the PHC
+// string format used by the reference implementation:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+type Argon2id struct {
+	// Memory is the memory used, in KiB.
+	Memory uint32
+
+	// Time is the number of passes over the memory.
+	Time uint32
+
+	// Threads is the degree of parallelism.
+	Threads uint8
+}
+
+// argon2Hash is a decoded argon2id hash.
+type argon2Hash struct {
+	params Argon2id
+	salt   []byte
+	key    []byte
+}
+
+// parseArgon2id decodes an argon2id hash.
+func parseArgon2id(hash string) (*argon2Hash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrUnknownHash
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return nil, ErrUnknownHash
+	}
+
+	var h argon2Hash
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.params.Memory, &h.params.Time, &h.params.Threads)
+	if err != nil || h.params.Time == 0 || h.params.Threads == 0 {
+		return nil, ErrUnknownHash
+	}
+
+	h.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, ErrUnknownHash
+	}
+	h.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(h.key) == 0 {
+		return nil, ErrUnknownHash
+	}
+
+	return &h, nil
+}
+
+// Hash returns the argon2id hash of the password with a random salt.
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, argon2KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, a.Memory, a.Time, a.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether the password matches the argon2id hash.
+func (a *Argon2id) Verify(hash string, password string) (bool, error) {
+	h, err := parseArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), h.salt, h.params.Time, h.params.Memory, h.params.Threads, uint32(len(h.key)))
+
+	return subtle.ConstantTimeCompare(key, h.key) == 1, nil
+}
+
+// NeedsRehash reports whether the hash is not an argon2id hash with these
+// parameters.
+func (a *Argon2id) NeedsRehash(hash string) bool {
+	h, err := parseArgon2id(hash)
+	return err != nil || h.params != *a || len(h.salt) != argon2SaltLength || len(h.key) != argon2KeyLength
+}
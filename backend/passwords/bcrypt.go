@@ -0,0 +1,57 @@
+package passwords
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes passwords with bcrypt. Only the first 72 bytes of a
+// password are used by bcrypt, which is why longer passwords are rejected
+// by Check.
+type Bcrypt struct {
+	// Cost is the log2 of the number of rounds. Zero means bcrypt.DefaultCost.
+	Cost int
+}
+
+// isBcrypt reports whether the hash looks like a bcrypt hash ($2a$, $2b$
+// or $2y$).
+func isBcrypt(hash string) bool {
+	return len(hash) == 60 &&
+		(strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$"))
+}
+
+func (b *Bcrypt) cost() int {
+	if b.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return b.Cost
+}
+
+// Hash returns the bcrypt hash of the password.
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost())
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify reports whether the password matches the bcrypt hash.
+func (b *Bcrypt) Verify(hash string, password string) (bool, error) {
+	if !isBcrypt(hash) {
+		return false, ErrUnknownHash
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NeedsRehash reports whether the hash is not a bcrypt hash of this cost.
+func (b *Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return !isBcrypt(hash) || err != nil || cost != b.cost()
+}
@@ -0,0 +1,79 @@
+// Package passwords hashes passwords and decides which passwords are
+// acceptable.
+//
+// New hashes are made by Default, which is configured with the
+// PASSWORD_HASH environment variable: "argon2id" (the default) or "bcrypt".
+// Verify accepts the hashes of either algorithm, whatever their parameters,
+// and NeedsRehash tells when a stored hash should be replaced by one made
+// with the current configuration.
+package passwords
+
+import (
+	"brickedup/backend/utils"
+	"errors"
+	"log"
+	"os"
+	"strings"
+)
+
+// ErrUnknownHash is returned for stored hashes of no supported algorithm.
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher hashes passwords with one algorithm.
+type Hasher interface {
+	// Hash returns the encoded hash of the password, including a fresh
+	// salt and the parameters it was made with.
+	Hash(password string) (string, error)
+
+	// Verify reports whether the password matches a hash of this
+	// algorithm, using the parameters encoded in the hash.
+	Verify(hash string, password string) (bool, error)
+
+	// NeedsRehash reports whether the hash was made with a different
+	// algorithm or different parameters than this hasher uses.
+	NeedsRehash(hash string) bool
+}
+
+// Default makes the hashes stored for new passwords.
+var Default Hasher = FromEnv()
+
+// FromEnv returns the hasher configured by the environment.
+func FromEnv() Hasher {
+	switch algorithm := os.Getenv("PASSWORD_HASH"); algorithm {
+	case "", "argon2id":
+		return &Argon2id{
+			Memory:  uint32(utils.IntFromEnv("ARGON2_MEMORY", 19*1024)),
+			Time:    uint32(max(utils.IntFromEnv("ARGON2_TIME", 2), 1)),
+			Threads: uint8(min(max(utils.IntFromEnv("ARGON2_THREADS", 1), 1), 255)),
+		}
+	case "bcrypt":
+		return &Bcrypt{Cost: utils.IntFromEnv("BCRYPT_COST", 12)}
+	default:
+		log.Printf("Unknown PASSWORD_HASH %q, falling back to argon2id\n", algorithm)
+		return &Argon2id{Memory: 19 * 1024, Time: 2, Threads: 1}
+	}
+}
+
+// Hash hashes the password with the Default hasher.
+func Hash(password string) (string, error) {
+	return Default.Hash(password)
+}
+
+// Verify reports whether the password matches the hash, whichever
+// supported algorithm made it.
+func Verify(hash string, password string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return (&Argon2id{}).Verify(hash, password)
+	case isBcrypt(hash):
+		return (&Bcrypt{}).Verify(hash, password)
+	default:
+		return false, ErrUnknownHash
+	}
+}
+
+// NeedsRehash reports whether the hash should be replaced by one made by
+// the Default hasher.
+func NeedsRehash(hash string) bool {
+	return Default.NeedsRehash(hash)
+}
@@ -0,0 +1,87 @@
+package passwords
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashers(t *testing.T) {
+	hashers := []Hasher{
+		&Argon2id{Memory: 1024, Time: 1, Threads: 1},
+		&Bcrypt{Cost: 4},
+	}
+	for _, h := range hashers {
+		hash, err := h.Hash("pa$$-word 42")
+		if err != nil {
+			t.Fatalf("%T: Hash returned error: %v", h, err)
+		}
+
+		for password, want := range map[string]bool{"pa$$-word 42": true, "pa$$word 42": false, "": false} {
+			ok, err := Verify(hash, password)
+			if err != nil || ok != want {
+				t.Errorf("%T: Verify(%q) = %v, %v; want %v", h, password, ok, err, want)
+			}
+		}
+
+		if h.NeedsRehash(hash) {
+			t.Errorf("%T: fresh hash should not need a rehash", h)
+		}
+
+		other, _ := h.Hash("pa$$-word 42")
+		if other == hash {
+			t.Errorf("%T: expected a random salt", h)
+		}
+	}
+}
+
+func TestArgon2idFormat(t *testing.T) {
+	hash, err := (&Argon2id{Memory: 1024, Time: 1, Threads: 2}).Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=2$") {
+		t.Errorf("unexpected hash %q", hash)
+	}
+
+	// Hash of "password" from the reference implementation
+	ok, err := Verify("$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc", "password")
+	if err != nil || !ok {
+		t.Errorf("expected reference hash to match, got %v, %v", ok, err)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon := &Argon2id{Memory: 1024, Time: 1, Threads: 1}
+	bcrypt := &Bcrypt{Cost: 4}
+
+	argonHash, _ := argon.Hash("password")
+	bcryptHash, _ := bcrypt.Hash("password")
+
+	tests := []struct {
+		name   string
+		hasher Hasher
+		hash   string
+		want   bool
+	}{
+		{"same argon2id parameters", argon, argonHash, false},
+		{"more argon2id memory", &Argon2id{Memory: 2048, Time: 1, Threads: 1}, argonHash, true},
+		{"more argon2id passes", &Argon2id{Memory: 1024, Time: 2, Threads: 1}, argonHash, true},
+		{"bcrypt to argon2id", argon, bcryptHash, true},
+		{"same bcrypt cost", bcrypt, bcryptHash, false},
+		{"higher bcrypt cost", &Bcrypt{Cost: 5}, bcryptHash, true},
+		{"argon2id to bcrypt", bcrypt, argonHash, true},
+	}
+	for _, tt := range tests {
+		if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestVerifyUnknownHash(t *testing.T) {
+	for _, hash := range []string{"", "plaintext", "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=1024$c2FsdA$a2V5"} {
+		if _, err := Verify(hash, "password"); err != ErrUnknownHash {
+			t.Errorf("Verify(%q): expected ErrUnknownHash, got %v", hash, err)
+		}
+	}
+}
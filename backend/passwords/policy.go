@@ -0,0 +1,108 @@
+package passwords
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	_ "embed"
+	"log"
+	"os"
+	"strings"
+)
+
+// commonPasswords is the bundled blocklist, one password per line.
+//
+//go:embed common.txt
+var commonPasswords string
+
+// Error codes of the FieldErrors reported by Check, in addition to those of
+// package validate.
+const (
+	CodeCommon        = "common"
+	CodeContainsEmail = "contains_email"
+)
+
+// Policy decides which new passwords are accepted.
+type Policy struct {
+	// MinLength is the minimum number of characters.
+	MinLength int
+
+	// Blocklist holds rejected passwords, lowercased.
+	Blocklist map[string]bool
+}
+
+// DefaultPolicy is the policy used by Check.
+var DefaultPolicy = PolicyFromEnv()
+
+// PolicyFromEnv returns the policy configured by the environment:
+// PASSWORD_MIN_LENGTH (10 by default) and PASSWORD_BLOCKLIST, the path of a
+// file of passwords to reject on top of the bundled list.
+func PolicyFromEnv() *Policy {
+	p := &Policy{
+		MinLength: utils.IntFromEnv("PASSWORD_MIN_LENGTH", 10),
+		Blocklist: map[string]bool{},
+	}
+	p.Block(commonPasswords)
+
+	if path := os.Getenv("PASSWORD_BLOCKLIST"); path != "" {
+		list, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("Could not read PASSWORD_BLOCKLIST: %v\n", err)
+		} else {
+			p.Block(string(list))
+		}
+	}
+
+	return p
+}
+
+// Block adds the passwords of the list, one per line, to the blocklist.
+// Empty lines and lines starting with # are skipped.
+func (p *Policy) Block(list string) {
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			p.Blocklist[strings.ToLower(line)] = true
+		}
+	}
+}
+
+// Check rejects the new password of the account with the given email if it
+// is missing, too short, too long for bcrypt, on the blocklist, or contains
+// the email address or its local part.
+func (p *Policy) Check(v *validate.Validator, field string, password string, email string) {
+	v.Required(field, password)
+	v.MaxBytes(field, password, validate.MaxPasswordBytes)
+	v.Length(field, password, p.MinLength, validate.MaxPasswordBytes)
+	if v.Failed(field) {
+		return
+	}
+
+	lower := strings.ToLower(password)
+	if p.Blocklist[lower] {
+		v.Fail(field, CodeCommon, "is too common")
+		return
+	}
+
+	if containsEmail(lower, validate.NormalizeEmail(email)) {
+		v.Fail(field, CodeContainsEmail, "must not contain your email address")
+	}
+}
+
+// Check checks a new password with the DefaultPolicy.
+func Check(v *validate.Validator, field string, password string, email string) {
+	DefaultPolicy.Check(v, field, password, email)
+}
+
+// containsEmail reports whether the lowercased password contains the
+// email, or its local part if that is at least 3 characters long.
+func containsEmail(password string, email string) bool {
+	if email == "" {
+		return false
+	}
+	if strings.Contains(password, email) {
+		return true
+	}
+
+	local, _, _ := strings.Cut(email, "@")
+	return len([]rune(local)) >= 3 && strings.Contains(password, local)
+}
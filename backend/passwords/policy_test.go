@@ -0,0 +1,54 @@
+package passwords
+
+import (
+	"brickedup/backend/validate"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p := &Policy{MinLength: 10, Blocklist: map[string]bool{}}
+	p.Block("# comment\nPassword123\n\n  letmeinplease  \n")
+
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"correct horse battery", ""},
+		{"", validate.CodeRequired},
+		{"short", validate.CodeTooShort},
+		{"ünïcödé12", validate.CodeTooShort},
+		{strings.Repeat("ä", 37), validate.CodeTooLong},
+		{"PASSWORD123", CodeCommon},
+		{"letmeinplease", CodeCommon},
+		{"my JOHN.DOE@example.com", CodeContainsEmail},
+		{"john.doe-rocks", CodeContainsEmail},
+		{"johnny was here", ""},
+	}
+	for _, tt := range tests {
+		v := validate.New()
+		p.Check(v, "password", tt.password, "John.Doe@example.com")
+
+		got := ""
+		var errs validate.Errors
+		if errors.As(v.Err(), &errs) {
+			got = errs[0].Code
+		}
+		if got != tt.want {
+			t.Errorf("Check(%q): expected %q, got %q", tt.password, tt.want, got)
+		}
+	}
+}
+
+func TestBundledBlocklist(t *testing.T) {
+	p := PolicyFromEnv()
+	for _, password := range []string{"password123", "qwertyuiop", "1234567890"} {
+		if !p.Blocklist[password] {
+			t.Errorf("expected %q to be blocked", password)
+		}
+	}
+	if p.Blocklist["# common passwords rejected by policy.check, one per line."] {
+		t.Error("comments should not be blocked")
+	}
+}
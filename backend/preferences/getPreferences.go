@@ -0,0 +1,60 @@
+package preferences
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"slices"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// GetPreferences returns the preferences of the user, with the defaults for
+// those they did not change. A default organization the user is not a
+// member of anymore is dropped.
+func GetPreferences(db *sql.DB, userid int) (*utils.Preferences, error) {
+	prefs := Defaults()
+
+	var defaultOrg sql.NullInt64
+	err := db.QueryRow(
+		`SELECT p.timezone, p.locale, p.date_format, p.digest, (
+			SELECT m.orgid FROM ORG_MEMBER m
+			WHERE m.userid = p.userid AND m.orgid = p.default_org
+			LIMIT 1
+		)
+		FROM USER_PREFERENCES p
+		WHERE p.userid = ?`,
+		userid).Scan(&prefs.TimeZone, &prefs.Locale, &prefs.DateFormat, &prefs.Digest, &defaultOrg)
+
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if defaultOrg.Valid {
+		orgid := int(defaultOrg.Int64)
+		prefs.DefaultOrg = &orgid
+	}
+
+	rows, err := db.Query(
+		`SELECT event, channels FROM NOTIFICATION_SETTING WHERE userid = ?`,
+		userid)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event, channels string
+		if err := rows.Scan(&event, &channels); err != nil {
+			return nil, err
+		}
+
+		// Settings of events that were dropped are ignored
+		if slices.Contains(Events, event) {
+			prefs.Notifications[event] = strings.Fields(channels)
+		}
+	}
+
+	return prefs, rows.Err()
+}
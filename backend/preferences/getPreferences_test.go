@@ -0,0 +1,60 @@
+package preferences
+
+import (
+	"brickedup/backend/utils"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestGetPreferences(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	prefs, err := GetPreferences(db, 1)
+	if err != nil {
+		t.Fatalf("GetPreferences returned error: %v", err)
+	}
+	if !reflect.DeepEqual(prefs, Defaults()) {
+		t.Errorf("expected the defaults, got %+v", prefs)
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO USER_PREFERENCES (userid, timezone, locale, date_format, default_org, digest)
+		VALUES (1, 'Europe/Berlin', 'de-DE', 'iso', 1, 'daily')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(
+		`INSERT INTO NOTIFICATION_SETTING (userid, event, channels) VALUES
+		(1, 'issue_due', ''), (1, 'dropped_event', 'email')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefs, err = GetPreferences(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefs.TimeZone != "Europe/Berlin" || prefs.Locale != "de-DE" || prefs.DateFormat != DateISO || prefs.Digest != DigestDaily {
+		t.Errorf("expected the stored preferences, got %+v", prefs)
+	}
+	if prefs.DefaultOrg == nil || *prefs.DefaultOrg != 1 {
+		t.Errorf("expected organization 1 by default, got %v", prefs.DefaultOrg)
+	}
+	if len(prefs.Notifications[EventIssueDue]) != 0 || len(prefs.Notifications[EventIssueAssigned]) != 2 {
+		t.Errorf("expected the stored and default channels, got %v", prefs.Notifications)
+	}
+	if _, ok := prefs.Notifications["dropped_event"]; ok {
+		t.Error("expected unknown events to be ignored")
+	}
+
+	// Leaving the organization drops it as default
+	if _, err = db.Exec(`DELETE FROM ORG_MEMBER WHERE userid = 1 AND orgid = 1`); err != nil {
+		t.Fatal(err)
+	}
+	if prefs, err = GetPreferences(db, 1); err != nil || prefs.DefaultOrg != nil {
+		t.Errorf("expected no default organization, got %v, %v", prefs.DefaultOrg, err)
+	}
+}
@@ -0,0 +1,134 @@
+// Package preferences stores the settings of users: how times are shown to
+// them, which organization they land on, and how they want to be notified.
+//
+// Users only have rows for what they changed; everything else falls back to
+// Defaults. Emails sent to a user show times in their time zone and date
+// format, see SendTemplate. The notification channels and digest frequency
+// are there for whatever sends reminders and digests to honor.
+package preferences
+
+import (
+	"brickedup/backend/utils"
+	"strings"
+
+	_ "time/tzdata" // time zones do not depend on the host
+)
+
+// Date formats.
+const (
+	DateLong = "long" // January 2, 2006
+	DateISO  = "iso"  // 2006-01-02
+	DateMDY  = "mdy"  // 01/02/2006
+	DateDMY  = "dmy"  // 02/01/2006
+)
+
+// dateLayouts are the layouts of package time for each date format, of a
+// date and of a date with its time.
+var dateLayouts = map[string][2]string{
+	DateLong: {"January 2, 2006", "January 2, 2006 at 15:04 MST"},
+	DateISO:  {"2006-01-02", "2006-01-02 15:04 MST"},
+	DateMDY:  {"01/02/2006", "01/02/2006 3:04 PM MST"},
+	DateDMY:  {"02/01/2006", "02/01/2006 15:04 MST"},
+}
+
+// Email digest frequencies.
+const (
+	DigestNever  = "never"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Event types users are notified of.
+const (
+	EventIssueAssigned  = "issue_assigned"
+	EventIssueDue       = "issue_due"
+	EventIssueCompleted = "issue_completed"
+)
+
+// Notification channels.
+const (
+	ChannelEmail = "email"
+	ChannelInApp = "in_app"
+)
+
+// The accepted values of the preferences with a fixed set of them.
+var (
+	DateFormats = []string{DateLong, DateISO, DateMDY, DateDMY}
+	Digests     = []string{DigestNever, DigestDaily, DigestWeekly}
+	Events      = []string{EventIssueAssigned, EventIssueDue, EventIssueCompleted}
+	Channels    = []string{ChannelEmail, ChannelInApp}
+)
+
+// Defaults returns the preferences of users who did not change any.
+func Defaults() *utils.Preferences {
+	return &utils.Preferences{
+		TimeZone:   "UTC",
+		Locale:     "en-US",
+		DateFormat: DateLong,
+		Notifications: map[string][]string{
+			EventIssueAssigned:  {ChannelEmail, ChannelInApp},
+			EventIssueDue:       {ChannelEmail, ChannelInApp},
+			EventIssueCompleted: {ChannelInApp},
+		},
+		Digest: DigestWeekly,
+	}
+}
+
+// canonicalLocale returns the BCP 47 language tag in its usual case, e.g.
+// "zh-Hant-TW" for "ZH-hant-tw". Only a language with an optional script
+// and region is accepted; ok is false for anything else.
+func canonicalLocale(tag string) (locale string, ok bool) {
+	parts := strings.Split(tag, "-")
+	if len(parts) > 3 || !isLetters(parts[0], 2, 3) {
+		return "", false
+	}
+	parts[0] = strings.ToLower(parts[0])
+
+	rest := parts[1:]
+	if len(rest) > 0 && isLetters(rest[0], 4, 4) {
+		rest[0] = strings.ToUpper(rest[0][:1]) + strings.ToLower(rest[0][1:])
+		rest = rest[1:]
+	}
+
+	if len(rest) > 0 {
+		switch {
+		case isLetters(rest[0], 2, 2):
+			rest[0] = strings.ToUpper(rest[0])
+		case isDigits(rest[0], 3):
+		default:
+			return "", false
+		}
+		rest = rest[1:]
+	}
+
+	if len(rest) > 0 {
+		return "", false
+	}
+	return strings.Join(parts, "-"), true
+}
+
+// isLetters reports whether s consists of between min and max ASCII letters.
+func isLetters(s string, min int, max int) bool {
+	if len(s) < min || len(s) > max {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// isDigits reports whether s consists of exactly n ASCII digits.
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package preferences
+
+import "testing"
+
+func TestCanonicalLocale(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+		ok   bool
+	}{
+		{"en-US", "en-US", true},
+		{"de", "de", true},
+		{"ZH-hant-tw", "zh-Hant-TW", true},
+		{"es-419", "es-419", true},
+		{"sr-Latn", "sr-Latn", true},
+		{"", "", false},
+		{"english", "", false},
+		{"en-US-x", "", false},
+		{"en_US", "", false},
+		{"en-1234", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := canonicalLocale(tt.tag)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q: expected %q, %v, got %q, %v", tt.tag, tt.want, tt.ok, got, ok)
+		}
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	defaults := Defaults()
+	defaults.Notifications[EventIssueDue] = nil
+
+	// Every call returns fresh defaults
+	if Defaults().Notifications[EventIssueDue] == nil {
+		t.Error("expected the defaults not to be shared")
+	}
+
+	for _, event := range Events {
+		if _, ok := Defaults().Notifications[event]; !ok {
+			t.Errorf("expected a default for %s", event)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package preferences
+
+import (
+	"brickedup/backend/mail"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// TimeFormat returns how times are shown to the user, in their time zone
+// and date format.
+func TimeFormat(db *sql.DB, userid int) (mail.TimeFormat, error) {
+	prefs, err := GetPreferences(db, userid)
+	if err != nil {
+		return mail.TimeFormat{}, err
+	}
+
+	location, err := time.LoadLocation(prefs.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	layouts, ok := dateLayouts[prefs.DateFormat]
+	if !ok {
+		layouts = dateLayouts[DateLong]
+	}
+
+	return mail.TimeFormat{
+		Location: location,
+		Date:     layouts[0],
+		DateTime: layouts[1],
+	}, nil
+}
+
+// SendTemplate renders the template `name` with `data` and sends it to `to`,
+// showing times the way the user prefers. `to` is usually the address of
+// the user, or one they are moving to.
+func SendTemplate(db *sql.DB, userid int, to string, name string, data any) error {
+	format, err := TimeFormat(db, userid)
+	if err != nil {
+		return err
+	}
+
+	return mail.SendTemplateFormatted(to, name, data, format)
+}
@@ -0,0 +1,40 @@
+package preferences
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSendTemplate(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	timezone, format := "Asia/Tokyo", DateISO
+	_, err := UpdatePreferences(db, 1, utils.PreferencesUpdate{TimeZone: &timezone, DateFormat: &format})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := mail.ResetData{Link: "https://example.com", Expires: time.Date(2025, 3, 1, 18, 0, 0, 0, time.UTC)}
+	if err = SendTemplate(db, 1, "john.doe@example.com", mail.TemplateReset, data); err != nil {
+		t.Fatalf("SendTemplate returned error: %v", err)
+	}
+	if err = SendTemplate(db, 2, "jane.smith@example.com", mail.TemplateReset, data); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := outbox.Messages()
+	if !strings.Contains(sent[0].Text, "2025-03-02 03:00 JST") {
+		t.Errorf("expected the time in the user's format, got %q", sent[0].Text)
+	}
+	if !strings.Contains(sent[1].Text, "March 1, 2025 at 18:00 UTC") {
+		t.Errorf("expected the time in the default format, got %q", sent[1].Text)
+	}
+}
@@ -0,0 +1,159 @@
+package preferences
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// UpdatePreferences changes the preferences of the user set in `update` and
+// returns all of them. Values that are not accepted fail with
+// validate.Errors: time zones have to be IANA names, locales BCP 47 tags,
+// and the default organization one the user is a member of. Notification
+// settings are validated per event, as "notifications.<event>".
+func UpdatePreferences(db *sql.DB, userid int, update utils.PreferencesUpdate) (*utils.Preferences, error) {
+	prefs, err := GetPreferences(db, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	v := validate.New()
+
+	if update.TimeZone != nil {
+		_, err := time.LoadLocation(*update.TimeZone)
+		if err != nil || *update.TimeZone == "" || *update.TimeZone == "Local" {
+			v.Fail("timezone", validate.CodeInvalid, "is not a known time zone")
+		} else {
+			prefs.TimeZone = *update.TimeZone
+		}
+	}
+
+	if update.Locale != nil {
+		locale, ok := canonicalLocale(*update.Locale)
+		if !ok {
+			v.Fail("locale", validate.CodeInvalid, "must be a language tag such as en-US")
+		} else {
+			prefs.Locale = locale
+		}
+	}
+
+	if update.DateFormat != nil {
+		if !slices.Contains(DateFormats, *update.DateFormat) {
+			v.Fail("date_format", validate.CodeInvalid, "must be one of %s", strings.Join(DateFormats, ", "))
+		} else {
+			prefs.DateFormat = *update.DateFormat
+		}
+	}
+
+	if update.Digest != nil {
+		if !slices.Contains(Digests, *update.Digest) {
+			v.Fail("digest", validate.CodeInvalid, "must be one of %s", strings.Join(Digests, ", "))
+		} else {
+			prefs.Digest = *update.Digest
+		}
+	}
+
+	if update.DefaultOrg != nil {
+		orgid := *update.DefaultOrg
+		if orgid == 0 {
+			prefs.DefaultOrg = nil
+		} else {
+			var member bool
+			err := db.QueryRow(
+				`SELECT EXISTS (SELECT 1 FROM ORG_MEMBER WHERE userid = ? AND orgid = ?)`,
+				userid, orgid).Scan(&member)
+
+			if err != nil {
+				return nil, err
+			}
+			if member {
+				prefs.DefaultOrg = &orgid
+			} else {
+				v.Fail("default_org", validate.CodeInvalid, "must be an organization you are a member of")
+			}
+		}
+	}
+
+	events := make([]string, 0, len(update.Notifications))
+	for event := range update.Notifications {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	for _, event := range events {
+		field := "notifications." + event
+		if !slices.Contains(Events, event) {
+			v.Fail(field, validate.CodeInvalid, "is not a known event")
+			continue
+		}
+
+		for _, channel := range update.Notifications[event] {
+			if !slices.Contains(Channels, channel) {
+				v.Fail(field, validate.CodeInvalid, "must only list %s", strings.Join(Channels, ", "))
+				break
+			}
+		}
+		if v.Failed(field) {
+			continue
+		}
+
+		// Listed once each, in the order of Channels
+		channels := []string{}
+		for _, channel := range Channels {
+			if slices.Contains(update.Notifications[event], channel) {
+				channels = append(channels, channel)
+			}
+		}
+		prefs.Notifications[event] = channels
+	}
+
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM USER_PREFERENCES WHERE userid = ?`, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO USER_PREFERENCES (userid, timezone, locale, date_format, default_org, digest)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		userid, prefs.TimeZone, prefs.Locale, prefs.DateFormat, prefs.DefaultOrg, prefs.Digest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		_, err = tx.Exec(
+			`DELETE FROM NOTIFICATION_SETTING WHERE userid = ? AND event = ?`,
+			userid, event)
+
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec(
+			`INSERT INTO NOTIFICATION_SETTING (userid, event, channels) VALUES (?, ?, ?)`,
+			userid, event, strings.Join(prefs.Notifications[event], " "))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return prefs, tx.Commit()
+}
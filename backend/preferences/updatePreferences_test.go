@@ -0,0 +1,96 @@
+package preferences
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"errors"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestUpdatePreferences(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	timezone, locale, digest := "America/New_York", "en-gb", DigestNever
+	orgid := 1
+	prefs, err := UpdatePreferences(db, 1, utils.PreferencesUpdate{
+		TimeZone:   &timezone,
+		Locale:     &locale,
+		DefaultOrg: &orgid,
+		Digest:     &digest,
+		Notifications: map[string][]string{
+			EventIssueCompleted: {ChannelInApp, ChannelEmail, ChannelEmail},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdatePreferences returned error: %v", err)
+	}
+	if prefs.Locale != "en-GB" || prefs.DateFormat != DateLong {
+		t.Errorf("expected the canonical locale and the default date format, got %+v", prefs)
+	}
+	if got := prefs.Notifications[EventIssueCompleted]; !reflect.DeepEqual(got, []string{ChannelEmail, ChannelInApp}) {
+		t.Errorf("expected each channel once, got %v", got)
+	}
+
+	stored, err := GetPreferences(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(stored, prefs) {
+		t.Errorf("expected %+v to be stored, got %+v", prefs, stored)
+	}
+
+	// Only what is set changes
+	format := DateDMY
+	orgid = 0
+	prefs, err = UpdatePreferences(db, 1, utils.PreferencesUpdate{DateFormat: &format, DefaultOrg: &orgid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefs.TimeZone != timezone || prefs.DateFormat != DateDMY || prefs.DefaultOrg != nil {
+		t.Errorf("expected a partial update, got %+v", prefs)
+	}
+	if len(prefs.Notifications[EventIssueCompleted]) != 2 {
+		t.Errorf("expected the notifications to stay, got %v", prefs.Notifications)
+	}
+}
+
+func TestUpdatePreferencesInvalid(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	timezone, locale, format, digest := "Mars/Olympus_Mons", "klingon", "julian", "hourly"
+	orgid := 2
+	_, err := UpdatePreferences(db, 1, utils.PreferencesUpdate{
+		TimeZone:   &timezone,
+		Locale:     &locale,
+		DateFormat: &format,
+		DefaultOrg: &orgid,
+		Digest:     &digest,
+		Notifications: map[string][]string{
+			EventIssueDue:    {"carrier_pigeon"},
+			"issue_exploded": {ChannelEmail},
+		},
+	})
+
+	var errs validate.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	want := []string{"timezone", "locale", "date_format", "digest", "default_org", "notifications.issue_due", "notifications.issue_exploded"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("expected errors for %v, got %v", want, fields)
+	}
+
+	if prefs, _ := GetPreferences(db, 1); !reflect.DeepEqual(prefs, Defaults()) {
+		t.Errorf("expected nothing to be stored, got %+v", prefs)
+	}
+}
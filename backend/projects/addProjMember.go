@@ -6,20 +6,11 @@ import (
 )
 
 // AddProjMember adds a user to a project. The user is given a role within the project.
-func AddProjMember(db *sql.DB, sessionid int64, userid int, roleid int, projectid int) error {
-	var manager int
+func AddProjMember(db *sql.DB, manager int, userid int, roleid int, projectid int) error {
 	var has_exec bool
 	var user_exists bool
 
-	err := db.QueryRow(
-		`SELECT userid FROM SESSION WHERE id = ?`,
-		sessionid).Scan(&manager)
-
-	if err != nil {
-		return err
-	}
-
-	err = db.QueryRow(`
+	err := db.QueryRow(`
 		SELECT EXISTS(
 			SELECT 1 FROM PROJECT_MEMBER_ROLE pmr
 			JOIN PROJECT_ROLE pr ON pmr.roleid = pr.id
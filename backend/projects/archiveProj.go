@@ -7,20 +7,11 @@ import (
 
 // ArchiveProj marks a project as "archived" if the user has the necessary
 // privileges in the project's organization.
-func ArchiveProj(db *sql.DB, sessionid int, projectid int) error {
-	var userid int
+func ArchiveProj(db *sql.DB, userid int, projectid int) error {
 	var orgid int
 	var has_exec bool
 
 	err := db.QueryRow(
-		`SELECT userid FROM SESSION WHERE id = ?`,
-		sessionid).Scan(&userid)
-
-	if err != nil {
-		return err
-	}
-
-	err = db.QueryRow(
 		`SELECT orgid FROM PROJECT WHERE id = ?`,
 		projectid).Scan(&orgid)
 
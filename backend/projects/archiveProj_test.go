@@ -3,7 +3,6 @@ package projects
 import (
 	"brickedup/backend/utils"
 	"testing"
-	"time"
 )
 
 func TestArchiveProj(t *testing.T) {
@@ -12,15 +11,8 @@ func TestArchiveProj(t *testing.T) {
 
 	const projectid = 1
 
-	// Insert session for user A (userID 1 - John, has exec permission in project 1)
-	expiry := time.Now().Add(24 * time.Hour)
-	res, err := db.Exec(`INSERT INTO SESSION (userid, expires) VALUES (?, ?)`, 1, expiry)
-	if err != nil {
-		t.Fatalf("Failed to insert session for user 1: %v", err)
-	}
-	sessionID, _ := res.LastInsertId()
-
-	err = ArchiveProj(db, int(sessionID), 1)
+	// User A (userID 1 - John) has exec permission in project 1
+	err := ArchiveProj(db, 1, 1)
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
@@ -45,19 +37,13 @@ func TestArchiveProj(t *testing.T) {
 	}
 
 	// Project does not exist
-	err = ArchiveProj(db, int(sessionID), -1)
+	err = ArchiveProj(db, 1, -1)
 	if err == nil {
 		t.Fatal("ArchiveProj should fail when project does not exist!")
 	}
 
 	// User has insufficient privileges (userID=2, Jane)
-	res, err = db.Exec(`INSERT INTO SESSION (userid, expires) VALUES (?, ?)`, 2, expiry)
-	if err != nil {
-		t.Fatalf("Failed to insert session for user 2: %v", err)
-	}
-	noExecSessionID, _ := res.LastInsertId()
-
-	err = ArchiveProj(db, int(noExecSessionID), projectid)
+	err = ArchiveProj(db, 2, projectid)
 	if err == nil {
 		t.Fatal("User with insufficient privileges should not be able to archive a project.")
 	}
@@ -6,22 +6,11 @@ import (
 )
 
 // assignProjectRole promotes a validated user (userB) to a new role within a project,
-// if the acting user (userA, identified via sessionid) has exec permission within that project.
-func assignProjectRole(db *sql.DB, sessionid int, userBID int, roleid, projectid int) error {
-	// Validate session
-	var userA int
-	var sessionValid bool
-	err := db.QueryRow(`
-		SELECT userid, expires > CURRENT_TIMESTAMP 
-		FROM SESSION WHERE id = ?
-	`, sessionid).Scan(&userA, &sessionValid)
-	if err != nil || !sessionValid {
-		return errors.New("invalid session")
-	}
-
+// if the acting user (userA, the authenticated user) has exec permission within that project.
+func assignProjectRole(db *sql.DB, userA int, userBID int, roleid, projectid int) error {
 	// Check project existence
 	var exists bool
-	err = db.QueryRow(`SELECT EXISTS(SELECT 1 FROM PROJECT WHERE id = ?)`, projectid).Scan(&exists)
+	err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM PROJECT WHERE id = ?)`, projectid).Scan(&exists)
 	if err != nil || !exists {
 		return errors.New("project not found")
 	}
@@ -1,33 +1,26 @@
 package projects
 
 import (
-	"brickedup/backend/utils"
+	"brickedup/backend/validate"
 	"database/sql"
-	"errors"
 
 	_ "modernc.org/sqlite"
 )
 
-// CreateProj creates a new project and assigns the user (from the session) to it as an admin.
+// CreateProj creates a new project and assigns the user to it as an admin.
 func CreateProj(
 	db *sql.DB, 
-	sessionID int,
+	userID int,
 	orgid int,
 	name string,
 	budget int, 
 	charter string) error {
 
-	if name == "" {
-		return errors.New("missing project name")
-	}
-
-	// In the function
-	name = utils.SanitizeText(name, utils.TEXT)
-	charter = utils.SanitizeText(charter, utils.TEXT)
-
-	// If sanitization removed all characters, reject the input
-	if name == "" {
-		return errors.New("project name contains only invalid characters")
+	v := validate.New()
+	v.Line("name", name, validate.MaxNameLength)
+	v.Text("charter", charter, validate.MaxTextLength)
+	if err := v.Err(); err != nil {
+		return err
 	}
 
 	// Begin transaction to ensure data consistency
@@ -35,21 +28,7 @@ func CreateProj(
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Get the user ID from the session
-	var userID int
-	err = tx.QueryRow("SELECT userid FROM SESSION WHERE id = ?", sessionID).Scan(&userID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return errors.New("no session exists for the provided sessionID")
-		}
-		return err
-	}
+	defer tx.Rollback()
 
 	result, err := tx.Exec(
 		"INSERT INTO PROJECT(name, budget, charter, orgid, archived) VALUES(?, ?, ?, ?, 0)", 
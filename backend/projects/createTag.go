@@ -1,7 +1,7 @@
 package projects
 
 import (
-	"brickedup/backend/utils"
+	"brickedup/backend/validate"
 	"database/sql"
 	"errors"
 
@@ -9,24 +9,14 @@ import (
 )
 
 // CreateTag creates a new tag for a project.
-// It takes sessionID (int), projectID (int), tagName (string), and tagColor (string) as parameters.
-func CreateTag(db *sql.DB, sessionID int, projectID int, tagName string, tagColor string) (int, error) {
+// It takes the user ID of the caller (int), projectID (int), tagName (string), and tagColor (string) as parameters.
+func CreateTag(db *sql.DB, userID int, projectID int, tagName string, tagColor string) (int, error) {
 	// Validate inputs
-	if tagName == "" || tagColor == "" {
-		return 0, errors.New("missing tagName or tagColor")
-	}
-
-	// Sanitize the tag name and color
-	sanitizedTagName := utils.SanitizeText(tagName, utils.TEXT)
-	sanitizedTagColor := utils.SanitizeText(tagColor, utils.PASSWORD)
-
-	// If sanitization removed all characters, reject the input
-	if sanitizedTagName == "" || sanitizedTagColor == "" {
-		return 0, errors.New("tag name or color contains only invalid characters")
-	}
-
-	if len(sanitizedTagColor) > 0 && sanitizedTagColor[0] != '#' {
-		sanitizedTagColor = "#" + sanitizedTagColor
+	v := validate.New()
+	v.Line("name", tagName, validate.MaxNameLength)
+	v.HexColor("color", tagColor)
+	if err := v.Err(); err != nil {
+		return 0, err
 	}
 
 	// Begin transaction to ensure data consistency
@@ -34,21 +24,7 @@ func CreateTag(db *sql.DB, sessionID int, projectID int, tagName string, tagColo
 	if err != nil {
 		return 0, err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Get the user ID from the session
-	var userID int
-	err = tx.QueryRow("SELECT userid FROM SESSION WHERE id = ?", sessionID).Scan(&userID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return 0, errors.New("no session exists for the provided sessionID")
-		}
-		return 0, err
-	}
+	defer tx.Rollback()
 
 	// Check if the project exists
 	var existingProjectID int
@@ -77,7 +53,7 @@ func CreateTag(db *sql.DB, sessionID int, projectID int, tagName string, tagColo
 
 	// Check if the tag name already exists in the project
 	var existingTagID int
-	err = tx.QueryRow("SELECT id FROM TAG WHERE projectid = ? AND name = ?", projectID, sanitizedTagName).Scan(&existingTagID)
+	err = tx.QueryRow("SELECT id FROM TAG WHERE projectid = ? AND name = ?", projectID, tagName).Scan(&existingTagID)
 	if err == nil {
 		return 0, errors.New("tag name already exists in the project")
 	}
@@ -86,7 +62,7 @@ func CreateTag(db *sql.DB, sessionID int, projectID int, tagName string, tagColo
 	}
 
 	// Insert new tag and get its ID
-	result, err := tx.Exec("INSERT INTO TAG(projectid, name, color) VALUES(?, ?, ?)", projectID, sanitizedTagName, sanitizedTagColor)
+	result, err := tx.Exec("INSERT INTO TAG(projectid, name, color) VALUES(?, ?, ?)", projectID, tagName, tagColor)
 	if err != nil {
 		return 0, err
 	}
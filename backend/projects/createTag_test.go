@@ -2,6 +2,8 @@ package projects
 
 import (
 	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"errors"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -11,14 +13,11 @@ func TestCreateTag(t *testing.T) {
 	db := utils.SetupTest(t)
 	defer db.Close()
 
-	// Get an existing session ID and project ID from the database
-	var sessionID, projectID int
-	err := db.QueryRow("SELECT id FROM SESSION LIMIT 1").Scan(&sessionID)
-	if err != nil {
-		t.Fatalf("failed to get session ID: %v", err)
-	}
+	// Use an existing user from populate.sql and get a project ID from the database
+	userID := 1
 
-	err = db.QueryRow("SELECT id FROM PROJECT LIMIT 1").Scan(&projectID)
+	var projectID int
+	err := db.QueryRow("SELECT id FROM PROJECT LIMIT 1").Scan(&projectID)
 	if err != nil {
 		t.Fatalf("failed to get project ID: %v", err)
 	}
@@ -26,9 +25,8 @@ func TestCreateTag(t *testing.T) {
 	// Test valid tag creation
 	tagName := "Test Tag Name"
 	tagColor := "#FF5733" // A valid color code
-	expectedSanitizedTagName := utils.SanitizeText(tagName, utils.TEXT)
 
-	tagID, err := CreateTag(db, sessionID, projectID, tagName, tagColor)
+	tagID, err := CreateTag(db, userID, projectID, tagName, tagColor)
 	if err != nil {
 		t.Errorf("CreateTag returned error: %v", err)
 	}
@@ -37,48 +35,61 @@ func TestCreateTag(t *testing.T) {
 		t.Errorf("expected valid tag ID, got %d", tagID)
 	}
 
-	// Verify tag was created with sanitized name
+	// Verify tag was stored verbatim
 	var retrievedTagName, retrievedTagColor string
 	err = db.QueryRow("SELECT name, color FROM TAG WHERE id = ?", tagID).Scan(&retrievedTagName, &retrievedTagColor)
 	if err != nil {
 		t.Errorf("failed to retrieve tag: %v", err)
 	}
-	if retrievedTagName != expectedSanitizedTagName {
-		t.Errorf("expected tag name %s, got %s", expectedSanitizedTagName, retrievedTagName)
+	if retrievedTagName != tagName {
+		t.Errorf("expected tag name %s, got %s", tagName, retrievedTagName)
 	}
 	if retrievedTagColor != tagColor {
 		t.Errorf("expected tag color %s, got %s", tagColor, retrievedTagColor)
 	}
 
 	// Test duplicate tag name
-	_, err = CreateTag(db, sessionID, projectID, tagName, tagColor)
+	_, err = CreateTag(db, userID, projectID, tagName, tagColor)
 	if err == nil {
 		t.Errorf("expected error for duplicate tag name, got nil")
 	}
 
-	// Test with potentially dangerous input
-	maliciousTagName := "Dangerous'; DROP TABLE TAG; --"
-	sanitizedMalicious := utils.SanitizeText(maliciousTagName, utils.TEXT)
-
-	if sanitizedMalicious == maliciousTagName {
-		t.Errorf("sanitization failed to clean malicious input")
+	// Punctuation and digits are kept as typed; queries are parameterized
+	quotedTagName := "Dangerous'; DROP TABLE TAG; --"
+	tagID, err = CreateTag(db, userID, projectID, quotedTagName, "#000000")
+	if err != nil {
+		t.Fatalf("CreateTag returned error: %v", err)
 	}
-
-	// Test with empty string after sanitization
-	_, err = CreateTag(db, sessionID, projectID, "12345", "#000000")
-	if err == nil && utils.SanitizeText("12345", utils.TEXT) == "" {
-		t.Errorf("should reject input that becomes empty after sanitization")
+	err = db.QueryRow("SELECT name FROM TAG WHERE id = ?", tagID).Scan(&retrievedTagName)
+	if err != nil || retrievedTagName != quotedTagName {
+		t.Errorf("expected tag name %q, got %q (%v)", quotedTagName, retrievedTagName, err)
 	}
 
-	// Test missing tagColor
-	_, err = CreateTag(db, sessionID, projectID, tagName, "")
-	if err == nil || err.Error() != "missing tagName or tagColor" {
-		t.Errorf("expected error for missing tagColor, got: %v", err)
+	_, err = CreateTag(db, userID, projectID, "v2.0", "#000")
+	if err != nil {
+		t.Errorf("expected digits to be accepted, got: %v", err)
 	}
 
-	// Test missing tagName
-	_, err = CreateTag(db, sessionID, projectID, "", tagColor)
-	if err == nil || err.Error() != "missing tagName or tagColor" {
-		t.Errorf("expected error for missing tagName, got: %v", err)
+	tests := []struct {
+		name   string
+		color  string
+		field  string
+		code   string
+	}{
+		{tagName, "", "color", validate.CodeRequired},
+		{"", tagColor, "name", validate.CodeRequired},
+		{"Bad Color", "red", "color", validate.CodeInvalid},
+		{"Line\nBreak", tagColor, "name", validate.CodeInvalid},
+	}
+	for _, tt := range tests {
+		_, err = CreateTag(db, userID, projectID, tt.name, tt.color)
+		var verrs validate.Errors
+		if !errors.As(err, &verrs) {
+			t.Errorf("CreateTag(%q, %q): expected validation error, got: %v", tt.name, tt.color, err)
+			continue
+		}
+		if len(verrs) != 1 || verrs[0].Field != tt.field || verrs[0].Code != tt.code {
+			t.Errorf("CreateTag(%q, %q): expected %s/%s, got %v", tt.name, tt.color, tt.field, tt.code, verrs)
+		}
 	}
 }
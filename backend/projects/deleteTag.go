@@ -4,29 +4,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"strconv"
 
 	_ "modernc.org/sqlite"
 )
 
-// DeleteTag removes a tag from the database if the user (linked by sessionID) has write permissions.
-func DeleteTag(db *sql.DB, sessionID int, tagID int) error {
+// DeleteTag removes a tag from the database if the user has write permissions.
+func DeleteTag(db *sql.DB, userID int, tagID int) error {
 	var canWrite bool
-	var userID int
 
-	// First, check if the provided sessionID exists in the SESSION table.
-	// If no matching row is found, the session is considered invalid.
-	err := db.QueryRow("SELECT userid FROM SESSION WHERE id = ?", sessionID).Scan(&userID)
-	if err != nil {
-		// If no session row is found, return a descriptive error.
-		if err == sql.ErrNoRows {
-			return errors.New("no session found for session ID " + strconv.Itoa(sessionID))
-		}
-		// For any other database error, return it as-is.
-		return err
-	}
-
-	// Next, check if the user (linked to the session) has the can_write permission for the specified tag.
+	// Check if the user has the can_write permission for the specified tag.
 	// This query joins multiple tables to ensure the user is part of the project and has a role allowing writes.
 	query := `
         SELECT pr.can_write
@@ -37,12 +23,10 @@ func DeleteTag(db *sql.DB, sessionID int, tagID int) error {
             ON pm.id = pmr.memberid
         JOIN PROJECT_ROLE pr
             ON pmr.roleid = pr.id
-        JOIN SESSION s
-            ON pm.userid = s.userid
         WHERE t.id = ?
-          AND s.id = ?;
+          AND pm.userid = ?;
     `
-	err = db.QueryRow(query, tagID, sessionID).Scan(&canWrite)
+	err := db.QueryRow(query, tagID, userID).Scan(&canWrite)
 	if err != nil {
 		// If there is no row matching this tag and session, it implies the user has no access or the session is invalid for that tag.
 		if err == sql.ErrNoRows {
@@ -13,28 +13,28 @@ import (
 func TestDeleteTag(t *testing.T) {
     tests := []struct {
         name        string
-        sessionID   int
+        userID      int
         tagID       int
         wantErr     bool
         wantDeleted bool
     }{
         {
             name:        "User1 can delete Tag #1 => success",
-            sessionID:   1,
+            userID:      1,
             tagID:       1,
             wantErr:     false,
             wantDeleted: true,
         },
         {
             name:        "User4 tries to delete Tag #1 => should fail",
-            sessionID:   5,
+            userID:      4,
             tagID:       1,
             wantErr:     true,
             wantDeleted: false,
         },
         {
-            name:        "Invalid session => should fail",
-            sessionID:   999,
+            name:        "Unknown user => should fail",
+            userID:      999,
             tagID:       1,
             wantErr:     true,
             wantDeleted: false,
@@ -47,7 +47,7 @@ func TestDeleteTag(t *testing.T) {
 			defer db.Close()
 
             // Call DeleteTag
-			err := DeleteTag(db, tc.sessionID, tc.tagID)
+			err := DeleteTag(db, tc.userID, tc.tagID)
             if tc.wantErr && err == nil {
                 t.Errorf("expected an error but got nil")
             }
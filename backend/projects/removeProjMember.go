@@ -5,32 +5,19 @@ import (
 	"errors"
 )
 
-func RemoveProjMember(db *sql.DB, sessionid int64, memberid int) error {
-	var manager int
+func RemoveProjMember(db *sql.DB, manager int, memberid int) error {
 	var has_exec bool
 
-	err := db.QueryRow(
-		`SELECT userid FROM SESSION WHERE id = ?`,
-		sessionid).Scan(&manager)
-
-	if err != nil {
-		return err
-	}
-
-	err = db.QueryRow(`
+	err := db.QueryRow(`
 		SELECT EXISTS(
 			SELECT * FROM PROJECT_MEMBER_ROLE pmr
 			JOIN PROJECT_ROLE pr ON pmr.roleid = pr.id
 			WHERE pmr.memberid = (
 				SELECT id
 				FROM PROJECT_MEMBER
-				WHERE userid = (
-					SELECT userid
-					FROM SESSION
-					WHERE id = ?
-				)
+				WHERE userid = ?
 			) AND pr.can_exec = 1
-		)`, manager, memberid).Scan(&has_exec)
+		)`, manager).Scan(&has_exec)
 
 	if err != nil {
 		return err
@@ -6,20 +6,13 @@ import (
 )
 
 // removeUserRole removes the role of user B in a project.
-// sessionID is used to authenticate user A (the initiator).
-func removeUserRole(db *sql.DB, sessionID int, userBID int, roleID, projectID int) error {
-	var userAID int
+// User A is the authenticated user initiating the change.
+func removeUserRole(db *sql.DB, userAID int, userBID int, roleID, projectID int) error {
 	var isUserAValidated, isUserBValidated bool
 	var userBExists, userBInProject, userBHasRole, userAHasExec bool
 
-	// Get user A from session
-	err := db.QueryRow(`SELECT userid FROM SESSION WHERE id = ?`, sessionID).Scan(&userAID)
-	if err != nil {
-		return errors.New("invalid session ID")
-	}
-
 	// Check if user A is verified
-	err = db.QueryRow(`SELECT verified FROM USER WHERE id = ?`, userAID).Scan(&isUserAValidated)
+	err := db.QueryRow(`SELECT verified FROM USER WHERE id = ?`, userAID).Scan(&isUserAValidated)
 	if err != nil || !isUserAValidated {
 		return errors.New("user A is not validated")
 	}
@@ -3,7 +3,6 @@ package projects
 import (
 	"brickedup/backend/utils"
 	"testing"
-	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -12,16 +11,10 @@ func TestRemoveUserRole(t *testing.T) {
 	db := utils.SetupTest(t)
 	defer db.Close()
 
-	// Insert session for user A (userID 1 - John, has exec permission in project 1)
-	expiry := time.Now().Add(24 * time.Hour)
-	res, err := db.Exec(`INSERT INTO SESSION (userid, expires) VALUES (?, ?)`, 1, expiry)
-	if err != nil {
-		t.Fatalf("Failed to insert session for user 1: %v", err)
-	}
-	sessionID, _ := res.LastInsertId()
+	// User A (userID 1 - John) has exec permission in project 1
 
 	// SUCCESS: Remove Jane (userID 2)'s Developer role (roleid=2) in project 1
-	err = removeUserRole(db, int(sessionID), 2, 2, 1)
+	err := removeUserRole(db, 1, 2, 2, 1)
 	if err != nil {
 		t.Fatalf("Expected success, got error: %v", err)
 	}
@@ -43,7 +36,7 @@ func TestRemoveUserRole(t *testing.T) {
 	}
 
 	// ERROR: User B does not exist
-	err = removeUserRole(db, int(sessionID), 999, 2, 1)
+	err = removeUserRole(db, 1, 999, 2, 1)
 	if err == nil || err.Error() != "user B does not exist" {
 		t.Fatalf("Expected 'user B does not exist', got: %v", err)
 	}
@@ -61,13 +54,7 @@ func TestRemoveUserRole(t *testing.T) {
 	}
 
 	// ERROR: User A lacks exec permissions (userID=2, Jane)
-	res, err = db.Exec(`INSERT INTO SESSION (userid, expires) VALUES (?, ?)`, 2, expiry)
-	if err != nil {
-		t.Fatalf("Failed to insert session for user 2: %v", err)
-	}
-	noExecSessionID, _ := res.LastInsertId()
-
-	err = removeUserRole(db, int(noExecSessionID), 1, 1, 1)
+	err = removeUserRole(db, 2, 1, 1, 1)
 	if err == nil || err.Error() != "user A lacks exec permissions" {
 		t.Fatalf("Expected 'user A lacks exec permissions', got: %v", err)
 	}
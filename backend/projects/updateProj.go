@@ -2,29 +2,18 @@ package projects
 
 import (
 	"database/sql"
-	"time"
 
 	"brickedup/backend/utils"
+	"brickedup/backend/validate"
 
 	_ "modernc.org/sqlite"
 )
 
 // UpdateProject function updates project.
-func UpdateProject(db *sql.DB, sessionID, projectID int, project utils.Project) error {
-	// First, validate the session and check execution privileges
-	var userID int
-	var sessionExpires time.Time
-	err := db.QueryRow(`
-		SELECT userid, expires FROM SESSION 
-		WHERE id = ? AND expires > ?
-	`, sessionID, time.Now()).Scan(&userID, &sessionExpires)
-	if err != nil {
-		return err
-	}
-
+func UpdateProject(db *sql.DB, userID int, projectID int, project utils.Project) error {
 	// Check if the user has exec privileges for this project
 	var hasExecPrivilege bool
-	err = db.QueryRow(`
+	err := db.QueryRow(`
 		SELECT EXISTS (
 			SELECT 1 FROM PROJECT_MEMBER pm
 			JOIN PROJECT_MEMBER_ROLE pmr ON pm.id = pmr.memberid
@@ -39,14 +28,12 @@ func UpdateProject(db *sql.DB, sessionID, projectID int, project utils.Project)
 		return sql.ErrNoRows // Indicates no matching privileges found
 	}
 
-	// Sanitize project fields
-	sanitizedProject := utils.Project{
-		ID:       project.ID,
-		OrgID:    project.OrgID,
-		Name:     utils.SanitizeText(project.Name, utils.TEXT),
-		Budget:   project.Budget,
-		Charter:  utils.SanitizeText(project.Charter, utils.TEXT),
-		Archived: project.Archived,
+	// Validate project fields
+	v := validate.New()
+	v.Line("name", project.Name, validate.MaxNameLength)
+	v.Text("charter", project.Charter, validate.MaxTextLength)
+	if err := v.Err(); err != nil {
+		return err
 	}
 
 	// Update the project in the database
@@ -55,12 +42,12 @@ func UpdateProject(db *sql.DB, sessionID, projectID int, project utils.Project)
 		SET name = ?, budget = ?, charter = ?, archived = ?
 		WHERE id = ? AND orgid = ?
 	`,
-		sanitizedProject.Name,
-		sanitizedProject.Budget,
-		sanitizedProject.Charter,
-		sanitizedProject.Archived,
+		project.Name,
+		project.Budget,
+		project.Charter,
+		project.Archived,
 		projectID,
-		sanitizedProject.OrgID,
+		project.OrgID,
 	)
 	if err != nil {
 		return err
@@ -3,7 +3,6 @@ package projects
 import (
 	"brickedup/backend/utils"
 	"testing"
-	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -28,8 +27,7 @@ func TestUpdateProject(t *testing.T) {
 		t.Fatalf("Failed to get original project data: %v", err)
 	}
 
-	// Create a session with a valid user who has exec privileges
-	// First find a user with exec privileges for this project
+	// Find a user with exec privileges for this project
 	var userID int
 	err = db.QueryRow(`
 		SELECT pm.userid FROM PROJECT_MEMBER pm
@@ -41,18 +39,6 @@ func TestUpdateProject(t *testing.T) {
 		t.Fatalf("Could not find a user with exec privileges: %v", err)
 	}
 
-	// Create a session for this user
-	result, err := db.Exec("INSERT INTO SESSION (userid, expires) VALUES (?, ?)",
-		userID, time.Now().Add(1*time.Hour))
-	if err != nil {
-		t.Fatalf("Failed to create test session: %v", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		t.Fatalf("Failed to get last insert ID: %v", err)
-	}
-	sessionID := int(id)
-
 	// Create updated project data
 	updatedProject := utils.Project{
 		ID:       originalProject.ID,
@@ -64,7 +50,7 @@ func TestUpdateProject(t *testing.T) {
 	}
 
 	// Test successful update
-	err = UpdateProject(db, sessionID, projectID, updatedProject)
+	err = UpdateProject(db, userID, projectID, updatedProject)
 	if err != nil {
 		t.Errorf("Expected project update to succeed, got error: %v", err)
 	}
@@ -93,36 +79,11 @@ func TestUpdateProject(t *testing.T) {
 
 	// Test with non-existent project ID
 	nonExistentProjectID := 99999
-	err = UpdateProject(db, sessionID, nonExistentProjectID, updatedProject)
+	err = UpdateProject(db, userID, nonExistentProjectID, updatedProject)
 	if err == nil {
 		t.Errorf("Expected error for non-existent project ID, but got nil")
 	}
 
-	// Test with invalid session ID
-	invalidSessionID := 99999
-	err = UpdateProject(db, invalidSessionID, projectID, updatedProject)
-	if err == nil {
-		t.Errorf("Expected error for invalid session ID, but got nil")
-	}
-
-	// Test with expired session
-	// Create an expired session for testing
-	expiredResult, err := db.Exec("INSERT INTO SESSION (userid, expires) VALUES (?, ?)",
-		userID, time.Now().Add(-1*time.Hour))
-	if err != nil {
-		t.Fatalf("Failed to create expired test session: %v", err)
-	}
-	expiredID, err := expiredResult.LastInsertId()
-	if err != nil {
-		t.Fatalf("Failed to get last insert ID: %v", err)
-	}
-	expiredSessionID := int(expiredID)
-
-	err = UpdateProject(db, expiredSessionID, projectID, updatedProject)
-	if err == nil {
-		t.Errorf("Expected error for expired session, but got nil")
-	}
-
 	// Test with user who doesn't have exec privileges
 	// First we need to find or create a user without exec privileges
 	var nonExecUserID int
@@ -186,19 +147,8 @@ func TestUpdateProject(t *testing.T) {
 		}
 	}
 
-	// Create a session for this non-exec user
-	nonExecResult, err := db.Exec("INSERT INTO SESSION (userid, expires) VALUES (?, ?)",
-		nonExecUserID, time.Now().Add(1*time.Hour))
-	if err != nil {
-		t.Fatalf("Failed to create test session: %v", err)
-	}
-	nonExecSessionID, err := nonExecResult.LastInsertId()
-	if err != nil {
-		t.Fatalf("Failed to get last insert ID: %v", err)
-	}
-
 	// Try to update with a user who doesn't have exec privileges
-	err = UpdateProject(db, int(nonExecSessionID), projectID, updatedProject)
+	err = UpdateProject(db, nonExecUserID, projectID, updatedProject)
 	if err == nil {
 		t.Errorf("Expected error for user without exec privileges, but got nil")
 	}
@@ -0,0 +1,41 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"time"
+)
+
+// Session lifetimes. They are read from the environment on startup as Go
+// durations (e.g. "30m", "12h") and fall back to the defaults below.
+var (
+	// IdleTimeout ends a session after this long without any request.
+	// Every request made with the session pushes the deadline forward.
+	IdleTimeout = utils.DurationFromEnv("SESSION_IDLE_TIMEOUT", 24*time.Hour)
+
+	// AbsoluteTimeout ends a session this long after login, no matter how
+	// active it is.
+	AbsoluteTimeout = utils.DurationFromEnv("SESSION_ABSOLUTE_TIMEOUT", 7*24*time.Hour)
+
+	// RememberTimeout replaces both timeouts for "remember me" sessions.
+	RememberTimeout = utils.DurationFromEnv("SESSION_REMEMBER_TIMEOUT", 30*24*time.Hour)
+)
+
+// timeouts returns the idle and absolute timeout of a session.
+func timeouts(remember bool) (idle, absolute time.Duration) {
+	if remember {
+		return RememberTimeout, RememberTimeout
+	}
+	return IdleTimeout, AbsoluteTimeout
+}
+
+// idleDeadline returns when a session seen at `now` expires from inactivity.
+// The deadline never exceeds the absolute expiry of the session.
+func idleDeadline(now time.Time, remember bool, absolute time.Time) time.Time {
+	idle, _ := timeouts(remember)
+
+	deadline := now.Add(idle)
+	if deadline.After(absolute) {
+		return absolute
+	}
+	return deadline
+}
@@ -0,0 +1,32 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"context"
+)
+
+// contextKey is the type of the key under which the authenticated session is
+// stored in a request context.
+type contextKey struct{}
+
+// NewContext returns a copy of ctx that carries the authenticated session.
+func NewContext(ctx context.Context, session *utils.Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, session)
+}
+
+// FromContext returns the authenticated session stored in ctx.
+// The boolean is false if the context does not carry a session.
+func FromContext(ctx context.Context) (*utils.Session, bool) {
+	session, ok := ctx.Value(contextKey{}).(*utils.Session)
+	return session, ok
+}
+
+// UserFromContext returns the ID of the authenticated user stored in ctx.
+// The boolean is false if the context does not carry a user.
+func UserFromContext(ctx context.Context) (int, bool) {
+	session, ok := FromContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	return session.UserID, true
+}
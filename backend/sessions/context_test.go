@@ -0,0 +1,27 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"context"
+	"testing"
+)
+
+func TestUserFromContext(t *testing.T) {
+	if _, ok := UserFromContext(context.Background()); ok {
+		t.Error("expected no user in an empty context")
+	}
+
+	ctx := NewContext(context.Background(), &utils.Session{ID: 7, UserID: 42})
+	userid, ok := UserFromContext(ctx)
+	if !ok {
+		t.Fatal("expected a user in the context")
+	}
+	if userid != 42 {
+		t.Errorf("expected user 42, got %d", userid)
+	}
+
+	session, ok := FromContext(ctx)
+	if !ok || session.ID != 7 {
+		t.Errorf("expected session 7, got %v", session)
+	}
+}
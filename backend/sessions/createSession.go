@@ -0,0 +1,97 @@
+// Package sessions issues and resolves the login sessions of users.
+//
+// Sessions are identified by a random token which is handed to the client
+// exactly once. Only the SHA-256 hash of the token is stored in the SESSION
+// table, so a leaked database cannot be used to impersonate users.
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// CreateSession starts a new session for the user and returns its raw
+// session and refresh tokens. "Remember me" sessions use RememberTimeout
+// instead of the regular timeouts. The IP address and user agent of the
+// client are recorded so the user can recognise the session later on.
+// Expired sessions of the user are cleaned up on the way.
+func CreateSession(db *sql.DB, userid int, remember bool, ip, userAgent string) (*utils.SessionData, error) {
+	session := &utils.SessionData{
+		UserID:   userid,
+		Remember: remember,
+	}
+
+	var err error
+	session.SessionID, err = utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session.RefreshToken, err = utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, absolute := timeouts(remember)
+	session.AbsoluteExpires = now.Add(absolute)
+	session.Expires = idleDeadline(now, remember, session.AbsoluteExpires)
+
+	err = deleteExpiredSessions(db, userid, now)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := db.Exec(
+		`INSERT INTO SESSION (userid, token, expires, absolute_expires,
+			remember, created, last_seen, ip, user_agent)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userid, utils.HashToken(session.SessionID), session.Expires,
+		session.AbsoluteExpires, remember, now, now, ip, userAgent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sessionid, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO REFRESH_TOKEN (sessionid, token)
+		VALUES (?, ?)`,
+		sessionid, utils.HashToken(session.RefreshToken))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// deleteExpiredSessions removes the sessions of the user that can no longer
+// be refreshed, together with their refresh tokens.
+func deleteExpiredSessions(db *sql.DB, userid int, now time.Time) error {
+	_, err := db.Exec(
+		`DELETE FROM REFRESH_TOKEN
+		WHERE sessionid IN (
+			SELECT id FROM SESSION
+			WHERE userid = ? AND absolute_expires <= ?
+		)`,
+		userid, now)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		`DELETE FROM SESSION
+		WHERE userid = ? AND absolute_expires <= ?`,
+		userid, now)
+
+	return err
+}
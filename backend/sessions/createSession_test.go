@@ -0,0 +1,189 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// setExpiry overrides the idle and absolute expiry of the session with the
+// given token.
+func setExpiry(t *testing.T, db *sql.DB, token string, expires, absolute time.Time) {
+	t.Helper()
+
+	_, err := db.Exec(
+		`UPDATE SESSION SET expires = ?, absolute_expires = ? WHERE token = ?`,
+		expires, absolute, utils.HashToken(token))
+
+	if err != nil {
+		t.Fatalf("failed to set session expiry: %v", err)
+	}
+}
+
+func TestCreateSession(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	session, err := CreateSession(db, 1, false, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := session.SessionID
+	if len(token) < 32 || len(session.RefreshToken) < 32 {
+		t.Fatalf("Session tokens %q, %q are too short", token, session.RefreshToken)
+	}
+
+	if token == session.RefreshToken {
+		t.Fatal("Session and refresh token are the same!")
+	}
+
+	// Only the hash of the token may be stored.
+	var stored, ip, userAgent string
+	err = db.QueryRow(
+		`SELECT token, ip, user_agent
+		FROM SESSION WHERE userid = 1 ORDER BY id DESC LIMIT 1`).Scan(&stored, &ip, &userAgent)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored == token {
+		t.Fatal("Session token was stored in plain text!")
+	}
+
+	if stored != utils.HashToken(token) {
+		t.Fatalf("Stored hash %q does not match token", stored)
+	}
+
+	if ip != "127.0.0.1" || userAgent != "test-agent" {
+		t.Errorf("Client details were not recorded: %q, %q", ip, userAgent)
+	}
+
+	var refreshStored int
+	err = db.QueryRow(
+		`SELECT COUNT(*) FROM REFRESH_TOKEN WHERE token = ?`,
+		utils.HashToken(session.RefreshToken)).Scan(&refreshStored)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if refreshStored != 1 {
+		t.Fatal("Refresh token was not stored as a hash")
+	}
+
+	other, err := CreateSession(db, 1, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if other.SessionID == token {
+		t.Fatal("Two sessions received the same token!")
+	}
+}
+
+// TestCreateSessionTimeouts checks the expiry of regular and "remember me"
+// sessions.
+func TestCreateSessionTimeouts(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	defer func(idle, absolute, remember time.Duration) {
+		IdleTimeout, AbsoluteTimeout, RememberTimeout = idle, absolute, remember
+	}(IdleTimeout, AbsoluteTimeout, RememberTimeout)
+
+	IdleTimeout = time.Hour
+	AbsoluteTimeout = 8 * time.Hour
+	RememberTimeout = 48 * time.Hour
+
+	tests := []struct {
+		name         string
+		remember     bool
+		wantIdle     time.Duration
+		wantAbsolute time.Duration
+	}{
+		{
+			name:         "Regular session",
+			remember:     false,
+			wantIdle:     time.Hour,
+			wantAbsolute: 8 * time.Hour,
+		},
+		{
+			name:         "Remember me",
+			remember:     true,
+			wantIdle:     48 * time.Hour,
+			wantAbsolute: 48 * time.Hour,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before := time.Now()
+
+			session, err := CreateSession(db, 2, tc.remember, "", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			idle := session.Expires.Sub(before)
+			if idle < tc.wantIdle || idle > tc.wantIdle+time.Minute {
+				t.Errorf("expected idle timeout %s, got %s", tc.wantIdle, idle)
+			}
+
+			absolute := session.AbsoluteExpires.Sub(before)
+			if absolute < tc.wantAbsolute || absolute > tc.wantAbsolute+time.Minute {
+				t.Errorf("expected absolute timeout %s, got %s", tc.wantAbsolute, absolute)
+			}
+		})
+	}
+}
+
+// TestCreateSessionPurgesExpired checks that creating a session removes the
+// expired sessions of the same user.
+func TestCreateSessionPurgesExpired(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	old, err := CreateSession(db, 2, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	setExpiry(t, db, old.SessionID, past, past)
+
+	_, err = CreateSession(db, 2, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expired int
+	err = db.QueryRow(
+		`SELECT COUNT(*) FROM SESSION WHERE userid = 2 AND absolute_expires <= ?`,
+		time.Now()).Scan(&expired)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expired != 0 {
+		t.Errorf("Expected expired sessions to be removed, %d left", expired)
+	}
+
+	var refresh int
+	err = db.QueryRow(
+		`SELECT COUNT(*) FROM REFRESH_TOKEN WHERE token = ?`,
+		utils.HashToken(old.RefreshToken)).Scan(&refresh)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if refresh != 0 {
+		t.Errorf("Expected refresh token of expired session to be removed")
+	}
+}
@@ -0,0 +1,63 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrInvalidSession is returned when a session token does not belong to an
+// active session.
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+// GetSession resolves a session token to the active session it belongs to.
+// It records the current time as the last time the session was seen and
+// slides the idle expiry of the session forward.
+// Unknown and expired sessions result in ErrInvalidSession.
+func GetSession(db *sql.DB, token string) (*utils.Session, error) {
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+
+	now := time.Now()
+	session := &utils.Session{}
+	var remember bool
+	var absolute time.Time
+
+	err := db.QueryRow(
+		`SELECT id, userid, created, absolute_expires, remember, ip, user_agent
+		FROM SESSION
+		WHERE token = ? AND expires > ? AND absolute_expires > ?`,
+		utils.HashToken(token), now, now).Scan(
+			&session.ID,
+			&session.UserID,
+			&session.Created,
+			&absolute,
+			&remember,
+			&session.IP,
+			&session.UserAgent)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidSession
+		}
+		return nil, err
+	}
+
+	session.LastSeen = now
+	session.Expires = idleDeadline(now, remember, absolute)
+	session.Current = true
+
+	_, err = db.Exec(
+		`UPDATE SESSION SET last_seen = ?, expires = ? WHERE id = ?`,
+		session.LastSeen, session.Expires, session.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
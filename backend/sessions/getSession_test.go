@@ -0,0 +1,169 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestGetSession(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	session, err := CreateSession(db, 2, false, "10.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := session.SessionID
+
+	session, err = CreateSession(db, 2, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idle := session.SessionID
+	setExpiry(t, db, idle, time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+
+	session, err = CreateSession(db, 2, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := session.SessionID
+	setExpiry(t, db, expired, time.Now().Add(time.Hour), time.Now().Add(-time.Minute))
+
+	tests := []struct {
+		name    string
+		token   string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:  "Valid session",
+			token: valid,
+			want:  2,
+		},
+		{
+			name:  "Session from populate.sql",
+			token: "session-1",
+			want:  1,
+		},
+		{
+			name:    "Idle session",
+			token:   idle,
+			wantErr: true,
+		},
+		{
+			name:    "Past absolute expiry",
+			token:   expired,
+			wantErr: true,
+		},
+		{
+			name:    "Unknown token",
+			token:   "not-a-session",
+			wantErr: true,
+		},
+		{
+			name:    "Hash instead of token",
+			token:   utils.HashToken(valid),
+			wantErr: true,
+		},
+		{
+			name:    "Empty token",
+			token:   "",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			session, err := GetSession(db, tc.token)
+			if tc.wantErr {
+				if err != ErrInvalidSession {
+					t.Fatalf("expected ErrInvalidSession, got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("did not expect an error but got: %v", err)
+			}
+
+			if session.UserID != tc.want {
+				t.Errorf("got userid %d, want %d", session.UserID, tc.want)
+			}
+
+			if !session.Current {
+				t.Error("resolved session should be marked as current")
+			}
+		})
+	}
+}
+
+// TestGetSessionLastSeen checks that resolving a session refreshes its
+// last-seen time.
+func TestGetSessionLastSeen(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	before := time.Now()
+
+	session, err := GetSession(db, "session-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lastSeen time.Time
+	err = db.QueryRow(
+		`SELECT last_seen FROM SESSION WHERE id = ?`,
+		session.ID).Scan(&lastSeen)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lastSeen.Before(before.Add(-time.Second)) {
+		t.Errorf("last_seen was not updated: %v", lastSeen)
+	}
+}
+
+// TestGetSessionSliding checks that activity extends the idle expiry of a
+// session, but never past its absolute expiry.
+func TestGetSessionSliding(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	defer func(idle time.Duration) { IdleTimeout = idle }(IdleTimeout)
+	IdleTimeout = time.Hour
+
+	created, err := CreateSession(db, 3, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	soon := time.Now().Add(time.Minute)
+	absolute := time.Now().Add(2 * time.Hour)
+	setExpiry(t, db, created.SessionID, soon, absolute)
+
+	session, err := GetSession(db, created.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !session.Expires.After(soon.Add(30 * time.Minute)) {
+		t.Errorf("idle expiry did not slide forward: %v", session.Expires)
+	}
+
+	// Close to the absolute expiry the idle expiry is capped.
+	absolute = time.Now().Add(10 * time.Minute)
+	setExpiry(t, db, created.SessionID, soon, absolute)
+
+	session, err = GetSession(db, created.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session.Expires.After(absolute) {
+		t.Errorf("idle expiry %v exceeds absolute expiry %v", session.Expires, absolute)
+	}
+}
@@ -0,0 +1,47 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// GetUserSessions returns the sessions of the user which have not reached
+// their absolute expiry, most recently seen first. Sessions that expired from
+// inactivity are included since they can still be refreshed.
+func GetUserSessions(db *sql.DB, userid int) ([]utils.Session, error) {
+	rows, err := db.Query(
+		`SELECT id, userid, created, last_seen, expires, ip, user_agent
+		FROM SESSION
+		WHERE userid = ? AND absolute_expires > ?
+		ORDER BY last_seen DESC, id DESC`,
+		userid, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []utils.Session{}
+	for rows.Next() {
+		var session utils.Session
+		err = rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.Created,
+			&session.LastSeen,
+			&session.Expires,
+			&session.IP,
+			&session.UserAgent)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
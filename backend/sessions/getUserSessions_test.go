@@ -0,0 +1,57 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestGetUserSessions(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// John Doe (userid 1) has two sessions in populate.sql
+	_, err := CreateSession(db, 1, false, "192.168.0.1", "Firefox")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := CreateSession(db, 1, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	setExpiry(t, db, expired.SessionID, past, past)
+
+	sessions, err := GetUserSessions(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 3 {
+		t.Fatalf("expected 3 active sessions, got %d", len(sessions))
+	}
+
+	for _, session := range sessions {
+		if session.UserID != 1 {
+			t.Errorf("session %d belongs to user %d", session.ID, session.UserID)
+		}
+	}
+
+	// The new session was seen most recently
+	if sessions[0].IP != "192.168.0.1" || sessions[0].UserAgent != "Firefox" {
+		t.Errorf("unexpected first session: %+v", sessions[0])
+	}
+
+	// Users without sessions get an empty list
+	sessions, err = GetUserSessions(db, 999)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 0 {
+		t.Errorf("expected no sessions, got %d", len(sessions))
+	}
+}
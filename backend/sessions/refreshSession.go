@@ -0,0 +1,130 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+var (
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown or
+	// its session reached its absolute expiry.
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	// ErrRefreshTokenReused is returned when a refresh token that was already
+	// exchanged is presented again. The token was most likely stolen, so the
+	// whole session is revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reused, session revoked")
+)
+
+// RefreshSession exchanges a refresh token for a new session token and a new
+// refresh token. The old refresh token is spent and the idle expiry of the
+// session is reset. The absolute expiry of the session is never extended.
+func RefreshSession(db *sql.DB, refreshToken string) (*utils.SessionData, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	session := &utils.SessionData{}
+	var tokenid, sessionid int
+	var used bool
+
+	err := db.QueryRow(
+		`SELECT rt.id, rt.used, s.id, s.userid, s.remember, s.absolute_expires
+		FROM REFRESH_TOKEN rt
+		JOIN SESSION s ON rt.sessionid = s.id
+		WHERE rt.token = ?`,
+		utils.HashToken(refreshToken)).Scan(
+			&tokenid,
+			&used,
+			&sessionid,
+			&session.UserID,
+			&session.Remember,
+			&session.AbsoluteExpires)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if used {
+		err = RevokeSession(db, session.UserID, sessionid)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if !session.AbsoluteExpires.After(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session.SessionID, err = utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session.RefreshToken, err = utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session.Expires = idleDeadline(now, session.Remember, session.AbsoluteExpires)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Spending the token only succeeds once, even for concurrent requests.
+	res, err := tx.Exec(
+		`UPDATE REFRESH_TOKEN SET used = 1
+		WHERE id = ? AND used = 0`,
+		tokenid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if affected == 0 {
+		return nil, ErrRefreshTokenReused
+	}
+
+	_, err = tx.Exec(
+		`UPDATE SESSION
+		SET token = ?, expires = ?, last_seen = ?
+		WHERE id = ?`,
+		utils.HashToken(session.SessionID), session.Expires, now, sessionid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO REFRESH_TOKEN (sessionid, token)
+		VALUES (?, ?)`,
+		sessionid, utils.HashToken(session.RefreshToken))
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
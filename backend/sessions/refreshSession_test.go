@@ -0,0 +1,93 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRefreshSession(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	created, err := CreateSession(db, 2, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The session expired from inactivity but can still be refreshed
+	setExpiry(t, db, created.SessionID, time.Now().Add(-time.Minute), created.AbsoluteExpires)
+
+	refreshed, err := RefreshSession(db, created.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshSession returned error: %v", err)
+	}
+
+	if refreshed.SessionID == created.SessionID || refreshed.RefreshToken == created.RefreshToken {
+		t.Fatal("tokens were not rotated")
+	}
+
+	if refreshed.UserID != 2 {
+		t.Errorf("expected userid 2, got %d", refreshed.UserID)
+	}
+
+	if !refreshed.AbsoluteExpires.Equal(created.AbsoluteExpires) {
+		t.Errorf("absolute expiry changed from %v to %v", created.AbsoluteExpires, refreshed.AbsoluteExpires)
+	}
+
+	if _, err = GetSession(db, created.SessionID); err != ErrInvalidSession {
+		t.Errorf("old session token is still valid: %v", err)
+	}
+
+	if _, err = GetSession(db, refreshed.SessionID); err != nil {
+		t.Errorf("new session token is invalid: %v", err)
+	}
+
+	// Presenting the spent token again revokes the whole session
+	_, err = RefreshSession(db, created.RefreshToken)
+	if err != ErrRefreshTokenReused {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	if _, err = GetSession(db, refreshed.SessionID); err != ErrInvalidSession {
+		t.Errorf("session survived refresh token reuse: %v", err)
+	}
+
+	if _, err = RefreshSession(db, refreshed.RefreshToken); err != ErrInvalidRefreshToken {
+		t.Errorf("refresh token survived reuse detection: %v", err)
+	}
+}
+
+func TestRefreshSessionInvalid(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	created, err := CreateSession(db, 2, false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	setExpiry(t, db, created.SessionID, past, past)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "Past absolute expiry", token: created.RefreshToken},
+		{name: "Session token instead of refresh token", token: created.SessionID},
+		{name: "Unknown token", token: "not-a-token"},
+		{name: "Empty token", token: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := RefreshSession(db, tc.token)
+			if err != ErrInvalidRefreshToken {
+				t.Errorf("expected ErrInvalidRefreshToken, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package sessions
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// RevokeOtherSessions ends every session of the user except the one with the
+// given ID, which is usually the session the request was made with.
+func RevokeOtherSessions(db *sql.DB, userid int, sessionid int) error {
+	_, err := db.Exec(
+		`DELETE FROM REFRESH_TOKEN
+		WHERE sessionid IN (
+			SELECT id FROM SESSION
+			WHERE userid = ? AND id != ?
+		)`,
+		userid, sessionid)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		`DELETE FROM SESSION
+		WHERE userid = ? AND id != ?`,
+		userid, sessionid)
+
+	return err
+}
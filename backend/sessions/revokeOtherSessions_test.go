@@ -0,0 +1,37 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRevokeOtherSessions(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// "session-1" and "session-4" both belong to John Doe (userid 1)
+	current, err := GetSession(db, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = RevokeOtherSessions(db, 1, current.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = GetSession(db, "session-1"); err != nil {
+		t.Errorf("current session was revoked: %v", err)
+	}
+
+	if _, err = GetSession(db, "session-4"); err != ErrInvalidSession {
+		t.Errorf("other session is still valid: %v", err)
+	}
+
+	// Sessions of other users are untouched
+	if _, err = GetSession(db, "session-2"); err != nil {
+		t.Errorf("session of another user was revoked: %v", err)
+	}
+}
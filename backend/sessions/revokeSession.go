@@ -0,0 +1,40 @@
+package sessions
+
+import (
+	"database/sql"
+	"errors"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrSessionNotFound is returned when a session to revoke does not exist or
+// belongs to another user.
+var ErrSessionNotFound = errors.New("session not found")
+
+// RevokeSession ends the session with the given ID. Users can only revoke
+// their own sessions.
+func RevokeSession(db *sql.DB, userid int, sessionid int) error {
+	res, err := db.Exec(
+		`DELETE FROM SESSION
+		WHERE id = ? AND userid = ?`,
+		sessionid, userid)
+
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+
+	_, err = db.Exec(
+		`DELETE FROM REFRESH_TOKEN WHERE sessionid = ?`,
+		sessionid)
+
+	return err
+}
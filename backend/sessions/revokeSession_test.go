@@ -0,0 +1,40 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRevokeSession(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	session, err := GetSession(db, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Jane Smith (userid 2) cannot revoke a session of John Doe
+	err = RevokeSession(db, 2, session.ID)
+	if err != ErrSessionNotFound {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	err = RevokeSession(db, 1, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = GetSession(db, "session-1")
+	if err != ErrInvalidSession {
+		t.Fatalf("revoked session is still valid: %v", err)
+	}
+
+	// Revoking twice fails
+	err = RevokeSession(db, 1, session.ID)
+	if err != ErrSessionNotFound {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package sessions
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// execer is implemented by *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// RevokeUserSessions ends every session of the user, e.g. after their
+// password was reset. It can be part of a transaction.
+func RevokeUserSessions(db execer, userid int) error {
+	_, err := db.Exec(
+		`DELETE FROM REFRESH_TOKEN
+		WHERE sessionid IN (
+			SELECT id FROM SESSION WHERE userid = ?
+		)`,
+		userid)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		`DELETE FROM SESSION WHERE userid = ?`,
+		userid)
+
+	return err
+}
@@ -0,0 +1,29 @@
+package sessions
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRevokeUserSessions(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// "session-1" and "session-4" both belong to John Doe (userid 1)
+	err := RevokeUserSessions(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"session-1", "session-4"} {
+		if _, err = GetSession(db, token); err != ErrInvalidSession {
+			t.Errorf("%s is still valid: %v", token, err)
+		}
+	}
+
+	if _, err = GetSession(db, "session-2"); err != nil {
+		t.Errorf("session of another user was revoked: %v", err)
+	}
+}
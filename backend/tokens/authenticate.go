@@ -0,0 +1,57 @@
+package tokens
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+var (
+	// ErrInvalidToken is returned for unknown, revoked and expired tokens.
+	ErrInvalidToken = errors.New("invalid or expired token")
+
+	// ErrInsufficientScope is returned when a valid token lacks the scope
+	// an endpoint requires.
+	ErrInsufficientScope = errors.New("token lacks the scope required by this endpoint")
+)
+
+// Authenticate resolves a personal access token to the token it belongs to
+// and checks that it grants `scope`. An empty scope is granted by no token.
+// The current time is recorded as the last time the token was used.
+func Authenticate(db *sql.DB, token string, scope string) (*utils.APIToken, error) {
+	if token == "" {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	apiToken, err := scanToken(db.QueryRow(
+		`SELECT id, userid, name, scopes, created, expires, last_used
+		FROM API_TOKEN
+		WHERE token = ? AND expires > ?`,
+		utils.HashToken(token), now))
+
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !slices.Contains(apiToken.Scopes, scope) {
+		return nil, ErrInsufficientScope
+	}
+
+	apiToken.LastUsed = &now
+	_, err = db.Exec(
+		`UPDATE API_TOKEN SET last_used = ? WHERE id = ?`,
+		now, apiToken.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return apiToken, nil
+}
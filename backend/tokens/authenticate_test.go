@@ -0,0 +1,54 @@
+package tokens
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestAuthenticate(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	token, err := CreateToken(db, 3, "bot", []string{ScopeIssuesRead, ScopeIssuesWrite}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	apiToken, err := Authenticate(db, token.Token, ScopeIssuesWrite)
+	if err != nil {
+		t.Fatalf("Authenticate returned error: %v", err)
+	}
+	if apiToken.UserID != 3 || apiToken.ID != token.ID {
+		t.Errorf("expected token %d of user 3, got %+v", token.ID, apiToken)
+	}
+
+	var lastUsed *time.Time
+	err = db.QueryRow("SELECT last_used FROM API_TOKEN WHERE id = ?", token.ID).Scan(&lastUsed)
+	if err != nil || lastUsed == nil {
+		t.Errorf("expected last use to be recorded, got %v (%v)", lastUsed, err)
+	}
+
+	if _, err := Authenticate(db, token.Token, ScopeProjectsAdmin); err != ErrInsufficientScope {
+		t.Errorf("expected ErrInsufficientScope, got %v", err)
+	}
+	if _, err := Authenticate(db, token.Token, ""); err != ErrInsufficientScope {
+		t.Errorf("expected endpoints without scope to be refused, got %v", err)
+	}
+
+	for _, invalid := range []string{"", "bu_unknown", utils.HashToken(token.Token)} {
+		if _, err := Authenticate(db, invalid, ScopeIssuesRead); err != ErrInvalidToken {
+			t.Errorf("Authenticate(%q): expected ErrInvalidToken, got %v", invalid, err)
+		}
+	}
+
+	_, err = db.Exec("UPDATE API_TOKEN SET expires = ? WHERE id = ?", time.Now().Add(-time.Minute), token.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Authenticate(db, token.Token, ScopeIssuesRead); err != ErrInvalidToken {
+		t.Errorf("expected expired token to be invalid, got %v", err)
+	}
+}
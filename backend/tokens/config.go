@@ -0,0 +1,13 @@
+package tokens
+
+const (
+	// tokenPrefix starts every token, so leaked tokens are easy to
+	// recognize, e.g. by secret scanners.
+	tokenPrefix = "bu_"
+
+	// DefaultLifetimeDays is how long tokens are valid if no expiry is given.
+	DefaultLifetimeDays = 30
+
+	// MaxLifetimeDays is the longest a token can be valid.
+	MaxLifetimeDays = 365
+)
@@ -0,0 +1,93 @@
+package tokens
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrTokenNameTaken is returned when the user already has a token of that name.
+var ErrTokenNameTaken = errors.New("a token with this name already exists")
+
+// CreateToken creates a personal access token for the user, named `name`,
+// granting `scopes` and expiring after `days` days.
+// The returned token is the only copy of it; only its hash is stored.
+func CreateToken(db *sql.DB, userid int, name string, scopes []string, days int) (*utils.NewAPIToken, error) {
+	v := validate.New()
+	v.Line("name", name, validate.MaxNameLength)
+	if len(scopes) == 0 {
+		v.Fail("scopes", validate.CodeRequired, "is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			v.Fail("scopes", validate.CodeInvalid, "has unknown scope %q, expected any of %s", scope, strings.Join(Scopes, ", "))
+		}
+	}
+	if days < 1 || days > MaxLifetimeDays {
+		v.Fail("expires_in_days", validate.CodeInvalid, "must be between 1 and %d", MaxLifetimeDays)
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err := db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM API_TOKEN WHERE userid = ? AND name = ?)`,
+		userid, name).Scan(&exists)
+
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrTokenNameTaken
+	}
+
+	secret, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// Store the scopes deduplicated and in a fixed order
+	var granted []string
+	for _, scope := range Scopes {
+		if slices.Contains(scopes, scope) {
+			granted = append(granted, scope)
+		}
+	}
+
+	now := time.Now()
+	token := &utils.NewAPIToken{
+		APIToken: utils.APIToken{
+			UserID:  userid,
+			Name:    name,
+			Scopes:  granted,
+			Created: now,
+			Expires: now.AddDate(0, 0, days),
+		},
+		Token: tokenPrefix + secret,
+	}
+
+	res, err := db.Exec(
+		`INSERT INTO API_TOKEN (userid, name, token, scopes, created, expires)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		userid, name, utils.HashToken(token.Token), strings.Join(granted, " "),
+		token.Created, token.Expires)
+
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	token.ID = int(id)
+
+	return token, nil
+}
@@ -0,0 +1,89 @@
+package tokens
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestCreateToken(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	token, err := CreateToken(db, 1, "CI", []string{ScopeIssuesWrite, ScopeIssuesRead, ScopeIssuesRead}, 7)
+	if err != nil {
+		t.Fatalf("CreateToken returned error: %v", err)
+	}
+
+	if !strings.HasPrefix(token.Token, tokenPrefix) {
+		t.Errorf("expected token to start with %q, got %q", tokenPrefix, token.Token)
+	}
+	if !slices.Equal(token.Scopes, []string{ScopeIssuesRead, ScopeIssuesWrite}) {
+		t.Errorf("expected deduplicated scopes in order, got %v", token.Scopes)
+	}
+	if d := time.Until(token.Expires); d < 6*24*time.Hour || d > 7*24*time.Hour {
+		t.Errorf("expected token to expire in 7 days, got %v", token.Expires)
+	}
+
+	// Only the hash is stored
+	var stored, scopes string
+	err = db.QueryRow("SELECT token, scopes FROM API_TOKEN WHERE id = ?", token.ID).Scan(&stored, &scopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored != utils.HashToken(token.Token) {
+		t.Errorf("expected the token hash to be stored, got %q", stored)
+	}
+	if scopes != "issues:read issues:write" {
+		t.Errorf("unexpected stored scopes %q", scopes)
+	}
+
+	_, err = CreateToken(db, 1, "CI", []string{ScopeIssuesRead}, 7)
+	if err != ErrTokenNameTaken {
+		t.Errorf("expected ErrTokenNameTaken, got %v", err)
+	}
+
+	// Names are per user
+	_, err = CreateToken(db, 2, "CI", []string{ScopeIssuesRead}, 7)
+	if err != nil {
+		t.Errorf("expected another user to reuse the name, got %v", err)
+	}
+}
+
+func TestCreateTokenInvalid(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	tests := []struct {
+		name   string
+		scopes []string
+		days   int
+		field  string
+	}{
+		{"", []string{ScopeIssuesRead}, 7, "name"},
+		{"bot", nil, 7, "scopes"},
+		{"bot", []string{"issues:delete"}, 7, "scopes"},
+		{"bot", []string{ScopeIssuesRead}, 0, "expires_in_days"},
+		{"bot", []string{ScopeIssuesRead}, MaxLifetimeDays + 1, "expires_in_days"},
+	}
+	for _, tt := range tests {
+		_, err := CreateToken(db, 1, tt.name, tt.scopes, tt.days)
+		var errs validate.Errors
+		if !errors.As(err, &errs) || errs[0].Field != tt.field {
+			t.Errorf("CreateToken(%q, %v, %d): expected error for %s, got %v", tt.name, tt.scopes, tt.days, tt.field, err)
+		}
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	got := ParseScopes("issues:read, issues:write projects:admin,")
+	if !slices.Equal(got, Scopes) {
+		t.Errorf("unexpected scopes %v", got)
+	}
+}
@@ -0,0 +1,69 @@
+package tokens
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanToken reads a token selected as id, userid, name, scopes, created,
+// expires, last_used.
+func scanToken(row scanner) (*utils.APIToken, error) {
+	var token utils.APIToken
+	var scopes string
+	var lastUsed sql.NullTime
+
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&scopes,
+		&token.Created,
+		&token.Expires,
+		&lastUsed)
+
+	if err != nil {
+		return nil, err
+	}
+
+	token.Scopes = strings.Fields(scopes)
+	if lastUsed.Valid {
+		token.LastUsed = &lastUsed.Time
+	}
+
+	return &token, nil
+}
+
+// GetUserTokens returns the personal access tokens of the user, newest
+// first. Expired tokens are included until they are revoked.
+func GetUserTokens(db *sql.DB, userid int) ([]utils.APIToken, error) {
+	rows, err := db.Query(
+		`SELECT id, userid, name, scopes, created, expires, last_used
+		FROM API_TOKEN
+		WHERE userid = ?
+		ORDER BY created DESC, id DESC`,
+		userid)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []utils.APIToken{}
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, rows.Err()
+}
@@ -0,0 +1,47 @@
+package tokens
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestGetUserTokens(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	tokens, err := GetUserTokens(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 0 {
+		t.Fatalf("expected no tokens, got %v", tokens)
+	}
+
+	first, _ := CreateToken(db, 1, "first", []string{ScopeIssuesRead}, 1)
+	second, _ := CreateToken(db, 1, "second", []string{ScopeProjectsAdmin}, 1)
+	CreateToken(db, 2, "other", []string{ScopeIssuesRead}, 1)
+
+	_, err = Authenticate(db, first.Token, ScopeIssuesRead)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err = GetUserTokens(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[0].ID != second.ID || tokens[1].ID != first.ID {
+		t.Fatalf("expected the two tokens of the user, newest first, got %+v", tokens)
+	}
+	if tokens[0].LastUsed != nil {
+		t.Errorf("expected unused token to have no last use, got %v", tokens[0].LastUsed)
+	}
+	if tokens[1].LastUsed == nil {
+		t.Errorf("expected used token to have a last use")
+	}
+	if tokens[1].Name != "first" || len(tokens[1].Scopes) != 1 || tokens[1].Scopes[0] != ScopeIssuesRead {
+		t.Errorf("unexpected token %+v", tokens[1])
+	}
+}
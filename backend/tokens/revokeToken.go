@@ -0,0 +1,32 @@
+package tokens
+
+import (
+	"database/sql"
+	"errors"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrTokenNotFound is returned when the user has no token with the given ID.
+var ErrTokenNotFound = errors.New("token not found")
+
+// RevokeToken deletes the personal access token `id` of the user.
+func RevokeToken(db *sql.DB, userid int, id int) error {
+	res, err := db.Exec(
+		`DELETE FROM API_TOKEN WHERE id = ? AND userid = ?`,
+		id, userid)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
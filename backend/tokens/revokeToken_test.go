@@ -0,0 +1,35 @@
+package tokens
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRevokeToken(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	token, err := CreateToken(db, 1, "bot", []string{ScopeIssuesRead}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Users can only revoke their own tokens
+	if err := RevokeToken(db, 2, token.ID); err != ErrTokenNotFound {
+		t.Errorf("expected ErrTokenNotFound, got %v", err)
+	}
+
+	if err := RevokeToken(db, 1, token.ID); err != nil {
+		t.Fatalf("RevokeToken returned error: %v", err)
+	}
+
+	if _, err := Authenticate(db, token.Token, ScopeIssuesRead); err != ErrInvalidToken {
+		t.Errorf("expected revoked token to be invalid, got %v", err)
+	}
+
+	if err := RevokeToken(db, 1, token.ID); err != ErrTokenNotFound {
+		t.Errorf("expected ErrTokenNotFound, got %v", err)
+	}
+}
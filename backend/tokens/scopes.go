@@ -0,0 +1,32 @@
+// Package tokens manages personal access tokens, which let scripts and bots
+// use the API on behalf of a user without a login session.
+//
+// Tokens are sent as `Authorization: Bearer <token>` and only grant access
+// to the endpoints covered by their scopes. Within those endpoints, the
+// project and organization roles of the user apply as usual.
+package tokens
+
+import "strings"
+
+// Scopes a token can be granted.
+const (
+	// ScopeIssuesRead allows reading projects, their issues and tags.
+	ScopeIssuesRead = "issues:read"
+
+	// ScopeIssuesWrite allows creating and updating issues and tags.
+	ScopeIssuesWrite = "issues:write"
+
+	// ScopeProjectsAdmin allows creating, updating and archiving projects
+	// and managing their members.
+	ScopeProjectsAdmin = "projects:admin"
+)
+
+// Scopes lists every scope in the order they are stored and shown in.
+var Scopes = []string{ScopeIssuesRead, ScopeIssuesWrite, ScopeProjectsAdmin}
+
+// ParseScopes splits a list of scopes separated by commas or whitespace.
+func ParseScopes(list string) []string {
+	return strings.FieldsFunc(list, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
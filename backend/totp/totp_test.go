@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"bytes"
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the test vectors in RFC 6238, appendix B.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).
+	EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// The last 6 digits of the 8 digit codes in RFC 6238
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, want := range vectors {
+		got, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != want {
+			t.Errorf("T=%d: got %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code, _ := Code(secret, Step(now.Add(-Period)))
+
+	step, ok := Validate(secret, code, now)
+	if !ok || step != Step(now)-1 {
+		t.Errorf("code of the previous step was refused")
+	}
+
+	if _, ok := Validate(secret, code, now.Add(3*Period)); ok {
+		t.Errorf("stale code was accepted")
+	}
+
+	for _, bad := range []string{"", "12345", "abcdef", "1234567"} {
+		if _, ok := Validate(secret, bad, now); ok {
+			t.Errorf("malformed code %q was accepted", bad)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("Bricked Up", "john.doe@example.com", "SECRET")
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Scheme != "otpauth" || parsed.Host != "totp" {
+		t.Errorf("unexpected URI %s", uri)
+	}
+
+	if !strings.HasSuffix(parsed.Path, "Bricked Up:john.doe@example.com") {
+		t.Errorf("unexpected label %q", parsed.Path)
+	}
+
+	if parsed.Query().Get("secret") != "SECRET" || parsed.Query().Get("issuer") != "Bricked Up" {
+		t.Errorf("unexpected parameters %q", parsed.RawQuery)
+	}
+
+	png, err := QRCode(uri, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Error("QR code is not a PNG")
+	}
+}
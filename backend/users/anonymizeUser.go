@@ -0,0 +1,75 @@
+package users
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// deletedUserName replaces the name of anonymized users.
+const deletedUserName = "Deleted user"
+
+// anonymizeUser erases the personal data of the user in one transaction.
+// The USER row stays, scrubbed and named deletedUserName, so that the
+// issues they worked on and the invitations they had accepted remain
+// attributed to someone. Its email becomes a placeholder that cannot be
+// registered, and the account cannot be logged into anymore.
+// It refuses with ErrLastAdmin while the user is the only admin of an
+// organization.
+func anonymizeUser(db *sql.DB, userid int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.QueryRow(`SELECT email FROM USER WHERE id = ?`, userid).Scan(&email)
+	if err != nil {
+		return err
+	}
+
+	org, err := lastAdminOf(tx, userid)
+	if err != nil {
+		return err
+	}
+	if org != "" {
+		return fmt.Errorf("%w: %s", ErrLastAdmin, org)
+	}
+
+	keys, err := eraseUser(tx, userid)
+	if err != nil {
+		return err
+	}
+
+	// Invitations still open would be sent in the name of nobody, and the
+	// ones addressed to the user hold their email
+	_, err = tx.Exec(
+		`DELETE FROM INVITATION
+		WHERE (inviter = ? AND uses < max_uses) OR email = ?`,
+		userid, email)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE USER
+		SET email = ?, name = ?, password = '', legacy_password = 0,
+			avatar = NULL, totp_secret = NULL, totp_enabled = 0, totp_last_step = 0,
+			anonymized = 1
+		WHERE id = ?`,
+		fmt.Sprintf("deleted-user-%d", userid), deletedUserName, userid)
+
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	deleteBlobs(keys)
+	return nil
+}
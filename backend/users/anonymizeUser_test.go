@@ -0,0 +1,89 @@
+package users
+
+import (
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/utils"
+	"errors"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestAnonymizeUser(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+
+	// An open invitation and an accepted one sent by Mike, and one to him
+	now := time.Now()
+	_, err := db.Exec(
+		`INSERT INTO INVITATION (kind, targetid, roleid, email, token, inviter, uses, created, expires) VALUES
+		('org', 1, 3, 'new@example.com', 'open', 3, 0, ?, ?),
+		('org', 1, 3, 'jane.smith@example.com', 'accepted', 3, 1, ?, ?),
+		('org', 3, 7, 'mike.johnson@example.com', 'received', 5, 0, ?, ?)`,
+		now, now.Add(time.Hour), now, now.Add(time.Hour), now, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Attempts recorded by email alone, like requests for reset links
+	err = recordLoginAttempt(db, methodPasswordReset, "Mike.Johnson@example.com", 0, "10.0.0.3", "", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = anonymizeUser(db, 3); err != nil {
+		t.Fatalf("anonymizeUser returned error: %v", err)
+	}
+
+	var email, name, password string
+	var anonymized bool
+	err = db.QueryRow(
+		`SELECT email, name, password, anonymized FROM USER WHERE id = 3`).Scan(&email, &name, &password, &anonymized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email != "deleted-user-3" || name != deletedUserName || password != "" || !anonymized {
+		t.Errorf("expected the user to be scrubbed, got %s, %s, %q, %v", email, name, password, anonymized)
+	}
+
+	// The work stays attributed, the memberships go
+	var issues, memberships, invitations int
+	err = db.QueryRow(
+		`SELECT (SELECT COUNT(*) FROM USER_ISSUES WHERE userid = 3),
+			(SELECT COUNT(*) FROM ORG_MEMBER WHERE userid = 3) +
+				(SELECT COUNT(*) FROM PROJECT_MEMBER WHERE userid = 3),
+			(SELECT COUNT(*) FROM INVITATION)`).Scan(&issues, &memberships, &invitations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues != 2 {
+		t.Errorf("expected the issues of the user to stay assigned, got %d", issues)
+	}
+	if memberships != 0 {
+		t.Errorf("expected the memberships to be removed, got %d", memberships)
+	}
+	if invitations != 1 {
+		t.Errorf("expected only the accepted invitation to stay, got %d", invitations)
+	}
+
+	var attempts int
+	db.QueryRow(`SELECT COUNT(*) FROM LOGIN_ATTEMPT WHERE email = 'mike.johnson@example.com'`).Scan(&attempts)
+	if attempts != 0 {
+		t.Errorf("expected the login attempts by email to be erased, got %d", attempts)
+	}
+
+	if _, _, err = Login(db, "mike.johnson@example.com", "hashed_password_3", false, "", ""); err != ErrInvalidCredentials {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestAnonymizeUserLastAdmin(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	if err := anonymizeUser(db, 1); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected ErrLastAdmin, got %v", err)
+	}
+}
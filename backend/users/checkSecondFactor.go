@@ -0,0 +1,55 @@
+package users
+
+import (
+	"brickedup/backend/totp"
+	"brickedup/backend/utils"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// checkSecondFactor reports whether `code` is a valid TOTP code or an unused
+// recovery code of the user. Each TOTP code and recovery code is accepted
+// only once.
+func checkSecondFactor(db *sql.DB, userid int, code string) (bool, error) {
+	var secret sql.NullString
+	var lastStep int64
+	err := db.QueryRow(
+		`SELECT totp_secret, totp_last_step FROM USER
+		WHERE id = ? AND totp_enabled = 1`,
+		userid).Scan(&secret, &lastStep)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	step, ok := totp.Validate(secret.String, code, time.Now())
+	if ok && step > lastStep {
+		// Only one of concurrent logins with the same code moves the step on
+		res, err := db.Exec(
+			`UPDATE USER SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?`,
+			step, userid, step)
+
+		if err != nil {
+			return false, err
+		}
+
+		n, err := res.RowsAffected()
+		return n == 1, err
+	}
+
+	res, err := db.Exec(
+		`UPDATE RECOVERY_CODE SET used = 1
+		WHERE userid = ? AND code = ? AND used = 0`,
+		userid, utils.HashToken(normalizeRecoveryCode(code)))
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
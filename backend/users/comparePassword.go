@@ -0,0 +1,53 @@
+package users
+
+import (
+	"brickedup/backend/passwords"
+	"brickedup/backend/utils"
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// comparePassword reports whether `password` matches the stored hash of the
+// user. Accounts with `legacy` set have passwords that were stripped of some
+// characters before hashing; they are compared the old way as well.
+// Accounts created through single sign-on have no password and never match.
+// Once the password matches, it is rehashed exactly as entered if the hash
+// is legacy or was made with another algorithm or other parameters than
+// passwords.Default uses.
+func comparePassword(db *sql.DB, userid int, hash string, legacy bool, password string) (bool, error) {
+	if hash == "" {
+		passwords.Verify(dummyHash(), password)
+		return false, nil
+	}
+
+	matches, err := passwords.Verify(hash, password)
+	if err != nil {
+		return false, err
+	}
+
+	if !matches && legacy {
+		stripped := utils.SanitizeText(password, utils.PASSWORD)
+		if stripped != password {
+			matches, err = passwords.Verify(hash, stripped)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if !matches || !(legacy || passwords.NeedsRehash(hash)) {
+		return matches, nil
+	}
+
+	rehashed, err := passwords.Hash(password)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = db.Exec(
+		`UPDATE USER SET password = ?, legacy_password = 0 WHERE id = ?`,
+		rehashed, userid)
+
+	return err == nil, err
+}
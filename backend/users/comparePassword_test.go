@@ -0,0 +1,77 @@
+package users
+
+import (
+	"brickedup/backend/passwords"
+	"brickedup/backend/utils"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	_ "modernc.org/sqlite"
+)
+
+// TestComparePasswordLegacy checks that passwords hashed after sanitization
+// still match and are rehashed exactly as entered.
+func TestComparePasswordLegacy(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	password := "pa$$-word 42"
+	stripped := utils.SanitizeText(password, utils.PASSWORD)
+	hash, err := bcrypt.GenerateFromPassword([]byte(stripped), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("UPDATE USER SET password = ?, legacy_password = 1 WHERE id = 1", string(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := comparePassword(db, 1, string(hash), true, password)
+	if err != nil || !ok {
+		t.Fatalf("expected legacy password to match, got %v, %v", ok, err)
+	}
+
+	var newHash string
+	var legacy bool
+	err = db.QueryRow("SELECT password, legacy_password FROM USER WHERE id = 1").Scan(&newHash, &legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy {
+		t.Error("expected legacy_password to be cleared")
+	}
+	if ok, _ := passwords.Verify(newHash, password); !ok || passwords.NeedsRehash(newHash) {
+		t.Error("expected the password to be rehashed as entered")
+	}
+
+	ok, err = comparePassword(db, 1, newHash, false, stripped)
+	if err != nil || ok {
+		t.Errorf("expected stripped password not to match anymore, got %v, %v", ok, err)
+	}
+}
+
+// TestLoginRehash checks that hashes made with other parameters are
+// replaced on login.
+func TestLoginRehash(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, _, err := Login(db, "john.doe@example.com", "hashed_password_1", false, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var hash string
+	err = db.QueryRow("SELECT password FROM USER WHERE id = 1").Scan(&hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passwords.NeedsRehash(hash) {
+		t.Errorf("expected the bcrypt hash to be replaced, got %q", hash)
+	}
+
+	_, _, err = Login(db, "john.doe@example.com", "hashed_password_1", false, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Errorf("expected login with the new hash to succeed, got %v", err)
+	}
+}
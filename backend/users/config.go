@@ -0,0 +1,119 @@
+package users
+
+import (
+	"brickedup/backend/utils"
+	"time"
+)
+
+// verificationLifetime is how long a verification link stays valid.
+const verificationLifetime = 24 * time.Hour
+
+// Lifecycle of unverified accounts. Both are read from the environment on
+// startup and fall back to the defaults below.
+var (
+	// ResendInterval is the minimum time between two verification emails
+	// to the same account.
+	ResendInterval = utils.DurationFromEnv("VERIFY_RESEND_INTERVAL", time.Minute)
+
+	// UnverifiedRetentionDays is after how many days accounts that were
+	// never verified are purged. Zero keeps them forever.
+	UnverifiedRetentionDays = utils.IntFromEnv("UNVERIFIED_RETENTION_DAYS", 7)
+)
+
+// Deactivated accounts can be reactivated during a grace period, after
+// which their personal data is erased.
+var (
+	// DeactivationGraceDays is how many days deactivated accounts are kept
+	// before they are anonymized.
+	DeactivationGraceDays = utils.IntFromEnv("DEACTIVATION_GRACE_DAYS", 30)
+)
+
+// reactivationInterval is the minimum time between two reactivation emails
+// to the same account.
+const reactivationInterval = time.Minute
+
+// Pages of user search results. The default is read from the environment
+// on startup.
+var (
+	// SearchPageSize is how many users a page holds unless the search asks
+	// for another size, up to maxSearchPageSize.
+	SearchPageSize = utils.IntFromEnv("USER_SEARCH_PAGE_SIZE", 20)
+)
+
+// maxSearchPageSize is the largest page of users a search can ask for.
+const maxSearchPageSize = 100
+
+// emailChangeLifetime is how long the link confirming a new email address
+// stays valid.
+const emailChangeLifetime = 24 * time.Hour
+
+// Passwordless login by email.
+const (
+	// magicLinkLifetime is how long a login link stays valid.
+	magicLinkLifetime = 10 * time.Minute
+
+	// magicLinkInterval is the minimum time between two login links sent
+	// to the same address.
+	magicLinkInterval = time.Minute
+)
+
+// ResetInterval is the minimum time between two password reset emails to
+// the same address. It is read from the environment on startup.
+var ResetInterval = utils.DurationFromEnv("PASSWORD_RESET_INTERVAL", time.Minute)
+
+// Emails anyone can request without logging in, like verification codes and
+// password reset links, are throttled per address whether or not it is
+// registered, and per IP address across all addresses.
+const (
+	// sendIPLimit is how many such emails an IP address may request within
+	// sendIPWindow.
+	sendIPLimit = 20
+
+	// sendIPWindow is how long requests from an IP address are counted.
+	sendIPWindow = time.Hour
+)
+
+// Two-factor authentication.
+const (
+	// totpIssuer names the service in authenticator apps.
+	totpIssuer = "Bricked Up"
+
+	// mfaChallengeLifetime is how long a user has to enter their code after
+	// logging in with their password.
+	mfaChallengeLifetime = 5 * time.Minute
+
+	// maxMFAAttempts is how many wrong codes end an MFA challenge.
+	maxMFAAttempts = 5
+
+	// recoveryCodeCount is how many recovery codes are handed out at once.
+	recoveryCodeCount = 10
+)
+
+// Brute-force protection of /login. Failed attempts are counted per account
+// since its last successful login and per IP address, within
+// LoginFailureWindow. After a few free attempts every failure doubles the
+// wait before the next attempt, until the lockout threshold is reached.
+var (
+	// LoginFailureWindow is how long failed attempts are counted.
+	LoginFailureWindow = utils.DurationFromEnv("LOGIN_FAILURE_WINDOW", time.Hour)
+
+	// LoginLockoutThreshold is after how many failures an account is
+	// locked. Addresses get ipThresholdFactor times as many, as they may be
+	// shared by many users.
+	LoginLockoutThreshold = utils.IntFromEnv("LOGIN_LOCKOUT_THRESHOLD", 10)
+
+	// LoginLockoutDuration is how long a locked account or address has to
+	// wait after its last failure.
+	LoginLockoutDuration = utils.DurationFromEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+)
+
+const (
+	// freeLoginAttempts is how many failures are allowed without any wait.
+	freeLoginAttempts = 3
+
+	// loginBackoffBase is the wait after the first failure past the free ones.
+	loginBackoffBase = time.Second
+
+	// ipThresholdFactor scales the thresholds for IP addresses.
+	ipThresholdFactor = 5
+)
@@ -0,0 +1,93 @@
+package users
+
+import (
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrInvalidEmailChange is returned when an email change token is unknown,
+// expired or was already used.
+var ErrInvalidEmailChange = errors.New("invalid or expired email change link")
+
+// ConfirmEmailChange replaces the address of the user with the one the token
+// was sent to by RequestEmailChange. An unverified account holding the
+// address is deleted, as the token proves who owns it; a verified one fails
+// with ErrEmailTaken. Deactivated accounts fail with ErrDeactivated.
+// Password reset and login links sent to the old address stop working.
+func ConfirmEmailChange(db *sql.DB, token string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id, userid int
+	var email string
+	var deactivated bool
+	err = tx.QueryRow(
+		`SELECT ec.id, ec.userid, ec.email, u.deactivated IS NOT NULL
+		FROM EMAIL_CHANGE ec
+		JOIN USER u ON u.id = ec.userid
+		WHERE ec.token = ? AND ec.expires > ?`,
+		utils.HashToken(token), time.Now()).Scan(&id, &userid, &email, &deactivated)
+
+	if err == sql.ErrNoRows {
+		return ErrInvalidEmailChange
+	} else if err != nil {
+		return err
+	}
+
+	if deactivated {
+		return ErrDeactivated
+	}
+
+	// The token is used once, even by concurrent confirmations
+	res, err := tx.Exec(`DELETE FROM EMAIL_CHANGE WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n != 1 {
+		return ErrInvalidEmailChange
+	}
+
+	var holder int
+	var verified bool
+	err = tx.QueryRow(
+		`SELECT id, verifyid IS NULL FROM USER WHERE email = ? AND id != ?`,
+		email, userid).Scan(&holder, &verified)
+
+	var keys []string
+	if err == nil && verified {
+		return ErrEmailTaken
+	} else if err == nil {
+		keys, err = deleteUser(tx, holder)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE USER SET email = ? WHERE id = ?`, email, userid)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range []string{"FORGOT_PASSWORD", "MAGIC_LINK"} {
+		_, err = tx.Exec(`DELETE FROM `+table+` WHERE userid = ?`, userid)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	deleteBlobs(keys)
+	return nil
+}
@@ -0,0 +1,147 @@
+package users
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestConfirmEmailChange(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Outstanding links sent to the old address
+	if _, err := createResetToken(db, 1); err != nil {
+		t.Fatal(err)
+	}
+	requestMagicLink(t, db, "john.doe@example.com", "browser-secret")
+
+	token := requestEmailChange(t, db, "john@example.org")
+	if err := ConfirmEmailChange(db, token); err != nil {
+		t.Fatalf("ConfirmEmailChange returned error: %v", err)
+	}
+
+	var email string
+	if err := db.QueryRow(`SELECT email FROM USER WHERE id = 1`).Scan(&email); err != nil {
+		t.Fatal(err)
+	}
+	if email != "john@example.org" {
+		t.Errorf("expected the new email, got %s", email)
+	}
+
+	if _, _, err := Login(db, "john@example.org", "hashed_password_1", false, "", ""); err != nil {
+		t.Errorf("login with the new email failed: %v", err)
+	}
+
+	var links int
+	err := db.QueryRow(
+		`SELECT (SELECT COUNT(*) FROM FORGOT_PASSWORD WHERE userid = 1) +
+			(SELECT COUNT(*) FROM MAGIC_LINK WHERE userid = 1)`).Scan(&links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links != 0 {
+		t.Errorf("expected links sent to the old address to be invalidated, got %d", links)
+	}
+
+	// The token is single-use
+	if err := ConfirmEmailChange(db, token); err != ErrInvalidEmailChange {
+		t.Errorf("expected ErrInvalidEmailChange on reuse, got %v", err)
+	}
+}
+
+func TestConfirmEmailChangeInvalid(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	if err := ConfirmEmailChange(db, "unknown"); err != ErrInvalidEmailChange {
+		t.Errorf("expected ErrInvalidEmailChange, got %v", err)
+	}
+
+	token := requestEmailChange(t, db, "john@example.org")
+	_, err := db.Exec(`UPDATE EMAIL_CHANGE SET expires = ?`, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfirmEmailChange(db, token); err != ErrInvalidEmailChange {
+		t.Errorf("expected ErrInvalidEmailChange for an expired link, got %v", err)
+	}
+}
+
+// TestConfirmEmailChangeTaken checks the owner of the address against
+// accounts that took it in the meantime.
+func TestConfirmEmailChangeTaken(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Sarah Williams (userid 4) never verified her account
+	token := requestEmailChange(t, db, "sarah.williams@example.com")
+	if err := ConfirmEmailChange(db, token); err != nil {
+		t.Fatalf("ConfirmEmailChange returned error: %v", err)
+	}
+
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM USER WHERE id = 4)`).Scan(&exists); err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Error("expected the unverified account to be deleted")
+	}
+
+	// Alex Brown (userid 5) verifies before John confirms
+	token = requestEmailChange(t, db, "alex.brown@example.com")
+	if _, err := db.Exec(`UPDATE USER SET verifyid = NULL, verified = 1 WHERE id = 5`); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfirmEmailChange(db, token); err != ErrEmailTaken {
+		t.Errorf("expected ErrEmailTaken, got %v", err)
+	}
+}
+
+func TestConfirmEmailChangeDeactivated(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	token := requestEmailChange(t, db, "john@example.org")
+	if _, err := db.Exec(`UPDATE USER SET deactivated = ? WHERE id = 1`, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ConfirmEmailChange(db, token); err != ErrDeactivated {
+		t.Errorf("expected ErrDeactivated, got %v", err)
+	}
+}
+
+// TestConfirmEmailChangeConcurrent checks that racing confirmations use the
+// token once.
+func TestConfirmEmailChangeConcurrent(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	token := requestEmailChange(t, db, "sarah.williams@example.com")
+
+	results := make(chan error, 2)
+	for range 2 {
+		go func() {
+			results <- ConfirmEmailChange(db, token)
+		}()
+	}
+
+	var confirmed, rejected int
+	for range 2 {
+		switch err := <-results; err {
+		case nil:
+			confirmed++
+		case ErrInvalidEmailChange:
+			rejected++
+		default:
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+	if confirmed != 1 || rejected != 1 {
+		t.Errorf("expected one confirmation and one rejection, got %d and %d", confirmed, rejected)
+	}
+}
@@ -0,0 +1,94 @@
+package users
+
+import (
+	"brickedup/backend/totp"
+	"brickedup/backend/utils"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrInvalidTOTPCode is returned when a TOTP or recovery code is wrong.
+var ErrInvalidTOTPCode = errors.New("invalid two-factor authentication code")
+
+// normalizeRecoveryCode makes recovery codes case-insensitive and ignores
+// the dash in the middle.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// generateRecoveryCodes replaces the recovery codes of the user with
+// recoveryCodeCount new ones and returns them. Only their hashes are stored.
+func generateRecoveryCodes(db *sql.DB, userid int) ([]string, error) {
+	_, err := db.Exec(`DELETE FROM RECOVERY_CODE WHERE userid = ?`, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(raw)[:10])
+		codes[i] = code[:5] + "-" + code[5:]
+
+		_, err = db.Exec(
+			`INSERT INTO RECOVERY_CODE (userid, code) VALUES (?, ?)`,
+			userid, utils.HashToken(normalizeRecoveryCode(code)))
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return codes, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves their
+// authenticator app works by entering a first code. It returns the recovery
+// codes, which are shown to the user this one time.
+func ConfirmTOTP(db *sql.DB, userid int, code string) ([]string, error) {
+	var secret sql.NullString
+	var enabled bool
+	err := db.QueryRow(
+		`SELECT totp_secret, totp_enabled FROM USER WHERE id = ?`,
+		userid).Scan(&secret, &enabled)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		return nil, ErrTOTPEnabled
+	}
+
+	if !secret.Valid {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	step, ok := totp.Validate(secret.String, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTOTPCode
+	}
+
+	_, err = db.Exec(
+		`UPDATE USER
+		SET totp_enabled = 1, totp_last_step = ?
+		WHERE id = ?`,
+		step, userid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return generateRecoveryCodes(db, userid)
+}
@@ -0,0 +1,149 @@
+package users
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/preferences"
+	"brickedup/backend/sessions"
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrLastAdmin is returned when deactivating or anonymizing the only active
+// member of an organization with exec privileges, which would leave it
+// without anyone to manage it.
+var ErrLastAdmin = errors.New("the last admin of an organization cannot leave")
+
+// lastAdminOf returns the name of an organization the user is the only
+// active admin of, or an empty string if there is none.
+func lastAdminOf(tx *sql.Tx, userid int) (string, error) {
+	var name string
+	err := tx.QueryRow(
+		`SELECT o.name
+		FROM ORG_MEMBER m
+		JOIN ORG_MEMBER_ROLE mr ON mr.memberid = m.id
+		JOIN ORG_ROLE r ON r.id = mr.roleid
+		JOIN ORGANIZATION o ON o.id = m.orgid
+		WHERE m.userid = ? AND r.orgid = m.orgid AND r.can_exec = 1
+		AND NOT EXISTS (
+			SELECT 1
+			FROM ORG_MEMBER om
+			JOIN ORG_MEMBER_ROLE omr ON omr.memberid = om.id
+			JOIN ORG_ROLE orr ON orr.id = omr.roleid
+			JOIN USER u ON u.id = om.userid
+			WHERE om.orgid = m.orgid AND om.userid != m.userid
+			AND orr.orgid = om.orgid AND orr.can_exec = 1 AND u.deactivated IS NULL
+		)
+		LIMIT 1`,
+		userid).Scan(&name)
+
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return name, err
+}
+
+// createReactivation replaces the reactivation link of the user with a new
+// one valid until `expires`, and returns its token.
+func createReactivation(tx *sql.Tx, userid int, expires time.Time) (string, error) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(`DELETE FROM REACTIVATION WHERE userid = ?`, userid)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO REACTIVATION (userid, token, created, expires)
+		VALUES (?, ?, ?, ?)`,
+		userid, utils.HashToken(token), time.Now(), expires)
+
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// sendReactivationEmail emails the reactivation link to the user.
+func sendReactivationEmail(db *sql.DB, userid int, to string, token string, deadline time.Time) error {
+	return preferences.SendTemplate(db, userid, to, mail.TemplateDeactivated, mail.DeactivatedData{
+		Link:     mail.Link("/reactivate", url.Values{"token": {token}}),
+		Deadline: deadline,
+	})
+}
+
+// DeactivateUser deactivates the account of the user: they are logged out
+// everywhere, their API tokens and pending links stop working, and they
+// cannot log in anymore. A link to reactivate the account is emailed. Unless
+// it is used within DeactivationGraceDays, the account is anonymized by
+// PurgeDeactivatedUsers.
+// It refuses with ErrLastAdmin while the user is the only admin of an
+// organization. It returns when the account will be anonymized.
+func DeactivateUser(db *sql.DB, userid int) (time.Time, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	var email string
+	var deactivated bool
+	err = tx.QueryRow(
+		`SELECT email, deactivated IS NOT NULL FROM USER WHERE id = ?`,
+		userid).Scan(&email, &deactivated)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+	if deactivated {
+		return time.Time{}, ErrDeactivated
+	}
+
+	org, err := lastAdminOf(tx, userid)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if org != "" {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrLastAdmin, org)
+	}
+
+	now := time.Now()
+	deadline := now.AddDate(0, 0, DeactivationGraceDays)
+
+	_, err = tx.Exec(`UPDATE USER SET deactivated = ? WHERE id = ?`, now, userid)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, table := range []string{"API_TOKEN", "MFA_CHALLENGE", "MAGIC_LINK", "EMAIL_CHANGE", "FORGOT_PASSWORD"} {
+		_, err = tx.Exec(`DELETE FROM `+table+` WHERE userid = ?`, userid)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+
+	err = sessions.RevokeUserSessions(tx, userid)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	token, err := createReactivation(tx, userid, deadline)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return time.Time{}, err
+	}
+
+	return deadline, sendReactivationEmail(db, userid, email, token, deadline)
+}
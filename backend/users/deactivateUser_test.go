@@ -0,0 +1,138 @@
+package users
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/sessions"
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// reactivationRegex finds the reactivation link in the text of an email.
+var reactivationRegex = regexp.MustCompile(`\S+/reactivate\?token=\S+`)
+
+// reactivationToken returns the token of the reactivation link in the last
+// email of the outbox.
+func reactivationToken(t *testing.T, outbox *mail.Recorder) string {
+	t.Helper()
+	sent := outbox.Messages()
+	if len(sent) == 0 {
+		t.Fatal("expected a reactivation email")
+	}
+
+	link, err := url.Parse(reactivationRegex.FindString(sent[len(sent)-1].Text))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("no reactivation link in %q", sent[len(sent)-1].Text)
+	}
+	return link.Query().Get("token")
+}
+
+// deactivateUser deactivates the account of the user and returns the token
+// of the emailed reactivation link.
+func deactivateUser(t *testing.T, db *sql.DB, userid int) string {
+	t.Helper()
+	outbox := mailtest.Record(t)
+	if _, err := DeactivateUser(db, userid); err != nil {
+		t.Fatal(err)
+	}
+	return reactivationToken(t, outbox)
+}
+
+func TestDeactivateUser(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	if _, err := sessions.CreateSession(db, 3, false, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	_, err := db.Exec(
+		`INSERT INTO API_TOKEN (userid, name, token, scopes, created, expires)
+		VALUES (3, 'ci', 'hash', 'issues:read', ?, ?)`,
+		time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline, err := DeactivateUser(db, 3)
+	if err != nil {
+		t.Fatalf("DeactivateUser returned error: %v", err)
+	}
+
+	want := time.Now().AddDate(0, 0, DeactivationGraceDays)
+	if deadline.Before(want.Add(-time.Minute)) || deadline.After(want) {
+		t.Errorf("expected the account to be anonymized in %d days, got %v", DeactivationGraceDays, deadline)
+	}
+
+	sent := outbox.Messages()
+	if len(sent) != 1 || sent[0].To != "mike.johnson@example.com" || !reactivationRegex.MatchString(sent[0].Text) {
+		t.Fatalf("expected the reactivation link to be emailed, got %+v", sent)
+	}
+
+	var credentials int
+	err = db.QueryRow(
+		`SELECT (SELECT COUNT(*) FROM SESSION WHERE userid = 3) +
+			(SELECT COUNT(*) FROM API_TOKEN WHERE userid = 3)`).Scan(&credentials)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credentials != 0 {
+		t.Errorf("expected sessions and API tokens to be revoked, got %d", credentials)
+	}
+
+	// Logging in fails, and no login or reset links are sent
+	if _, _, err = Login(db, "mike.johnson@example.com", "hashed_password_3", false, "", ""); err != ErrDeactivated {
+		t.Errorf("expected ErrDeactivated, got %v", err)
+	}
+	if err = RequestMagicLink(db, "mike.johnson@example.com", false, "browser-secret", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err = ForgotPassword(db, "mike.johnson@example.com", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(outbox.Messages()) != 1 {
+		t.Errorf("expected no more emails, got %d", len(outbox.Messages()))
+	}
+
+	if _, err = DeactivateUser(db, 3); err != ErrDeactivated {
+		t.Errorf("expected ErrDeactivated when deactivating again, got %v", err)
+	}
+}
+
+func TestDeactivateUserLastAdmin(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	mailtest.Record(t)
+
+	// John is the only admin of TechCorp Solutions
+	_, err := DeactivateUser(db, 1)
+	if !errors.Is(err, ErrLastAdmin) {
+		t.Fatalf("expected ErrLastAdmin, got %v", err)
+	}
+
+	var deactivated bool
+	if err = db.QueryRow(`SELECT deactivated IS NOT NULL FROM USER WHERE id = 1`).Scan(&deactivated); err != nil {
+		t.Fatal(err)
+	}
+	if deactivated {
+		t.Error("expected the account to stay active")
+	}
+
+	// With Mike as a second admin John can leave, but then Mike cannot
+	if _, err = db.Exec(`INSERT INTO ORG_MEMBER_ROLE (memberid, roleid) VALUES (3, 1)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DeactivateUser(db, 1); err != nil {
+		t.Fatalf("DeactivateUser returned error: %v", err)
+	}
+	if _, err = DeactivateUser(db, 3); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected ErrLastAdmin, got %v", err)
+	}
+}
@@ -2,54 +2,56 @@ package users
 
 import (
 	"database/sql"
-	"fmt"
-)
 
-// DeleteUser removes a user and associated records based on the session ID.
-func DeleteUser(db *sql.DB, sessionID string) error {
-	var userID int
+	_ "modernc.org/sqlite"
+)
 
-	// Retrieve user ID from session
-	err := db.QueryRow("SELECT userid FROM SESSION WHERE id = ?", sessionID).Scan(&userID)
+// DeleteUser removes a user along with every record referring to them, in
+// one transaction. It is meant for accounts that were never used, such as
+// unverified ones; users leaving are deactivated with DeactivateUser and
+// anonymized later, which keeps the history of their work.
+func DeleteUser(db *sql.DB, userID int) error {
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	// Debugging: Log found user ID
-	fmt.Printf("Deleting user ID: %d\n", userID)
-
-	// Delete user-related entries in foreign key tables
-	_, err = db.Exec("DELETE FROM REMINDER WHERE userid = ?", userID)
+	keys, err := deleteUser(tx, userID)
 	if err != nil {
 		return err
 	}
 
-	_, err = db.Exec("DELETE FROM USER_ISSUES WHERE userid = ?", userID)
-	if err != nil {
+	if err = tx.Commit(); err != nil {
 		return err
 	}
 
-	_, err = db.Exec("DELETE FROM PROJECT_MEMBER WHERE userid = ?", userID)
+	deleteBlobs(keys)
+	return nil
+}
+
+// deleteUser is DeleteUser within the transaction. It returns the keys of
+// the user's files, to delete with deleteBlobs once it is committed.
+func deleteUser(tx *sql.Tx, userID int) ([]string, error) {
+	keys, err := eraseUser(tx, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = db.Exec("DELETE FROM ORG_MEMBER WHERE userid = ?", userID)
+	_, err = tx.Exec("DELETE FROM USER_ISSUES WHERE userid = ?", userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = db.Exec("DELETE FROM SESSION WHERE userid = ?", userID)
+	_, err = tx.Exec("DELETE FROM INVITATION WHERE inviter = ?", userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Finally, delete the user
-	_, err = db.Exec("DELETE FROM USER WHERE id = ?", userID)
+	_, err = tx.Exec("DELETE FROM USER WHERE id = ?", userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Printf("User ID %d successfully deleted\n", userID)
-	return nil
+	return keys, nil
 }
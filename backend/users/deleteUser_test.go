@@ -2,7 +2,6 @@ package users
 
 import (
 	"brickedup/backend/utils"
-	"database/sql"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -13,24 +12,15 @@ func TestDeleteUser(t *testing.T) {
 	db := utils.SetupTest(t)
 	defer db.Close()
 
-	// Fetch a session ID for an existing user
-	var sessionID string
-	err := db.QueryRow("SELECT id FROM SESSION LIMIT 1").Scan(&sessionID)
-	if err == sql.ErrNoRows {
-		t.Fatalf("No session found in SESSION table")
-	} else if err != nil {
-		t.Fatalf("Failed to fetch session ID: %v", err)
-	}
-
-	// Attempt to delete the user
-	err = DeleteUser(db, sessionID)
+	// Attempt to delete John Doe (userid 1) from populate.sql
+	err := DeleteUser(db, 1)
 	if err != nil {
 		t.Fatalf("deleteUser returned an error: %v", err)
 	}
 
 	// Verify that the user is deleted
 	var count int
-	err = db.QueryRow("SELECT COUNT(*) FROM USER WHERE id = (SELECT userid FROM SESSION WHERE id = ?)", sessionID).Scan(&count)
+	err = db.QueryRow("SELECT COUNT(*) FROM USER WHERE id = 1").Scan(&count)
 	if err != nil {
 		t.Fatalf("Failed to query user count: %v", err)
 	}
@@ -38,4 +28,19 @@ func TestDeleteUser(t *testing.T) {
 	if count != 0 {
 		t.Errorf("User was not deleted, count: %d", count)
 	}
+
+	// Verify that no records refer to the user anymore
+	err = db.QueryRow(
+		`SELECT (SELECT COUNT(*) FROM SESSION WHERE userid = 1) +
+			(SELECT COUNT(*) FROM USER_ISSUES WHERE userid = 1) +
+			(SELECT COUNT(*) FROM ORG_MEMBER WHERE userid = 1) +
+			(SELECT COUNT(*) FROM ORG_MEMBER_ROLE WHERE memberid = 1) +
+			(SELECT COUNT(*) FROM PROJECT_MEMBER WHERE userid = 1)`).Scan(&count)
+	if err != nil {
+		t.Fatalf("Failed to query related records: %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("Related records were not deleted, count: %d", count)
+	}
 }
@@ -0,0 +1,55 @@
+package users
+
+import (
+	"database/sql"
+	"errors"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrTOTPRequired is returned when disabling 2FA while an organization of
+// the user requires it.
+var ErrTOTPRequired = errors.New("two-factor authentication is required by an organization")
+
+// DisableTOTP turns two-factor authentication off after checking a current
+// TOTP or recovery code. The secret and recovery codes are deleted.
+func DisableTOTP(db *sql.DB, userid int, code string) error {
+	var required bool
+	err := db.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM ORG_MEMBER om
+			JOIN ORGANIZATION o ON o.id = om.orgid
+			WHERE om.userid = ? AND o.require_2fa = 1
+		)`,
+		userid).Scan(&required)
+
+	if err != nil {
+		return err
+	}
+
+	if required {
+		return ErrTOTPRequired
+	}
+
+	ok, err := checkSecondFactor(db, userid, code)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrInvalidTOTPCode
+	}
+
+	_, err = db.Exec(
+		`UPDATE USER
+		SET totp_secret = NULL, totp_enabled = 0, totp_last_step = 0
+		WHERE id = ?`,
+		userid)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`DELETE FROM RECOVERY_CODE WHERE userid = ?`, userid)
+	return err
+}
@@ -0,0 +1,49 @@
+package users
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestDisableTOTP(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, recovery := enableTOTP(t, db, 2)
+
+	if err := DisableTOTP(db, 2, "000000"); err != ErrInvalidTOTPCode {
+		t.Errorf("expected ErrInvalidTOTPCode, got %v", err)
+	}
+
+	// Jane Smith (2) is a member of organization 2
+	_, err := db.Exec(`UPDATE ORGANIZATION SET require_2fa = 1 WHERE id = 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DisableTOTP(db, 2, recovery[0]); err != ErrTOTPRequired {
+		t.Errorf("expected ErrTOTPRequired, got %v", err)
+	}
+
+	_, err = db.Exec(`UPDATE ORGANIZATION SET require_2fa = 0 WHERE id = 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DisableTOTP(db, 2, recovery[0]); err != nil {
+		t.Fatalf("DisableTOTP returned error: %v", err)
+	}
+
+	var codes int
+	db.QueryRow(`SELECT COUNT(*) FROM RECOVERY_CODE WHERE userid = 2`).Scan(&codes)
+	if codes != 0 {
+		t.Error("recovery codes were kept")
+	}
+
+	_, challenge, err := Login(db, "jane.smith@example.com", "hashed_password_2", false, "", "")
+	if err != nil || challenge != nil {
+		t.Errorf("login still asks for a code: %v", err)
+	}
+}
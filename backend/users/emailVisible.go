@@ -0,0 +1,39 @@
+package users
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// visibleEmails selects the users whose email a viewer may see: the viewer
+// themselves and the users sharing an organization or project with them.
+// The viewer's ID is bound to each of the three placeholders.
+const visibleEmails = `SELECT ?
+	UNION SELECT om.userid FROM ORG_MEMBER om
+		JOIN ORG_MEMBER vm ON vm.orgid = om.orgid
+		WHERE vm.userid = ?
+	UNION SELECT pm.userid FROM PROJECT_MEMBER pm
+		JOIN PROJECT_MEMBER vm ON vm.projectid = pm.projectid
+		WHERE vm.userid = ?`
+
+// emailVisible reports whether `viewer` may see the email of the user.
+// Viewers who have yet to enable required two-factor authentication only
+// see their own.
+func emailVisible(db *sql.DB, viewer int, userid int) (bool, error) {
+	if viewer == userid {
+		return true, nil
+	}
+
+	required, err := MFARequired(db, viewer)
+	if err != nil || required {
+		return false, err
+	}
+
+	var visible bool
+	err = db.QueryRow(
+		`SELECT ? IN (`+visibleEmails+`)`,
+		userid, viewer, viewer, viewer).Scan(&visible)
+
+	return visible, err
+}
@@ -0,0 +1,39 @@
+package users
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestEmailVisible(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// John shares an organization with Jane, but nothing with Sarah
+	for userid, want := range map[int]bool{1: true, 2: true, 4: false} {
+		visible, err := emailVisible(db, 1, userid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if visible != want {
+			t.Errorf("user %d: expected %v, got %v", userid, want, visible)
+		}
+	}
+
+	// Until John enables the two-factor authentication his organization
+	// requires, he only sees his own email
+	if _, err := db.Exec(`UPDATE ORGANIZATION SET require_2fa = 1 WHERE id = 1`); err != nil {
+		t.Fatal(err)
+	}
+	for userid, want := range map[int]bool{1: true, 2: false} {
+		visible, err := emailVisible(db, 1, userid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if visible != want {
+			t.Errorf("user %d with 2FA pending: expected %v, got %v", userid, want, visible)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package users
+
+import (
+	"brickedup/backend/totp"
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrTOTPEnabled is returned when enrolling while 2FA is already enabled.
+var ErrTOTPEnabled = errors.New("two-factor authentication is already enabled")
+
+// EnrollTOTP generates a new TOTP secret for the user. The secret is not used
+// for logins until it is confirmed with ConfirmTOTP.
+func EnrollTOTP(db *sql.DB, userid int) (*utils.TOTPEnrollment, error) {
+	var email string
+	var enabled bool
+	err := db.QueryRow(
+		`SELECT email, totp_enabled FROM USER WHERE id = ?`,
+		userid).Scan(&email, &enabled)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		return nil, ErrTOTPEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(
+		`UPDATE USER SET totp_secret = ? WHERE id = ?`,
+		secret, userid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &utils.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, email, secret),
+	}, nil
+}
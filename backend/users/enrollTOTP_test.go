@@ -0,0 +1,88 @@
+package users
+
+import (
+	"brickedup/backend/totp"
+	"brickedup/backend/utils"
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// enableTOTP sets up two-factor authentication for the user and returns the
+// secret and the recovery codes.
+func enableTOTP(t *testing.T, db *sql.DB, userid int) (string, []string) {
+	t.Helper()
+
+	enrollment, err := EnrollTOTP(db, userid)
+	if err != nil {
+		t.Fatalf("EnrollTOTP returned error: %v", err)
+	}
+
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	codes, err := ConfirmTOTP(db, userid, code)
+	if err != nil {
+		t.Fatalf("ConfirmTOTP returned error: %v", err)
+	}
+
+	return enrollment.Secret, codes
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	if _, err := TOTPQRCode(db, 1, 128); err != ErrTOTPNotEnrolled {
+		t.Errorf("expected ErrTOTPNotEnrolled, got %v", err)
+	}
+
+	enrollment, err := EnrollTOTP(db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(enrollment.URI, "otpauth://totp/") ||
+		!strings.Contains(enrollment.URI, "secret="+enrollment.Secret) {
+		t.Errorf("unexpected URI %s", enrollment.URI)
+	}
+
+	png, err := TOTPQRCode(db, 1, 128)
+	if err != nil || !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Fatalf("QR code is not a PNG: %v", err)
+	}
+
+	// Not enabled until confirmed
+	_, challenge, err := Login(db, "john.doe@example.com", "hashed_password_1", false, "", "")
+	if err != nil || challenge != nil {
+		t.Fatalf("2FA was used before it was confirmed: %v", err)
+	}
+
+	if _, err := ConfirmTOTP(db, 1, "000000"); err != ErrInvalidTOTPCode {
+		t.Errorf("expected ErrInvalidTOTPCode, got %v", err)
+	}
+
+	code, _ := totp.Code(enrollment.Secret, totp.Step(time.Now()))
+	codes, err := ConfirmTOTP(db, 1, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(codes) != recoveryCodeCount {
+		t.Errorf("expected %d recovery codes, got %d", recoveryCodeCount, len(codes))
+	}
+
+	var stored string
+	db.QueryRow(`SELECT code FROM RECOVERY_CODE WHERE userid = 1 LIMIT 1`).Scan(&stored)
+	for _, code := range codes {
+		if stored == code || stored == normalizeRecoveryCode(code) {
+			t.Fatal("recovery codes are stored in plaintext")
+		}
+	}
+
+	if _, err := EnrollTOTP(db, 1); err != ErrTOTPEnabled {
+		t.Errorf("expected ErrTOTPEnabled, got %v", err)
+	}
+}
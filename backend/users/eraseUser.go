@@ -0,0 +1,90 @@
+package users
+
+import (
+	"brickedup/backend/avatars"
+	"brickedup/backend/blobs"
+	"brickedup/backend/exports"
+	"brickedup/backend/sessions"
+	"brickedup/backend/validate"
+	"database/sql"
+	"log"
+
+	_ "modernc.org/sqlite"
+)
+
+// personalRows are the statements deleting, by user ID, what is of use to
+// the user alone: their credentials, pending links, reminders, memberships
+// and login history. Role assignments go before the memberships they
+// belong to. Login attempts are also kept by email, see eraseUser.
+var personalRows = []string{
+	`DELETE FROM REMINDER WHERE userid = ?`,
+	`DELETE FROM NOTIFICATION_SETTING WHERE userid = ?`,
+	`DELETE FROM USER_PREFERENCES WHERE userid = ?`,
+	`DELETE FROM PROJECT_MEMBER_ROLE WHERE memberid IN (
+		SELECT id FROM PROJECT_MEMBER WHERE userid = ?
+	)`,
+	`DELETE FROM PROJECT_MEMBER WHERE userid = ?`,
+	`DELETE FROM ORG_MEMBER_ROLE WHERE memberid IN (
+		SELECT id FROM ORG_MEMBER WHERE userid = ?
+	)`,
+	`DELETE FROM ORG_MEMBER WHERE userid = ?`,
+	`DELETE FROM RECOVERY_CODE WHERE userid = ?`,
+	`DELETE FROM MFA_CHALLENGE WHERE userid = ?`,
+	`DELETE FROM API_TOKEN WHERE userid = ?`,
+	`DELETE FROM USER_IDENTITY WHERE userid = ?`,
+	`DELETE FROM MAGIC_LINK WHERE userid = ?`,
+	`DELETE FROM EMAIL_CHANGE WHERE userid = ?`,
+	`DELETE FROM FORGOT_PASSWORD WHERE userid = ?`,
+	`DELETE FROM REACTIVATION WHERE userid = ?`,
+	`DELETE FROM LOGIN_ATTEMPT WHERE userid = ?`,
+}
+
+// eraseUser deletes the personal records of the user within the
+// transaction, along with their sessions and data exports. It leaves the
+// USER row itself to the caller. It returns the keys of the user's files,
+// to delete with deleteBlobs once the transaction is committed.
+func eraseUser(tx *sql.Tx, userid int) ([]string, error) {
+	var email string
+	var avatar sql.NullString
+	err := tx.QueryRow(`SELECT email, avatar FROM USER WHERE id = ?`, userid).Scan(&email, &avatar)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	for _, query := range personalRows {
+		if _, err := tx.Exec(query, userid); err != nil {
+			return nil, err
+		}
+	}
+
+	// Attempts for unknown users and throttled email requests only hold
+	// the email
+	if email != "" {
+		_, err = tx.Exec(`DELETE FROM LOGIN_ATTEMPT WHERE email = ?`, validate.NormalizeEmail(email))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = sessions.RevokeUserSessions(tx, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := exports.DeleteUserExports(tx, userid)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(keys, avatars.Keys(avatar.String)...), nil
+}
+
+// deleteBlobs deletes the files of an erased user. Failures are only
+// logged, as nothing refers to the files anymore.
+func deleteBlobs(keys []string) {
+	for _, key := range keys {
+		if err := blobs.Delete(key); err != nil {
+			log.Println(err.Error())
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package users
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/preferences"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"errors"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// resetTokenLifetime is how long a password reset link stays valid.
+const resetTokenLifetime = time.Hour
+
+// ErrResetThrottled is returned when a password reset was requested for the
+// address, or by the client, too recently.
+var ErrResetThrottled = errors.New("password reset was requested too recently")
+
+// createResetToken stores a new single-use password reset token for the user
+// and returns it. Only the hash of the token is stored.
+func createResetToken(db *sql.DB, userid int) (string, error) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO FORGOT_PASSWORD (userid, code, expirationdate)
+		VALUES (?, ?, ?)`,
+		userid, utils.HashToken(token), time.Now().Add(resetTokenLifetime))
+
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// sendResetEmail emails the password reset link to the user.
+func sendResetEmail(db *sql.DB, userid int, to string, token string) error {
+	return preferences.SendTemplate(db, userid, to, mail.TemplateReset, mail.ResetData{
+		Link:    mail.Link("/reset-password", url.Values{"token": {token}}),
+		Expires: time.Now().Add(resetTokenLifetime),
+	})
+}
+
+// ForgotPassword emails a password reset link to the user with the given
+// email, requested from `ip`. To not reveal which emails are registered,
+// unknown emails and deactivated accounts are not treated as an error, and
+// requests are throttled with ErrResetThrottled per address and IP address
+// whether or not the email is registered. The email itself is sent by the
+// configured mailer.
+func ForgotPassword(db *sql.DB, email string, ip string, userAgent string) error {
+	email = validate.NormalizeEmail(email)
+
+	throttled, err := requestThrottled(db, methodPasswordReset, email, ip, userAgent, ResetInterval)
+	if err != nil {
+		return err
+	}
+	if throttled {
+		return ErrResetThrottled
+	}
+
+	var userid int
+	err = db.QueryRow(
+		`SELECT id FROM USER WHERE email = ? AND deactivated IS NULL`,
+		email).Scan(&userid)
+
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	token, err := createResetToken(db, userid)
+	if err != nil {
+		return err
+	}
+
+	return sendResetEmail(db, userid, email, token)
+}
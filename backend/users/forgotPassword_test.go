@@ -0,0 +1,97 @@
+package users
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestForgotPassword(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	var before int
+	err := db.QueryRow("SELECT COUNT(*) FROM FORGOT_PASSWORD").Scan(&before)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Known email
+	err = ForgotPassword(db, "jane.smith@example.com", "", "")
+	if err != nil {
+		t.Fatalf("ForgotPassword returned error: %v", err)
+	}
+
+	// Unknown emails must look exactly the same to the caller
+	err = ForgotPassword(db, "nouser@example.com", "", "")
+	if err != nil {
+		t.Fatalf("ForgotPassword revealed an unknown email: %v", err)
+	}
+
+	var after int
+	err = db.QueryRow("SELECT COUNT(*) FROM FORGOT_PASSWORD").Scan(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after != before+1 {
+		t.Errorf("expected exactly one new reset token, got %d", after-before)
+	}
+
+	sent := outbox.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected exactly one email, got %d", len(sent))
+	}
+
+	if sent[0].To != "jane.smith@example.com" {
+		t.Errorf("email sent to %q", sent[0].To)
+	}
+
+	if !strings.Contains(sent[0].Text, mail.Link("/reset-password", nil)+"?token=") {
+		t.Errorf("email does not contain the reset link: %q", sent[0].Text)
+	}
+
+	// Mailboxes cannot be flooded, and unknown emails are throttled alike
+	for _, email := range []string{"jane.smith@example.com", "nouser@example.com"} {
+		if err = ForgotPassword(db, email, "", ""); err != ErrResetThrottled {
+			t.Errorf("%s: expected ErrResetThrottled, got %v", email, err)
+		}
+	}
+	if len(outbox.Messages()) != 1 {
+		t.Errorf("expected no more emails, got %d", len(outbox.Messages()))
+	}
+}
+
+func TestCreateResetToken(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	token, err := createResetToken(db, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var code string
+	var expires time.Time
+	err = db.QueryRow(
+		`SELECT code, expirationdate FROM FORGOT_PASSWORD
+		WHERE userid = 2 ORDER BY id DESC LIMIT 1`).Scan(&code, &expires)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code == token || code != utils.HashToken(token) {
+		t.Error("reset token was not stored as a hash")
+	}
+
+	if expires.Before(time.Now()) || expires.After(time.Now().Add(resetTokenLifetime)) {
+		t.Errorf("unexpected expiration date %v", expires)
+	}
+}
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"brickedup/backend/avatars"
 	"brickedup/backend/utils"
 	"database/sql"
 	"encoding/json"
@@ -91,10 +92,12 @@ func getUserIssues(db *sql.DB, user *utils.User) error {
 }
 
 
-// GetUser fetches one user by userid from the DB and returns JSON data.
-func GetUser(db *sql.DB, userid int) ([]byte, error) {
+// GetUser fetches one user by userid from the DB, as seen by `viewer`, and
+// returns JSON data. The avatar is the URL serving it. The email is left
+// empty unless emailVisible allows the viewer to see it.
+func GetUser(db *sql.DB, userid int, viewer int) ([]byte, error) {
 	// Get exactly one row for the given userID.
-	row := db.QueryRow(`SELECT name, email, verified, avatar FROM USER WHERE id = ?`, userid)
+	row := db.QueryRow(`SELECT name, email, verified, COALESCE(avatar, '') FROM USER WHERE id = ?`, userid)
 
 	var user utils.User
 	user.ID = userid
@@ -107,6 +110,15 @@ func GetUser(db *sql.DB, userid int) ([]byte, error) {
 		}
 		return nil, err
 	}
+	user.Avatar = avatars.URL(userid, user.Avatar)
+
+	visible, err := emailVisible(db, viewer, userid)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		user.Email = ""
+	}
 
 	err = getUserProjects(db, &user)
 	if err != nil {
@@ -19,7 +19,7 @@ func TestGetUser(t *testing.T) {
 					Name: "John Doe",
 					Email: "john.doe@example.com",
 					Password: "",
-					Avatar: "avatar1.png",
+					Avatar: "/avatar?userid=1",
 					Verified: true,
 					Projects: []int{ 1, 2 },
 					Organizations: []int{1},
@@ -55,7 +55,7 @@ func TestGetUser(t *testing.T) {
 
     for _, tc := range tests {
         t.Run(tc.name, func(t *testing.T) {
-            got, err := GetUser(db, tc.userID)
+            got, err := GetUser(db, tc.userID, 1)
             
             if tc.wantErr && err == nil {
                 t.Fatalf("expected an error but got none")
@@ -77,3 +77,23 @@ func TestGetUser(t *testing.T) {
         })
     }
 }
+
+// TestGetUserHidesEmail checks that users sharing nothing with the viewer
+// are shown without their email.
+func TestGetUserHidesEmail(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	got, err := GetUser(db, 4, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var user utils.User
+	if err := json.Unmarshal(got, &user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "Sarah Williams" || user.Email != "" {
+		t.Errorf("expected Sarah without her email, got %s", got)
+	}
+}
@@ -1,54 +1,136 @@
 package users
 
 import (
+	"brickedup/backend/passwords"
+	"brickedup/backend/sessions"
 	"brickedup/backend/utils"
+	"brickedup/backend/validate"
 	"database/sql"
-	"time"
+	"errors"
+	"sync"
 
-	"golang.org/x/crypto/bcrypt"
 	_ "modernc.org/sqlite"
 )
 
+var (
+	// ErrInvalidCredentials is returned for unknown emails and wrong
+	// passwords alike, so the response does not reveal which emails exist.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	// ErrEmailNotVerified is returned for the correct password of an account
+	// that was not verified yet.
+	ErrEmailNotVerified = errors.New("email is not verified")
+
+	// ErrDeactivated is returned for the correct credentials of an account
+	// that was deactivated. It can be reactivated with the emailed link
+	// until it is anonymized.
+	ErrDeactivated = errors.New("account is deactivated")
+
+	// ErrTooManyAttempts is returned while the account or the client's
+	// address has to wait after failed attempts. See LoginRetryAfter.
+	ErrTooManyAttempts = errors.New("too many failed login attempts")
+)
+
+// dummyHash is compared against for unknown emails, so they take as long
+// to fail as wrong passwords.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := passwords.Hash("dummy password")
+	return hash
+})
+
 // Login authenticates a user by verifying their email and password.
-// If authentication is successful and the user is verified, it creates a new session or reuses an existing one.
-// It returns the session data.
-func Login(db *sql.DB, email, password string) (session *utils.SessionData, err error) {
-	session = &utils.SessionData{}
+// If authentication is successful and the user is verified, it creates a new session
+// for the client with the given IP address and user agent. With `remember` set the
+// session is a longer-lived "remember me" session.
+// It returns the session data, or, if the user has two-factor authentication enabled,
+// a challenge to pass to VerifyMFA along with a code.
+// Every attempt is recorded, and repeated failures for the same account or from
+// the same address have to wait increasingly long (ErrTooManyAttempts).
+// Deactivated accounts fail with ErrDeactivated.
+func Login(db *sql.DB, email, password string, remember bool, ip, userAgent string) (*utils.SessionData, *utils.MFAChallenge, error) {
+	var userid int
 	var storedPassword string
+	var verified bool
+	var legacy bool
+	var totpEnabled bool
+	var deactivated bool
+
+	email = validate.NormalizeEmail(email)
+
+	wait, err := LoginRetryAfter(db, email, ip)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if wait > 0 {
+		err = recordLoginAttempt(db, methodPassword, email, 0, ip, userAgent, false, reasonThrottled)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrTooManyAttempts
+	}
 
     // Query the database to get the user's ID, hashed password, and verification status
     err = db.QueryRow(
-        `SELECT id, password 
+        `SELECT id, password, verifyid IS NULL, legacy_password, totp_enabled, deactivated IS NOT NULL
 		FROM USER 
-		WHERE email = ? AND verifyid IS NULL `, 
-        email).Scan(&session.UserID, &storedPassword)
+		WHERE email = ?`, 
+        email).Scan(&userid, &storedPassword, &verified, &legacy, &totpEnabled, &deactivated)
 
-	if err != nil {
-        return nil, err
+	if err == sql.ErrNoRows {
+		passwords.Verify(dummyHash(), password)
+
+		err = recordLoginAttempt(db, methodPassword, email, 0, ip, userAgent, false, reasonUnknownUser)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidCredentials
+	} else if err != nil {
+        return nil, nil, err
     }
 
     // Compare the provided password with the stored hashed password
-    if err := bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(password)); 
-    err != nil {
-        return nil, err
+    matches, err := comparePassword(db, userid, storedPassword, legacy, password)
+    if err != nil {
+        return nil, nil, err
     }
 
-    // Set the session expiration time (valid for 24 hours)
-    session.Expires = time.Now().Add(24 * time.Hour)
+    if !matches {
+		err = recordLoginAttempt(db, methodPassword, email, userid, ip, userAgent, false, reasonWrongPassword)
+		if err != nil {
+			return nil, nil, err
+		}
+        return nil, nil, ErrInvalidCredentials
+    }
 
-    // Insert the new session into the SESSION table in the database
-    result, err := db.Exec(
-		"INSERT INTO SESSION (userid, expires) VALUES (?, ?)", 
-		session.UserID, session.Expires)
+	if !verified {
+		err = recordLoginAttempt(db, methodPassword, email, userid, ip, userAgent, false, reasonUnverified)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrEmailNotVerified
+	}
 
-    if err != nil {
-        return nil, err
-    }
+	if deactivated {
+		err = recordLoginAttempt(db, methodPassword, email, userid, ip, userAgent, false, reasonDeactivated)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrDeactivated
+	}
 
-    session.SessionID, err = result.LastInsertId()
-    if err != nil {
-        return nil, err
+    // The login of 2FA users is recorded once they entered their code
+    if totpEnabled {
+        challenge, err := createMFAChallenge(db, userid, remember, methodPassword)
+        return nil, challenge, err
     }
 
-    return session, nil
+	err = recordLoginAttempt(db, methodPassword, email, userid, ip, userAgent, true, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+    // Insert the new session into the SESSION table in the database
+    session, err := sessions.CreateSession(db, userid, remember, ip, userAgent)
+    return session, nil, err
 }
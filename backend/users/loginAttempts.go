@@ -0,0 +1,153 @@
+package users
+
+import (
+	"brickedup/backend/validate"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Login methods recorded for login attempts.
+const (
+	methodPassword  = "password"
+	methodOIDC      = "oidc"
+	methodMagicLink = "magic_link"
+
+	// methodEmailChange records the password checks of email changes, so
+	// a stolen session cannot be used to guess the password either.
+	methodEmailChange = "email_change"
+
+	// methodResendVerification records requests for verification emails,
+	// which are throttled by requestThrottled.
+	methodResendVerification = "resend_verification"
+
+	// methodPasswordReset records requests for password reset emails.
+	methodPasswordReset = "password_reset"
+
+	// methodMagicLinkRequest records requests for login links, apart from
+	// the logins with them.
+	methodMagicLinkRequest = "magic_link_request"
+)
+
+// Reasons recorded for failed login attempts.
+const (
+	reasonUnknownUser   = "unknown_user"
+	reasonWrongPassword = "wrong_password"
+	reasonUnverified    = "unverified"
+	reasonWrong2FACode  = "wrong_2fa_code"
+	reasonThrottled     = "throttled"
+	reasonInvalidLink   = "invalid_link"
+	reasonWrongBrowser  = "wrong_browser"
+	reasonDeactivated   = "deactivated"
+)
+
+// recordLoginAttempt adds the attempt to log in with `method` to the audit
+// log in LOGIN_ATTEMPT. `userid` is zero for unknown emails.
+func recordLoginAttempt(db *sql.DB, method string, email string, userid int, ip, userAgent string, success bool, reason string) error {
+	var user sql.NullInt64
+	if userid != 0 {
+		user = sql.NullInt64{Int64: int64(userid), Valid: true}
+	}
+
+	_, err := db.Exec(
+		`INSERT INTO LOGIN_ATTEMPT (email, userid, ip, user_agent, success, reason, method, created)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		validate.NormalizeEmail(email), user, ip, userAgent, success, reason, method, time.Now().UTC())
+
+	return err
+}
+
+// loginBackoff returns how long to wait after the last of `failures` failed
+// attempts, when `free` failures are allowed and `threshold` failures lock.
+// A threshold of zero disables the lockout.
+func loginBackoff(failures int, free int, threshold int) time.Duration {
+	if failures < free || failures == 0 {
+		return 0
+	}
+
+	if threshold > 0 && failures >= threshold {
+		return LoginLockoutDuration
+	}
+
+	if failures-free >= 30 {
+		return LoginLockoutDuration
+	}
+
+	wait := loginBackoffBase << (failures - free)
+	if wait > LoginLockoutDuration {
+		return LoginLockoutDuration
+	}
+	return wait
+}
+
+// loginRetryAfterFor returns how much longer attempts with `column` (email or
+// ip) set to `value` have to wait.
+func loginRetryAfterFor(db *sql.DB, column string, value string, factor int, now time.Time) (time.Duration, error) {
+	// Only failures within the window count
+	since := now.Add(-LoginFailureWindow)
+
+	// A successful login starts the count of the account over, but not that
+	// of the address, or guessing could go on between logins to an account
+	// of one's own. Emails anyone can request are no logins.
+	if column == "email" {
+		var lastSuccess time.Time
+		err := db.QueryRow(
+			`SELECT created FROM LOGIN_ATTEMPT
+			WHERE email = ? AND success = 1 AND method IN (?, ?, ?) AND created > ?
+			ORDER BY created DESC LIMIT 1`,
+			value, methodPassword, methodOIDC, methodMagicLink, since).Scan(&lastSuccess)
+
+		if err == nil {
+			since = lastSuccess
+		} else if err != sql.ErrNoRows {
+			return 0, err
+		}
+	}
+
+	// Only wrong credentials count, not throttled attempts
+	var failures int
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM LOGIN_ATTEMPT
+		WHERE `+column+` = ? AND success = 0 AND reason IN (?, ?, ?) AND created > ?`,
+		value, reasonUnknownUser, reasonWrongPassword, reasonWrong2FACode, since).Scan(&failures)
+
+	if err != nil || failures == 0 {
+		return 0, err
+	}
+
+	var lastFailure time.Time
+	err = db.QueryRow(
+		`SELECT created FROM LOGIN_ATTEMPT
+		WHERE `+column+` = ? AND success = 0 AND reason IN (?, ?, ?)
+		ORDER BY created DESC LIMIT 1`,
+		value, reasonUnknownUser, reasonWrongPassword, reasonWrong2FACode).Scan(&lastFailure)
+
+	if err != nil {
+		return 0, err
+	}
+
+	backoff := loginBackoff(failures, freeLoginAttempts*factor, LoginLockoutThreshold*factor)
+	wait := lastFailure.Add(backoff).Sub(now)
+	if wait < 0 {
+		return 0, nil
+	}
+	return wait, nil
+}
+
+// LoginRetryAfter returns how long the client at `ip` has to wait before it
+// may try to log in to the account `email` again. Zero means right away.
+func LoginRetryAfter(db *sql.DB, email string, ip string) (time.Duration, error) {
+	now := time.Now().UTC()
+
+	wait, err := loginRetryAfterFor(db, "email", validate.NormalizeEmail(email), 1, now)
+	if err != nil || ip == "" {
+		return wait, err
+	}
+
+	ipWait, err := loginRetryAfterFor(db, "ip", ip, ipThresholdFactor, now)
+	if ipWait > wait {
+		wait = ipWait
+	}
+	return wait, err
+}
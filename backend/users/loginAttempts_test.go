@@ -0,0 +1,196 @@
+package users
+
+import (
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestLoginBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{freeLoginAttempts - 1, 0},
+		{freeLoginAttempts, loginBackoffBase},
+		{freeLoginAttempts + 2, 4 * loginBackoffBase},
+		{LoginLockoutThreshold, LoginLockoutDuration},
+		{LoginLockoutThreshold + 5, LoginLockoutDuration},
+	}
+
+	for _, tt := range tests {
+		got := loginBackoff(tt.failures, freeLoginAttempts, LoginLockoutThreshold)
+		if got != tt.want {
+			t.Errorf("%d failures: got %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestLoginThrottling(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	age := func(d time.Duration) {
+		t.Helper()
+		_, err := db.Exec(`UPDATE LOGIN_ATTEMPT SET created = ?`, time.Now().UTC().Add(-d))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < freeLoginAttempts; i++ {
+		_, _, err := Login(db, "jane.smith@example.com", "wrong", false, "10.0.0.1", "")
+		if err != ErrInvalidCredentials {
+			t.Fatalf("attempt %d: expected ErrInvalidCredentials, got %v", i, err)
+		}
+	}
+
+	// Even the right password has to wait now, from any address
+	_, _, err := Login(db, "jane.smith@example.com", "hashed_password_2", false, "10.0.0.2", "")
+	if err != ErrTooManyAttempts {
+		t.Fatalf("expected ErrTooManyAttempts, got %v", err)
+	}
+
+	wait, err := LoginRetryAfter(db, "Jane.Smith@example.com", "10.0.0.2")
+	if err != nil || wait <= 0 || wait > loginBackoffBase {
+		t.Errorf("unexpected wait %s (%v)", wait, err)
+	}
+
+	// Other accounts are not affected
+	if _, _, err := Login(db, "john.doe@example.com", "hashed_password_1", false, "10.0.0.2", ""); err != nil {
+		t.Errorf("other account was throttled: %v", err)
+	}
+
+	age(2 * loginBackoffBase)
+	if _, _, err := Login(db, "jane.smith@example.com", "hashed_password_2", false, "10.0.0.2", ""); err != nil {
+		t.Fatalf("login after the backoff failed: %v", err)
+	}
+
+	// A successful login starts the count over
+	if wait, _ := LoginRetryAfter(db, "jane.smith@example.com", ""); wait != 0 {
+		t.Errorf("expected no wait after a successful login, got %s", wait)
+	}
+
+	var failed, throttled int
+	db.QueryRow(`SELECT COUNT(*) FROM LOGIN_ATTEMPT WHERE email = 'jane.smith@example.com' AND success = 0 AND reason = ?`,
+		reasonWrongPassword).Scan(&failed)
+	db.QueryRow(`SELECT COUNT(*) FROM LOGIN_ATTEMPT WHERE reason = ?`, reasonThrottled).Scan(&throttled)
+	if failed != freeLoginAttempts || throttled != 1 {
+		t.Errorf("expected %d failed and 1 throttled attempts in the audit log, got %d and %d",
+			freeLoginAttempts, failed, throttled)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	for i := 0; i < LoginLockoutThreshold; i++ {
+		err := recordLoginAttempt(db, methodPassword, "jane.smith@example.com", 2, "10.0.0.1", "", false, reasonWrongPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wait, err := LoginRetryAfter(db, "jane.smith@example.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wait < LoginLockoutDuration-time.Minute {
+		t.Errorf("expected the account to be locked, wait is %s", wait)
+	}
+
+	// Failures outside the window are forgotten
+	_, err = db.Exec(`UPDATE LOGIN_ATTEMPT SET created = ?`,
+		time.Now().UTC().Add(-LoginFailureWindow-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wait, _ := LoginRetryAfter(db, "jane.smith@example.com", ""); wait != 0 {
+		t.Errorf("expected no wait after the window, got %s", wait)
+	}
+}
+
+func TestLoginThrottlingPerIP(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Guessing across many accounts from one address
+	for i := 0; i < freeLoginAttempts*ipThresholdFactor; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		err := recordLoginAttempt(db, methodPassword, email, 0, "10.0.0.9", "", false, reasonUnknownUser)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, _, err := Login(db, "john.doe@example.com", "hashed_password_1", false, "10.0.0.9", "")
+	if err != ErrTooManyAttempts {
+		t.Errorf("expected ErrTooManyAttempts from the address, got %v", err)
+	}
+
+	if _, _, err := Login(db, "john.doe@example.com", "hashed_password_1", false, "10.0.0.10", ""); err != nil {
+		t.Errorf("login from another address failed: %v", err)
+	}
+}
+
+// TestLoginThrottlingPerIPAfterSuccess checks that logging in to an account
+// of one's own does not reset the count of the address.
+func TestLoginThrottlingPerIPAfterSuccess(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	for i := 0; i < freeLoginAttempts*ipThresholdFactor; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		err := recordLoginAttempt(db, methodPassword, email, 0, "10.0.0.9", "", false, reasonUnknownUser)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := recordLoginAttempt(db, methodPassword, "mike.johnson@example.com", 3, "10.0.0.9", "", true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wait, _ := LoginRetryAfter(db, "john.doe@example.com", "10.0.0.9"); wait <= 0 {
+		t.Error("expected the address to still be throttled after a successful login")
+	}
+}
+
+// TestLoginLockoutEmailRequests checks that emails anyone can request for
+// an account do not lift its lockout.
+func TestLoginLockoutEmailRequests(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	mailtest.Record(t)
+
+	for i := 0; i < LoginLockoutThreshold; i++ {
+		err := recordLoginAttempt(db, methodPassword, "jane.smith@example.com", 2, "10.0.0.1", "", false, reasonWrongPassword)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ForgotPassword(db, "jane.smith@example.com", "10.0.0.2", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := ResendVerification(db, "jane.smith@example.com", "10.0.0.2", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := RequestMagicLink(db, "jane.smith@example.com", false, "browser-secret", "10.0.0.2", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	wait, err := LoginRetryAfter(db, "jane.smith@example.com", "")
+	if err != nil || wait < LoginLockoutDuration-time.Minute {
+		t.Errorf("expected the account to stay locked, wait is %s (%v)", wait, err)
+	}
+}
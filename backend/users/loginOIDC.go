@@ -0,0 +1,150 @@
+package users
+
+import (
+	"brickedup/backend/oidc"
+	"brickedup/backend/sessions"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrProviderEmailUnverified is returned for identities that are not linked
+// to an account yet and whose email the provider has not verified.
+var ErrProviderEmailUnverified = errors.New("the identity provider has not verified this email address")
+
+// LoginOIDC logs in the user an identity provider vouched for, like Login
+// does after checking a password.
+// Identities are recognized by provider and subject. A new identity is
+// linked to the account with the same email, or to a new account without a
+// password, but only if the provider verified the email. Accounts that were
+// never verified are verified by that, and lose the password whoever signed
+// up with the email chose. Deactivated accounts fail with ErrDeactivated.
+func LoginOIDC(db *sql.DB, identity *oidc.Identity, remember bool, ip, userAgent string) (*utils.SessionData, *utils.MFAChallenge, error) {
+	userid, err := linkIdentity(db, identity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var email string
+	var totpEnabled, deactivated bool
+	err = db.QueryRow(
+		`SELECT email, totp_enabled, deactivated IS NOT NULL FROM USER WHERE id = ?`,
+		userid).Scan(&email, &totpEnabled, &deactivated)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if deactivated {
+		err = recordLoginAttempt(db, methodOIDC, email, userid, ip, userAgent, false, reasonDeactivated)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrDeactivated
+	}
+
+	// The login of 2FA users is recorded once they entered their code
+	if totpEnabled {
+		challenge, err := createMFAChallenge(db, userid, remember, methodOIDC)
+		return nil, challenge, err
+	}
+
+	err = recordLoginAttempt(db, methodOIDC, email, userid, ip, userAgent, true, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := sessions.CreateSession(db, userid, remember, ip, userAgent)
+	return session, nil, err
+}
+
+// linkIdentity returns the ID of the user the identity belongs to, linking
+// or provisioning an account if it is new.
+func linkIdentity(db *sql.DB, identity *oidc.Identity) (int, error) {
+	var userid int
+	err := db.QueryRow(
+		`SELECT userid FROM USER_IDENTITY WHERE provider = ? AND subject = ?`,
+		identity.Provider, identity.Subject).Scan(&userid)
+
+	if err == nil {
+		return userid, nil
+	} else if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	email := validate.NormalizeEmail(identity.Email)
+	v := validate.New()
+	v.Email("email", email)
+	if !identity.EmailVerified || v.Err() != nil {
+		return 0, ErrProviderEmailUnverified
+	}
+
+	name := identity.Name
+	v.Name("name", name, validate.MaxNameLength)
+	if v.Failed("name") {
+		name = "New User"
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var verifyID sql.NullInt64
+	err = tx.QueryRow(
+		`SELECT id, verifyid FROM USER WHERE email = ?`,
+		email).Scan(&userid, &verifyID)
+
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := tx.Exec(
+			`INSERT INTO USER (email, password, name, avatar, verified, created)
+			VALUES (?, '', ?, 'default.png', 1, ?)`,
+			email, name, time.Now().UTC())
+
+		if err != nil {
+			return 0, err
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		userid = int(id)
+
+	case err != nil:
+		return 0, err
+
+	case verifyID.Valid:
+		_, err = tx.Exec(
+			`UPDATE USER
+			SET verifyid = NULL, verified = 1, password = '', legacy_password = 0
+			WHERE id = ?`,
+			userid)
+
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(`DELETE FROM VERIFY_USER WHERE id = ?`, verifyID.Int64)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO USER_IDENTITY (userid, provider, subject, email, created)
+		VALUES (?, ?, ?, ?, ?)`,
+		userid, identity.Provider, identity.Subject, email, time.Now())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return userid, tx.Commit()
+}
@@ -0,0 +1,122 @@
+package users
+
+import (
+	"brickedup/backend/oidc"
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestLoginOIDC(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// A new email provisions an account without a password
+	identity := &oidc.Identity{
+		Provider:      "corp",
+		Subject:       "corp-42",
+		Email:         "New.Hire@Example.com",
+		EmailVerified: true,
+		Name:          "New Hire",
+	}
+	session, _, err := LoginOIDC(db, identity, false, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("LoginOIDC returned error: %v", err)
+	}
+
+	var email, name, password string
+	var verified bool
+	err = db.QueryRow("SELECT email, name, password, verified FROM USER WHERE id = ?", session.UserID).Scan(&email, &name, &password, &verified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email != "new.hire@example.com" || name != "New Hire" || password != "" || !verified {
+		t.Errorf("unexpected account %q %q %q %v", email, name, password, verified)
+	}
+
+	// Accounts without a password cannot log in with one
+	_, _, err = Login(db, "new.hire@example.com", "", false, "127.0.0.1", "test-agent")
+	if err != ErrInvalidCredentials {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+
+	// The identity is recognized by subject, even if the email changed
+	identity.Email = "renamed@example.com"
+	again, _, err := LoginOIDC(db, identity, false, "127.0.0.1", "test-agent")
+	if err != nil || again.UserID != session.UserID {
+		t.Errorf("expected login as user %d, got %v (%v)", session.UserID, again, err)
+	}
+
+	// The same subject at another provider is another identity
+	identity.Provider = "other"
+	identity.Email = "john.doe@example.com"
+	other, _, err := LoginOIDC(db, identity, false, "127.0.0.1", "test-agent")
+	if err != nil || other.UserID != 1 {
+		t.Errorf("expected the identity to be linked to John Doe by email, got %v (%v)", other, err)
+	}
+
+	// John can still log in with his password
+	_, _, err = Login(db, "john.doe@example.com", "hashed_password_1", false, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Errorf("expected password login to keep working, got %v", err)
+	}
+}
+
+func TestLoginOIDCUnverifiedEmail(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	identity := &oidc.Identity{Provider: "corp", Subject: "corp-1", Email: "john.doe@example.com"}
+	_, _, err := LoginOIDC(db, identity, false, "127.0.0.1", "test-agent")
+	if err != ErrProviderEmailUnverified {
+		t.Errorf("expected ErrProviderEmailUnverified, got %v", err)
+	}
+
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM USER_IDENTITY").Scan(&count)
+	if count != 0 {
+		t.Errorf("expected no identity to be linked, got %d", count)
+	}
+}
+
+// TestLoginOIDCUnverifiedAccount checks that accounts nobody verified are
+// taken over by the owner of the email, without the password set at signup.
+func TestLoginOIDCUnverifiedAccount(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Sarah Williams (4) never verified her email
+	identity := &oidc.Identity{Provider: "corp", Subject: "corp-4", Email: "sarah.williams@example.com", EmailVerified: true}
+	session, _, err := LoginOIDC(db, identity, false, "127.0.0.1", "test-agent")
+	if err != nil || session.UserID != 4 {
+		t.Fatalf("expected login as user 4, got %v (%v)", session, err)
+	}
+
+	var password string
+	var verified bool
+	var verifyID *int
+	err = db.QueryRow("SELECT password, verified, verifyid FROM USER WHERE id = 4").Scan(&password, &verified, &verifyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != "" || !verified || verifyID != nil {
+		t.Errorf("expected verified account without password, got %q %v %v", password, verified, verifyID)
+	}
+}
+
+func TestLoginOIDCWithTOTP(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	enableTOTP(t, db, 1)
+
+	identity := &oidc.Identity{Provider: "corp", Subject: "corp-1", Email: "john.doe@example.com", EmailVerified: true}
+	session, challenge, err := LoginOIDC(db, identity, false, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session != nil || challenge == nil || challenge.Challenge == "" {
+		t.Errorf("expected an MFA challenge, got %v, %v", session, challenge)
+	}
+}
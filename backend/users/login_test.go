@@ -12,30 +12,52 @@ func TestLogin(t *testing.T) {
 	defer db.Close()
 
 	// Test valid login
-	session, err := Login(db, "john.doe@example.com", "hashed_password_1")
+	session, _, err := Login(db, "john.doe@example.com", "hashed_password_1", false, "127.0.0.1", "test-agent")
     if err != nil {
         t.Fatal(err)
     }
 
-	if session.SessionID < 0 {
+	if session.SessionID == "" {
         t.Fatalf("Valid login failed: invalid sessionid")
     }
 
     // Test invalid password
-    _, err = Login(db, "user1@example.com", "wrongpassword")
-    if err == nil {
-        t.Fatal("Invalid password failed: should not be logged in")
+    _, _, err = Login(db, "jane.smith@example.com", "wrongpassword", false, "127.0.0.1", "test-agent")
+    if err != ErrInvalidCredentials {
+        t.Fatalf("Invalid password failed: expected ErrInvalidCredentials, got %v", err)
     }
 
 	// Test non-existent user
-	_, err = Login(db, "nouser@example.com", "testpassword")
-    if err == nil {
-        t.Fatal("Non-existent user failed: should not be logged in")
+	_, _, err = Login(db, "nouser@example.com", "testpassword", false, "127.0.0.1", "test-agent")
+    if err != ErrInvalidCredentials {
+        t.Fatalf("Non-existent user failed: expected ErrInvalidCredentials, got %v", err)
     }
 
 	// Test unverified user
-	_, err = Login(db, "unverified@example.com", "password3")
+	_, _, err = Login(db, "unverified@example.com", "password3", false, "127.0.0.1", "test-agent")
     if err == nil {
         t.Fatal("Unverified user failed: should not be logged in")
     }
 }
+
+// TestLoginUnverified checks that unverified users are told so only when
+// they know the password.
+func TestLoginUnverified(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, err := db.Exec(`UPDATE USER SET verifyid = 1, verified = 0 WHERE id = 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = Login(db, "john.doe@example.com", "hashed_password_1", false, "", "")
+	if err != ErrEmailNotVerified {
+		t.Errorf("expected ErrEmailNotVerified, got %v", err)
+	}
+
+	_, _, err = Login(db, "john.doe@example.com", "wrongpassword", false, "", "")
+	if err != ErrInvalidCredentials {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
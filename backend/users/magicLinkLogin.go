@@ -0,0 +1,85 @@
+package users
+
+import (
+	"brickedup/backend/sessions"
+	"brickedup/backend/utils"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+var (
+	// ErrInvalidMagicLink is returned for login links that are unknown,
+	// expired or were already used.
+	ErrInvalidMagicLink = errors.New("invalid or expired login link")
+
+	// ErrWrongBrowser is returned when a login link is opened in another
+	// browser than the one it was requested from.
+	ErrWrongBrowser = errors.New("login link was requested from another browser")
+)
+
+// MagicLinkLogin logs in with the token of a link sent by RequestMagicLink,
+// in the browser holding `browser`. Each link logs in once. Every attempt is
+// recorded in the login audit log.
+// Like Login, it returns the session, or a challenge if the user has
+// two-factor authentication enabled.
+func MagicLinkLogin(db *sql.DB, token string, browser string, ip, userAgent string) (*utils.SessionData, *utils.MFAChallenge, error) {
+	now := time.Now()
+
+	var id, userid int
+	var browserHash, email string
+	var remember, totpEnabled bool
+	err := db.QueryRow(
+		`SELECT m.id, m.userid, m.browser, m.remember, u.email, u.totp_enabled
+		FROM MAGIC_LINK m
+		JOIN USER u ON u.id = m.userid
+		WHERE m.token = ? AND m.expires > ? AND m.used IS NULL AND u.deactivated IS NULL`,
+		utils.HashToken(token), now).Scan(&id, &userid, &browserHash, &remember, &email, &totpEnabled)
+
+	if err == sql.ErrNoRows {
+		err = recordLoginAttempt(db, methodMagicLink, "", 0, ip, userAgent, false, reasonInvalidLink)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidMagicLink
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if browser == "" || subtle.ConstantTimeCompare([]byte(utils.HashToken(browser)), []byte(browserHash)) != 1 {
+		err = recordLoginAttempt(db, methodMagicLink, email, userid, ip, userAgent, false, reasonWrongBrowser)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrWrongBrowser
+	}
+
+	// Use up the link, unless a concurrent request did already
+	res, err := db.Exec(
+		`UPDATE MAGIC_LINK SET used = ? WHERE id = ? AND used IS NULL`,
+		now, id)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	// The login of 2FA users is recorded once they entered their code
+	if totpEnabled {
+		challenge, err := createMFAChallenge(db, userid, remember, methodMagicLink)
+		return nil, challenge, err
+	}
+
+	err = recordLoginAttempt(db, methodMagicLink, email, userid, ip, userAgent, true, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := sessions.CreateSession(db, userid, remember, ip, userAgent)
+	return session, nil, err
+}
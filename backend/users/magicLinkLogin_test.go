@@ -0,0 +1,111 @@
+package users
+
+import (
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// requestMagicLink requests a login link for the user and returns its token.
+func requestMagicLink(t *testing.T, db *sql.DB, email string, browser string) string {
+	t.Helper()
+	outbox := mailtest.Record(t)
+	if err := RequestMagicLink(db, email, false, browser, "127.0.0.1", "test-agent"); err != nil {
+		t.Fatal(err)
+	}
+	return magicLinkToken(t, outbox.Messages()[0])
+}
+
+// lastLoginAttempt returns the method, success and reason of the last
+// recorded login attempt.
+func lastLoginAttempt(t *testing.T, db *sql.DB) (string, bool, string) {
+	t.Helper()
+	var method, reason string
+	var success bool
+	err := db.QueryRow(
+		`SELECT method, success, reason FROM LOGIN_ATTEMPT ORDER BY id DESC LIMIT 1`).Scan(&method, &success, &reason)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return method, success, reason
+}
+
+func TestMagicLinkLogin(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	token := requestMagicLink(t, db, "jane.smith@example.com", "browser-secret")
+
+	// A forwarded link does not work in another browser
+	for _, browser := range []string{"", "other-browser"} {
+		_, _, err := MagicLinkLogin(db, token, browser, "10.0.0.1", "other-agent")
+		if err != ErrWrongBrowser {
+			t.Errorf("browser %q: expected ErrWrongBrowser, got %v", browser, err)
+		}
+	}
+	if method, success, reason := lastLoginAttempt(t, db); method != methodMagicLink || success || reason != reasonWrongBrowser {
+		t.Errorf("expected audited wrong browser, got %s %v %s", method, success, reason)
+	}
+
+	session, challenge, err := MagicLinkLogin(db, token, "browser-secret", "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("MagicLinkLogin returned error: %v", err)
+	}
+	if challenge != nil || session == nil || session.UserID != 2 {
+		t.Fatalf("expected a session of Jane, got %+v, %+v", session, challenge)
+	}
+	if method, success, _ := lastLoginAttempt(t, db); method != methodMagicLink || !success {
+		t.Errorf("expected audited success, got %s %v", method, success)
+	}
+
+	// Links are single-use
+	_, _, err = MagicLinkLogin(db, token, "browser-secret", "127.0.0.1", "test-agent")
+	if err != ErrInvalidMagicLink {
+		t.Errorf("expected ErrInvalidMagicLink on reuse, got %v", err)
+	}
+	if _, _, reason := lastLoginAttempt(t, db); reason != reasonInvalidLink {
+		t.Errorf("expected audited invalid link, got %s", reason)
+	}
+}
+
+func TestMagicLinkLoginExpired(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	token := requestMagicLink(t, db, "jane.smith@example.com", "browser-secret")
+	_, err := db.Exec("UPDATE MAGIC_LINK SET expires = ?", time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = MagicLinkLogin(db, token, "browser-secret", "127.0.0.1", "test-agent")
+	if err != ErrInvalidMagicLink {
+		t.Errorf("expected ErrInvalidMagicLink, got %v", err)
+	}
+}
+
+func TestMagicLinkLoginWithTOTP(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	enableTOTP(t, db, 2)
+	token := requestMagicLink(t, db, "jane.smith@example.com", "browser-secret")
+
+	session, challenge, err := MagicLinkLogin(db, token, "browser-secret", "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session != nil || challenge == nil {
+		t.Fatalf("expected an MFA challenge, got %+v, %+v", session, challenge)
+	}
+
+	var method string
+	err = db.QueryRow("SELECT method FROM MFA_CHALLENGE WHERE userid = 2").Scan(&method)
+	if err != nil || method != methodMagicLink {
+		t.Errorf("expected the challenge to remember the login method, got %q (%v)", method, err)
+	}
+}
@@ -0,0 +1,24 @@
+package users
+
+import (
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// MFARequired reports whether the user has to enable two-factor
+// authentication first, because an organization they are a member of
+// requires it and they have not.
+func MFARequired(db *sql.DB, userid int) (bool, error) {
+	var required bool
+	err := db.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM ORG_MEMBER om
+			JOIN ORGANIZATION o ON o.id = om.orgid
+			JOIN USER u ON u.id = om.userid
+			WHERE om.userid = ? AND o.require_2fa = 1 AND u.totp_enabled = 0
+		)`,
+		userid).Scan(&required)
+
+	return required, err
+}
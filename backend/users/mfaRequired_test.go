@@ -0,0 +1,34 @@
+package users
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestMFARequired(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, err := db.Exec(`UPDATE ORGANIZATION SET require_2fa = 1 WHERE id = 3`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mike Johnson (3) and Alex Brown (5) are members of organization 3
+	for userid, want := range map[int]bool{1: false, 3: true, 5: true} {
+		required, err := MFARequired(db, userid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if required != want {
+			t.Errorf("user %d: expected %v, got %v", userid, want, required)
+		}
+	}
+
+	enableTOTP(t, db, 3)
+	if required, _ := MFARequired(db, 3); required {
+		t.Error("2FA is still required after enabling it")
+	}
+}
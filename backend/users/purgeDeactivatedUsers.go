@@ -0,0 +1,55 @@
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// PurgeDeactivatedUsers anonymizes the accounts that were deactivated more
+// than `days` days ago and not reactivated since. It returns how many
+// accounts were anonymized. Accounts whose user is still the only admin of
+// an organization are skipped, and reported with ErrLastAdmin once all
+// others are done.
+func PurgeDeactivatedUsers(db *sql.DB, days int) (int, error) {
+	rows, err := db.Query(
+		`SELECT id FROM USER
+		WHERE deactivated < ? AND anonymized = 0`,
+		time.Now().AddDate(0, 0, -days))
+
+	if err != nil {
+		return 0, err
+	}
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var n int
+	var skipped error
+	for _, userID := range userIDs {
+		err := anonymizeUser(db, userID)
+		if errors.Is(err, ErrLastAdmin) {
+			skipped = err
+			continue
+		} else if err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	return n, skipped
+}
@@ -0,0 +1,56 @@
+package users
+
+import (
+	"brickedup/backend/blobs/blobstest"
+	"brickedup/backend/utils"
+	"errors"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestPurgeDeactivatedUsers(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	blobstest.Temp(t)
+
+	deactivateUser(t, db, 3)
+
+	n, err := PurgeDeactivatedUsers(db, 30)
+	if err != nil || n != 0 {
+		t.Fatalf("expected nothing to be anonymized within the grace period, got %d, %v", n, err)
+	}
+
+	// John was deactivated while the only admin, which DeactivateUser
+	// refuses; he is skipped
+	_, err = db.Exec(
+		`UPDATE USER SET deactivated = ? WHERE id IN (1, 3)`,
+		time.Now().AddDate(0, 0, -31))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err = PurgeDeactivatedUsers(db, 30)
+	if !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("expected ErrLastAdmin for the skipped account, got %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected one account to be anonymized, got %d", n)
+	}
+
+	var anonymized []int
+	rows, err := db.Query(`SELECT id FROM USER WHERE anonymized = 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		anonymized = append(anonymized, id)
+	}
+	if len(anonymized) != 1 || anonymized[0] != 3 {
+		t.Errorf("expected Mike to be anonymized, got %v", anonymized)
+	}
+}
@@ -0,0 +1,63 @@
+package users
+
+import (
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// PurgeUnverifiedUsers deletes the accounts that were created, or last signed
+// up for, more than `days` days ago and never verified. It returns how many
+// accounts were deleted. With `days` set to zero nothing is deleted.
+func PurgeUnverifiedUsers(db *sql.DB, days int) (int, error) {
+	if days <= 0 {
+		return 0, nil
+	}
+
+	rows, err := db.Query(
+		`SELECT id, verifyid FROM USER
+		WHERE verified = 0 AND created < ?`,
+		time.Now().UTC().AddDate(0, 0, -days))
+
+	if err != nil {
+		return 0, err
+	}
+
+	var userIDs []int
+	var verifyIDs []sql.NullInt64
+	for rows.Next() {
+		var userID int
+		var verifyID sql.NullInt64
+		if err := rows.Scan(&userID, &verifyID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		userIDs = append(userIDs, userID)
+		verifyIDs = append(verifyIDs, verifyID)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, userID := range userIDs {
+		if err := DeleteUser(db, userID); err != nil {
+			return i, err
+		}
+
+		if verifyIDs[i].Valid {
+			_, err := db.Exec(
+				`DELETE FROM VERIFY_USER WHERE id = ?`,
+				verifyIDs[i].Int64)
+
+			if err != nil {
+				return i, err
+			}
+		}
+	}
+
+	return len(userIDs), nil
+}
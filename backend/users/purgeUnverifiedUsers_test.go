@@ -0,0 +1,50 @@
+package users
+
+import (
+	"brickedup/backend/utils"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestPurgeUnverifiedUsers(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Sarah Williams (4) signed up long ago, Alex Brown (5) just now,
+	// and John Doe (1) is old but verified
+	_, err := db.Exec(`UPDATE USER SET created = ? WHERE id IN (1, 4)`,
+		time.Now().UTC().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := PurgeUnverifiedUsers(db, 0)
+	if err != nil || n != 0 {
+		t.Fatalf("purge with zero days deleted %d accounts (%v)", n, err)
+	}
+
+	n, err = PurgeUnverifiedUsers(db, 7)
+	if err != nil {
+		t.Fatalf("PurgeUnverifiedUsers returned error: %v", err)
+	}
+
+	if n != 1 {
+		t.Errorf("expected 1 purged account, got %d", n)
+	}
+
+	for id, want := range map[int]int{1: 1, 4: 0, 5: 1} {
+		var count int
+		db.QueryRow(`SELECT COUNT(*) FROM USER WHERE id = ?`, id).Scan(&count)
+		if count != want {
+			t.Errorf("user %d: expected %d rows, got %d", id, want, count)
+		}
+	}
+
+	var codes int
+	db.QueryRow(`SELECT COUNT(*) FROM VERIFY_USER WHERE id = 1`).Scan(&codes)
+	if codes != 0 {
+		t.Error("verification code of the purged account was kept")
+	}
+}
@@ -0,0 +1,102 @@
+package users
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrInvalidReactivation is returned for reactivation links that are
+// unknown or whose grace period is over.
+var ErrInvalidReactivation = errors.New("invalid or expired reactivation link")
+
+// RequestReactivation emails a new reactivation link to the deactivated
+// account with the given email, at most once per reactivationInterval.
+// To not reveal which emails are registered or deactivated, other emails
+// are not treated as an error.
+func RequestReactivation(db *sql.DB, email string) error {
+	email = validate.NormalizeEmail(email)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userid int
+	var deactivated time.Time
+	var sent sql.NullTime
+	err = tx.QueryRow(
+		`SELECT u.id, u.deactivated, r.created
+		FROM USER u
+		LEFT JOIN REACTIVATION r ON r.userid = u.id
+		WHERE u.email = ? AND u.deactivated IS NOT NULL AND u.anonymized = 0`,
+		email).Scan(&userid, &deactivated, &sent)
+
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if sent.Valid && time.Since(sent.Time) < reactivationInterval {
+		return nil
+	}
+
+	deadline := deactivated.AddDate(0, 0, DeactivationGraceDays)
+	if !deadline.After(time.Now()) {
+		return nil
+	}
+
+	token, err := createReactivation(tx, userid, deadline)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return sendReactivationEmail(db, userid, email, token, deadline)
+}
+
+// ReactivateUser reactivates the deactivated account the link with `token`
+// was sent for, after which the user can log in again. Sessions, API tokens
+// and links revoked by the deactivation stay revoked.
+func ReactivateUser(db *sql.DB, token string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userid int
+	err = tx.QueryRow(
+		`SELECT r.userid
+		FROM REACTIVATION r
+		JOIN USER u ON u.id = r.userid
+		WHERE r.token = ? AND r.expires > ? AND u.anonymized = 0`,
+		utils.HashToken(token), time.Now()).Scan(&userid)
+
+	if err == sql.ErrNoRows {
+		return ErrInvalidReactivation
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE USER SET deactivated = NULL WHERE id = ?`, userid)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`DELETE FROM REACTIVATION WHERE userid = ?`, userid)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,89 @@
+package users
+
+import (
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestReactivateUser(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	token := deactivateUser(t, db, 3)
+
+	if err := ReactivateUser(db, token); err != nil {
+		t.Fatalf("ReactivateUser returned error: %v", err)
+	}
+	if _, _, err := Login(db, "mike.johnson@example.com", "hashed_password_3", false, "", ""); err != nil {
+		t.Errorf("login after reactivation failed: %v", err)
+	}
+
+	// The link is single-use
+	if err := ReactivateUser(db, token); err != ErrInvalidReactivation {
+		t.Errorf("expected ErrInvalidReactivation on reuse, got %v", err)
+	}
+}
+
+func TestReactivateUserExpired(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	token := deactivateUser(t, db, 3)
+
+	_, err := db.Exec(`UPDATE REACTIVATION SET expires = ?`, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ReactivateUser(db, token); err != ErrInvalidReactivation {
+		t.Errorf("expected ErrInvalidReactivation, got %v", err)
+	}
+	if err = ReactivateUser(db, "unknown"); err != ErrInvalidReactivation {
+		t.Errorf("expected ErrInvalidReactivation for an unknown token, got %v", err)
+	}
+}
+
+func TestRequestReactivation(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	old := deactivateUser(t, db, 3)
+	outbox := mailtest.Record(t)
+
+	// Within reactivationInterval of the deactivation nothing is sent
+	if err := RequestReactivation(db, "Mike.Johnson@example.com"); err != nil {
+		t.Fatalf("RequestReactivation returned error: %v", err)
+	}
+	if len(outbox.Messages()) != 0 {
+		t.Fatalf("expected no email, got %d", len(outbox.Messages()))
+	}
+
+	_, err := db.Exec(`UPDATE REACTIVATION SET created = ?`, time.Now().Add(-2*reactivationInterval))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = RequestReactivation(db, "mike.johnson@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := reactivationToken(t, outbox)
+
+	if err = ReactivateUser(db, old); err != ErrInvalidReactivation {
+		t.Errorf("expected the old link to be replaced, got %v", err)
+	}
+	if err = ReactivateUser(db, token); err != nil {
+		t.Errorf("ReactivateUser returned error: %v", err)
+	}
+
+	// Unknown and active accounts get nothing
+	for _, email := range []string{"unknown@example.com", "jane.smith@example.com"} {
+		if err = RequestReactivation(db, email); err != nil {
+			t.Errorf("%s: expected no error, got %v", email, err)
+		}
+	}
+	if len(outbox.Messages()) != 1 {
+		t.Errorf("expected no more emails, got %d", len(outbox.Messages()))
+	}
+}
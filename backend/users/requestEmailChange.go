@@ -0,0 +1,135 @@
+package users
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/preferences"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// RequestEmailChange stages `newEmail` as the new address of the user, once
+// `password` proves that the user and not just their session is asking.
+// A confirmation link goes to the new address and a notice to the current
+// one; the address only changes with ConfirmEmailChange. A new request
+// replaces the pending one.
+// Wrong passwords count towards the same limits as failed logins and fail
+// with ErrInvalidCredentials or, once throttled, ErrTooManyAttempts.
+func RequestEmailChange(db *sql.DB, userid int, password string, newEmail string, ip, userAgent string) error {
+	newEmail = validate.NormalizeEmail(newEmail)
+
+	var email, hash string
+	var legacy bool
+	err := db.QueryRow(
+		`SELECT email, password, legacy_password FROM USER WHERE id = ?`,
+		userid).Scan(&email, &hash, &legacy)
+
+	if err != nil {
+		return err
+	}
+
+	v := validate.New()
+	v.Email("email", newEmail)
+	if !v.Failed("email") && newEmail == email {
+		v.Fail("email", validate.CodeInvalid, "is already the address of the account")
+	}
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	wait, err := LoginRetryAfter(db, email, ip)
+	if err != nil {
+		return err
+	}
+
+	if wait > 0 {
+		err = recordLoginAttempt(db, methodEmailChange, email, userid, ip, userAgent, false, reasonThrottled)
+		if err != nil {
+			return err
+		}
+		return ErrTooManyAttempts
+	}
+
+	matches, err := comparePassword(db, userid, hash, legacy, password)
+	if err != nil {
+		return err
+	}
+
+	if !matches {
+		err = recordLoginAttempt(db, methodEmailChange, email, userid, ip, userAgent, false, reasonWrongPassword)
+		if err != nil {
+			return err
+		}
+		return ErrInvalidCredentials
+	}
+
+	err = recordLoginAttempt(db, methodEmailChange, email, userid, ip, userAgent, true, "")
+	if err != nil {
+		return err
+	}
+
+	// Unverified accounts holding the address give way on confirmation
+	var taken bool
+	err = db.QueryRow(
+		`SELECT EXISTS (SELECT 1 FROM USER WHERE email = ? AND verifyid IS NULL)`,
+		newEmail).Scan(&taken)
+
+	if err != nil {
+		return err
+	}
+
+	if taken {
+		return ErrEmailTaken
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expires := now.Add(emailChangeLifetime)
+
+	_, err = db.Exec(`DELETE FROM EMAIL_CHANGE WHERE userid = ?`, userid)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO EMAIL_CHANGE (userid, email, token, created, expires)
+		VALUES (?, ?, ?, ?, ?)`,
+		userid, newEmail, utils.HashToken(token), now, expires)
+
+	if err != nil {
+		return err
+	}
+
+	err = preferences.SendTemplate(db, userid, newEmail, mail.TemplateEmailChange, mail.EmailChangeData{
+		Link:    mail.Link("/confirm-email", url.Values{"token": {token}}),
+		Expires: expires,
+	})
+	if err != nil {
+		return err
+	}
+
+	return preferences.SendTemplate(db, userid, email, mail.TemplateEmailNotice, mail.EmailNoticeData{
+		Email: newEmail,
+		Link:  mail.Link("/forgot-password", nil),
+	})
+}
+
+// EmailChangeRetryAfter returns how long the client at `ip` has to wait
+// before it may try to change the email of the user again.
+func EmailChangeRetryAfter(db *sql.DB, userid int, ip string) (time.Duration, error) {
+	var email string
+	err := db.QueryRow(`SELECT email FROM USER WHERE id = ?`, userid).Scan(&email)
+	if err != nil {
+		return 0, err
+	}
+
+	return LoginRetryAfter(db, email, ip)
+}
@@ -0,0 +1,139 @@
+package users
+
+import (
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// emailChangeRegex finds the confirmation link in the text of an email.
+var emailChangeRegex = regexp.MustCompile(`\S+/confirm-email\?token=\S+`)
+
+// requestEmailChange asks to change the email of John Doe and returns the
+// token of the confirmation link.
+func requestEmailChange(t *testing.T, db *sql.DB, newEmail string) string {
+	t.Helper()
+	outbox := mailtest.Record(t)
+	err := RequestEmailChange(db, 1, "hashed_password_1", newEmail, "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := url.Parse(emailChangeRegex.FindString(outbox.Messages()[0].Text))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("no confirmation link in %q", outbox.Messages()[0].Text)
+	}
+	return link.Query().Get("token")
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	err := RequestEmailChange(db, 1, "hashed_password_1", " John@Example.org ", "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("RequestEmailChange returned error: %v", err)
+	}
+
+	sent := outbox.Messages()
+	if len(sent) != 2 {
+		t.Fatalf("expected two emails, got %d", len(sent))
+	}
+	if sent[0].To != "john@example.org" || !emailChangeRegex.MatchString(sent[0].Text) {
+		t.Errorf("expected the confirmation link at the new address, got %+v", sent[0])
+	}
+	if sent[1].To != "john.doe@example.com" || emailChangeRegex.MatchString(sent[1].Text) {
+		t.Errorf("expected a notice without the link at the old address, got %+v", sent[1])
+	}
+
+	// Nothing changes before the confirmation
+	var email string
+	if err = db.QueryRow(`SELECT email FROM USER WHERE id = 1`).Scan(&email); err != nil {
+		t.Fatal(err)
+	}
+	if email != "john.doe@example.com" {
+		t.Errorf("email changed before the confirmation: %s", email)
+	}
+
+	// A new request replaces the pending one
+	requestEmailChange(t, db, "john@example.net")
+	var pending int
+	if err = db.QueryRow(`SELECT COUNT(*) FROM EMAIL_CHANGE WHERE userid = 1`).Scan(&pending); err != nil {
+		t.Fatal(err)
+	}
+	if pending != 1 {
+		t.Errorf("expected one pending change, got %d", pending)
+	}
+}
+
+func TestRequestEmailChangeRejected(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		want     error
+		code     string
+	}{
+		{"invalid email", "hashed_password_1", "john", nil, validate.CodeInvalid},
+		{"same email", "hashed_password_1", "John.Doe@example.com", nil, validate.CodeInvalid},
+		{"wrong password", "wrongpassword", "john@example.org", ErrInvalidCredentials, ""},
+		{"verified account", "hashed_password_1", "jane.smith@example.com", ErrEmailTaken, ""},
+	}
+	for _, tt := range tests {
+		err := RequestEmailChange(db, 1, tt.password, tt.email, "127.0.0.1", "test-agent")
+		var errs validate.Errors
+		if tt.code != "" {
+			if !errors.As(err, &errs) || errs[0].Field != "email" || errs[0].Code != tt.code {
+				t.Errorf("%s: expected email to be %s, got %v", tt.name, tt.code, err)
+			}
+		} else if err != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	if len(outbox.Messages()) != 0 {
+		t.Errorf("expected no emails, got %d", len(outbox.Messages()))
+	}
+
+	if method, success, reason := lastLoginAttempt(t, db); method != methodEmailChange || !success || reason != "" {
+		t.Errorf("expected the last password check to be audited, got %s %v %s", method, success, reason)
+	}
+}
+
+// TestRequestEmailChangeThrottled checks that a session cannot be used to
+// guess the password.
+func TestRequestEmailChangeThrottled(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	mailtest.Record(t)
+
+	var err error
+	for range freeLoginAttempts {
+		err = RequestEmailChange(db, 1, "wrongpassword", "john@example.org", "127.0.0.1", "test-agent")
+	}
+	if err != ErrInvalidCredentials {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+
+	err = RequestEmailChange(db, 1, "hashed_password_1", "john@example.org", "127.0.0.1", "test-agent")
+	if err != ErrTooManyAttempts {
+		t.Errorf("expected ErrTooManyAttempts, got %v", err)
+	}
+
+	_, _, err = Login(db, "john.doe@example.com", "hashed_password_1", false, "127.0.0.1", "test-agent")
+	if err != ErrTooManyAttempts {
+		t.Errorf("expected logins to be throttled as well, got %v", err)
+	}
+}
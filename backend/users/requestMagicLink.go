@@ -0,0 +1,64 @@
+package users
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/preferences"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// RequestMagicLink emails a single-use login link to the verified account
+// with the given email. The link only works in the browser holding
+// `browser`, a secret the caller keeps in a cookie, so a forwarded link is
+// of no use to anyone else.
+// To not reveal which emails are registered, unknown, unverified and
+// deactivated emails as well as requests throttled by requestThrottled,
+// within magicLinkInterval of the last one to the address or past the limit
+// of the IP address, succeed without sending anything.
+func RequestMagicLink(db *sql.DB, email string, remember bool, browser string, ip, userAgent string) error {
+	email = validate.NormalizeEmail(email)
+
+	throttled, err := requestThrottled(db, methodMagicLinkRequest, email, ip, userAgent, magicLinkInterval)
+	if err != nil || throttled {
+		return err
+	}
+
+	var userid int
+	err = db.QueryRow(
+		`SELECT id FROM USER WHERE email = ? AND verifyid IS NULL AND deactivated IS NULL`,
+		email).Scan(&userid)
+
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	expires := now.Add(magicLinkLifetime)
+	_, err = db.Exec(
+		`INSERT INTO MAGIC_LINK (userid, token, browser, remember, ip, user_agent, created, expires)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		userid, utils.HashToken(token), utils.HashToken(browser), remember,
+		ip, userAgent, now, expires)
+
+	if err != nil {
+		return err
+	}
+
+	return preferences.SendTemplate(db, userid, email, mail.TemplateMagicLink, mail.MagicLinkData{
+		Link:    mail.Link("/magic-link", url.Values{"token": {token}}),
+		Expires: expires,
+	})
+}
@@ -0,0 +1,111 @@
+package users
+
+import (
+	"brickedup/backend/mail"
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// magicLinkRegex finds the login link in the text of an email.
+var magicLinkRegex = regexp.MustCompile(`\S+/magic-link\?token=\S+`)
+
+// magicLinkToken returns the token of the login link in the message.
+func magicLinkToken(t *testing.T, msg mail.Message) string {
+	t.Helper()
+	link, err := url.Parse(magicLinkRegex.FindString(msg.Text))
+	if err != nil || link.Query().Get("token") == "" {
+		t.Fatalf("no login link in %q", msg.Text)
+	}
+	return link.Query().Get("token")
+}
+
+func TestRequestMagicLink(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	err := RequestMagicLink(db, " John.Doe@example.com", true, "browser-secret", "127.0.0.1", "test-agent")
+	if err != nil {
+		t.Fatalf("RequestMagicLink returned error: %v", err)
+	}
+
+	sent := outbox.Messages()
+	if len(sent) != 1 || sent[0].To != "john.doe@example.com" {
+		t.Fatalf("expected one email to John, got %+v", sent)
+	}
+	token := magicLinkToken(t, sent[0])
+
+	var storedToken, browser string
+	var remember bool
+	var expires time.Time
+	err = db.QueryRow("SELECT token, browser, remember, expires FROM MAGIC_LINK WHERE userid = 1").Scan(&storedToken, &browser, &remember, &expires)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if storedToken != utils.HashToken(token) || browser != utils.HashToken("browser-secret") || !remember {
+		t.Errorf("expected hashed token and browser secret, got %q %q %v", storedToken, browser, remember)
+	}
+	if time.Until(expires) > magicLinkLifetime {
+		t.Errorf("expected link to expire within %v, got %v", magicLinkLifetime, expires)
+	}
+
+	// Another request right away sends nothing
+	err = RequestMagicLink(db, "john.doe@example.com", false, "browser-secret", "127.0.0.1", "test-agent")
+	if err != nil || len(outbox.Messages()) != 1 {
+		t.Errorf("expected no second email, got %d (%v)", len(outbox.Messages()), err)
+	}
+
+	// Neither do unknown or unverified emails, without telling the caller
+	for _, email := range []string{"nouser@example.com", "sarah.williams@example.com"} {
+		err = RequestMagicLink(db, email, false, "browser-secret", "127.0.0.1", "test-agent")
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", email, err)
+		}
+	}
+	if len(outbox.Messages()) != 1 {
+		t.Errorf("expected no emails to unknown or unverified accounts, got %d", len(outbox.Messages()))
+	}
+}
+
+// TestRequestMagicLinkPerIP checks that a client cannot ask for login links
+// to any number of addresses, and that the requests are recorded.
+func TestRequestMagicLinkPerIP(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	for i := 0; i < sendIPLimit; i++ {
+		email := "nouser" + strconv.Itoa(i) + "@example.com"
+		if err := RequestMagicLink(db, email, false, "browser-secret", "203.0.113.9", ""); err != nil {
+			t.Fatalf("%s: unexpected error %v", email, err)
+		}
+	}
+
+	err := RequestMagicLink(db, "john.doe@example.com", false, "browser-secret", "203.0.113.9", "")
+	if err != nil || len(outbox.Messages()) != 0 {
+		t.Errorf("expected the address to be throttled, got %d emails (%v)", len(outbox.Messages()), err)
+	}
+
+	err = RequestMagicLink(db, "john.doe@example.com", false, "browser-secret", "198.51.100.4", "")
+	if err != nil || len(outbox.Messages()) != 1 {
+		t.Errorf("expected other clients not to be throttled, got %d emails (%v)", len(outbox.Messages()), err)
+	}
+
+	var requests, throttled int
+	err = db.QueryRow(
+		`SELECT COUNT(*), COALESCE(SUM(reason = ?), 0) FROM LOGIN_ATTEMPT WHERE method = ?`,
+		reasonThrottled, methodMagicLinkRequest).Scan(&requests, &throttled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != sendIPLimit+2 || throttled != 1 {
+		t.Errorf("expected %d requests with 1 throttled in the audit log, got %d with %d", sendIPLimit+2, requests, throttled)
+	}
+}
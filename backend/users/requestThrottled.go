@@ -0,0 +1,41 @@
+package users
+
+import (
+	"brickedup/backend/validate"
+	"database/sql"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// requestThrottled records the request from `ip` for an email of `method`
+// to `email` and reports whether it has to be refused: when one was sent to
+// the address within `interval`, or the IP address already requested
+// sendIPLimit emails within sendIPWindow. Nothing about the account is
+// looked at, so the answer is the same for registered and unknown emails.
+func requestThrottled(db *sql.DB, method string, email string, ip string, userAgent string, interval time.Duration) (bool, error) {
+	email = validate.NormalizeEmail(email)
+	now := time.Now().UTC()
+
+	var throttled bool
+	err := db.QueryRow(
+		`SELECT EXISTS (
+			SELECT 1 FROM LOGIN_ATTEMPT
+			WHERE method = ? AND success = 1 AND email = ? AND created > ?)
+		OR (SELECT COUNT(*) FROM LOGIN_ATTEMPT
+			WHERE method = ? AND success = 1 AND ip = ? AND ip != '' AND created > ?) >= ?`,
+		method, email, now.Add(-interval),
+		method, ip, now.Add(-sendIPWindow), sendIPLimit).Scan(&throttled)
+
+	if err != nil {
+		return false, err
+	}
+
+	reason := ""
+	if throttled {
+		reason = reasonThrottled
+	}
+
+	err = recordLoginAttempt(db, method, email, 0, ip, userAgent, !throttled, reason)
+	return throttled, err
+}
@@ -0,0 +1,117 @@
+package users
+
+import (
+	"brickedup/backend/validate"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrResendThrottled is returned when a verification email was sent to the
+// account less than ResendInterval ago.
+var ErrResendThrottled = errors.New("verification email was sent too recently")
+
+// refreshVerificationCode gives the unverified user a new verification code
+// valid for verificationLifetime and returns it. The code replaces the
+// current one, `verifyID`, which may be NULL or point to a deleted row.
+// `passwordHash` becomes the password of the account once the code is used;
+// if empty, the one of the current code is kept.
+func refreshVerificationCode(db *sql.DB, userID int, verifyID sql.NullInt64, passwordHash string) (string, error) {
+	// Creation times are kept in UTC, like CURRENT_TIMESTAMP
+	now := time.Now().UTC()
+	code := generateVerificationCode()
+
+	if verifyID.Valid {
+		var recent int
+		err := db.QueryRow(
+			`SELECT COUNT(*) FROM VERIFY_USER
+			WHERE id = ? AND created > ?`,
+			verifyID.Int64, now.Add(-ResendInterval)).Scan(&recent)
+
+		if err != nil {
+			return "", err
+		}
+
+		if recent > 0 {
+			return "", ErrResendThrottled
+		}
+
+		res, err := db.Exec(
+			`UPDATE VERIFY_USER
+			SET code = ?, expires = ?, created = ?, password = COALESCE(NULLIF(?, ''), password)
+			WHERE id = ?`,
+			code, now.Add(verificationLifetime), now, passwordHash, verifyID.Int64)
+
+		if err != nil {
+			return "", err
+		}
+
+		if n, _ := res.RowsAffected(); n > 0 {
+			return code, nil
+		}
+	}
+
+	res, err := db.Exec(
+		`INSERT INTO VERIFY_USER (code, expires, created, password)
+		VALUES (?, ?, ?, NULLIF(?, ''))`,
+		code, now.Add(verificationLifetime), now, passwordHash)
+
+	if err != nil {
+		return "", err
+	}
+	newID, _ := res.LastInsertId()
+
+	_, err = db.Exec(
+		`UPDATE USER
+		SET verifyid = ?
+		WHERE id = ?`,
+		newID, userID)
+
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// ResendVerification emails a new verification code to the unverified
+// account with the given email, requested from `ip`. To not reveal which
+// emails are registered, unknown and already verified emails are not
+// treated as an error, and requests are throttled with ErrResendThrottled
+// per address and IP address whether or not the email is registered.
+func ResendVerification(db *sql.DB, email string, ip string, userAgent string) error {
+	email = validate.NormalizeEmail(email)
+
+	throttled, err := requestThrottled(db, methodResendVerification, email, ip, userAgent, ResendInterval)
+	if err != nil {
+		return err
+	}
+	if throttled {
+		return ErrResendThrottled
+	}
+
+	var userID int
+	var verifyID sql.NullInt64
+	err = db.QueryRow(
+		`SELECT id, verifyid FROM USER
+		WHERE email = ? AND verified = 0`,
+		email).Scan(&userID, &verifyID)
+
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// A code sent at signup moments ago is not resent, without telling
+	code, err := refreshVerificationCode(db, userID, verifyID, "")
+	if err == ErrResendThrottled {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return sendVerificationEmail(email, code)
+}
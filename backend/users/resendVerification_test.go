@@ -0,0 +1,130 @@
+package users
+
+import (
+	"brickedup/backend/mail/mailtest"
+	"brickedup/backend/utils"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestResendVerification(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	// Sarah Williams (user 4) is unverified and was just sent code 123456,
+	// which is not resent without telling
+	err := ResendVerification(db, "sarah.williams@example.com", "", "")
+	if err != nil || len(outbox.Messages()) != 0 {
+		t.Fatalf("expected no error and no email, got %v, %d emails", err, len(outbox.Messages()))
+	}
+
+	past := time.Now().UTC().Add(-ResendInterval - time.Second)
+	_, err = db.Exec(`UPDATE VERIFY_USER SET created = ? WHERE id = 1`, past)
+	if err == nil {
+		_, err = db.Exec(`UPDATE LOGIN_ATTEMPT SET created = ?`, past)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ResendVerification(db, "sarah.williams@example.com", "", "")
+	if err != nil {
+		t.Fatalf("ResendVerification returned error: %v", err)
+	}
+
+	var code string
+	err = db.QueryRow(
+		`SELECT vu.code FROM VERIFY_USER vu
+		INNER JOIN USER u ON u.verifyid = vu.id
+		WHERE u.id = 4`).Scan(&code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code == "123456" {
+		t.Error("verification code was not replaced")
+	}
+
+	sent := outbox.Messages()
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "code="+code) {
+		t.Fatalf("expected one email with the new code, got %v", sent)
+	}
+
+	if err = ResendVerification(db, "sarah.williams@example.com", "", ""); err != ErrResendThrottled {
+		t.Errorf("expected ErrResendThrottled, got %v", err)
+	}
+
+	// Unknown and verified emails look the same as a successful resend,
+	// throttling included
+	for _, email := range []string{"nouser@example.com", "john.doe@example.com"} {
+		if err := ResendVerification(db, email, "", ""); err != nil {
+			t.Errorf("%s: unexpected error %v", email, err)
+		}
+		if err := ResendVerification(db, email, "", ""); err != ErrResendThrottled {
+			t.Errorf("%s: expected ErrResendThrottled, got %v", email, err)
+		}
+	}
+
+	if len(outbox.Messages()) != 1 {
+		t.Error("email sent to an unknown or verified account")
+	}
+}
+
+// TestResendVerificationPerIP checks that a client cannot ask for emails to
+// any number of addresses.
+func TestResendVerificationPerIP(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	mailtest.Record(t)
+
+	for i := 0; i < sendIPLimit; i++ {
+		email := "nouser" + strconv.Itoa(i) + "@example.com"
+		if err := ResendVerification(db, email, "203.0.113.9", ""); err != nil {
+			t.Fatalf("%s: unexpected error %v", email, err)
+		}
+	}
+
+	if err := ResendVerification(db, "another@example.com", "203.0.113.9", ""); err != ErrResendThrottled {
+		t.Errorf("expected ErrResendThrottled, got %v", err)
+	}
+	if err := ResendVerification(db, "another@example.com", "198.51.100.4", ""); err != nil {
+		t.Errorf("expected other clients not to be throttled, got %v", err)
+	}
+}
+
+// TestResendVerificationMissingCode checks that users whose code was deleted
+// by older versions can still get a new one.
+func TestResendVerificationMissingCode(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	mailtest.Record(t)
+
+	_, err := db.Exec(`DELETE FROM VERIFY_USER WHERE id = 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Alex Brown (user 5) still points to the deleted code
+	err = ResendVerification(db, "alex.brown@example.com", "", "")
+	if err != nil {
+		t.Fatalf("ResendVerification returned error: %v", err)
+	}
+
+	var code string
+	err = db.QueryRow(
+		`SELECT vu.code FROM VERIFY_USER vu
+		INNER JOIN USER u ON u.verifyid = vu.id
+		WHERE u.id = 5`).Scan(&code)
+	if err != nil {
+		t.Fatalf("user has no verification code: %v", err)
+	}
+
+	if err := VerifyUser(code, db); err != nil {
+		t.Errorf("new code does not verify the user: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package users
+
+import (
+	"brickedup/backend/passwords"
+	"brickedup/backend/sessions"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrInvalidResetToken is returned when a password reset token is unknown,
+// expired or was already used.
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// ResetPassword sets a new password for the user the reset token was issued
+// to. All reset tokens of the user are invalidated and all of their sessions
+// are revoked, so the new password has to be used to log in again.
+func ResetPassword(db *sql.DB, token string, newPassword string) error {
+	// Remove expired reset tokens
+	_, err := db.Exec(
+		`DELETE FROM FORGOT_PASSWORD
+		WHERE expirationdate <= ?`,
+		time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	// Check if the reset token is valid and has not expired
+	code := utils.HashToken(token)
+	var userid int
+	var email string
+	err = db.QueryRow(
+		`SELECT f.userid, u.email
+		FROM FORGOT_PASSWORD f
+		JOIN USER u ON u.id = f.userid
+		WHERE f.code = ? AND f.expirationdate > ?`,
+		code, time.Now()).Scan(&userid, &email)
+
+	if err == sql.ErrNoRows {
+		return ErrInvalidResetToken
+	} else if err != nil {
+		return err
+	}
+
+	v := validate.New()
+	passwords.Check(v, "password", newPassword, email)
+	if err := v.Err(); err != nil {
+		return err
+	}
+
+	// Hashing is slow, so it is done before the transaction
+	hash, err := passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The token is consumed first, so only one of concurrent resets with it
+	// can set a password
+	res, err := tx.Exec(
+		`DELETE FROM FORGOT_PASSWORD
+		WHERE code = ? AND userid = ? AND expirationdate > ?`,
+		code, userid, time.Now())
+
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n != 1 {
+		return ErrInvalidResetToken
+	}
+
+	_, err = tx.Exec(
+		`UPDATE USER SET password = ?, legacy_password = 0 WHERE id = ?`,
+		hash, userid)
+
+	if err != nil {
+		return err
+	}
+
+	// All other outstanding tokens are invalidated too
+	_, err = tx.Exec(
+		`DELETE FROM FORGOT_PASSWORD WHERE userid = ?`,
+		userid)
+
+	if err != nil {
+		return err
+	}
+
+	err = sessions.RevokeUserSessions(tx, userid)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
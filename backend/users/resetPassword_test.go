@@ -0,0 +1,97 @@
+package users
+
+import (
+	"brickedup/backend/sessions"
+	"brickedup/backend/utils"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestResetPassword(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// Jane Smith (userid 2) has the reset token "reset-2" in populate.sql
+	other, err := createResetToken(db, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ResetPassword(db, "reset-2", "brandnewpassword")
+	if err != nil {
+		t.Fatalf("ResetPassword returned error: %v", err)
+	}
+
+	_, _, err = Login(db, "jane.smith@example.com", "brandnewpassword", false, "", "")
+	if err != nil {
+		t.Errorf("login with the new password failed: %v", err)
+	}
+
+	// Existing sessions are revoked
+	if _, err = sessions.GetSession(db, "session-2"); err == nil {
+		t.Error("existing session was not revoked")
+	}
+
+	// The token is single-use and other outstanding tokens are invalidated
+	for _, token := range []string{"reset-2", other} {
+		err = ResetPassword(db, token, "anotherpassword")
+		if err != ErrInvalidResetToken {
+			t.Errorf("expected ErrInvalidResetToken, got %v", err)
+		}
+	}
+}
+
+// TestResetPasswordConcurrent checks that of two resets racing with the
+// same token, only one sets a password.
+func TestResetPasswordConcurrent(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	// Like the server, so both look up the token before either hashed
+	db.SetMaxOpenConns(1)
+
+	errs := make(chan error, 2)
+	for _, password := range []string{"brandnewpassword", "anotherpassword"} {
+		go func() { errs <- ResetPassword(db, "reset-2", password) }()
+	}
+
+	var failed int
+	for range 2 {
+		if err := <-errs; err == ErrInvalidResetToken {
+			failed++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if failed != 1 {
+		t.Errorf("expected exactly one reset to be refused, got %d", failed)
+	}
+}
+
+func TestResetPasswordInvalid(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+	}{
+		{name: "Expired token", token: "reset-1", password: "newpassword"},
+		{name: "Unknown token", token: "not-a-token", password: "newpassword"},
+		{name: "Hash instead of token", token: utils.HashToken("reset-2"), password: "newpassword"},
+		{name: "Missing password", token: "reset-2", password: ""},
+		{name: "Short password", token: "reset-2", password: "short"},
+		{name: "Common password", token: "reset-2", password: "password123"},
+		{name: "Password with email", token: "reset-2", password: "jane.smith@example.com"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ResetPassword(db, tc.token, tc.password)
+			if err == nil {
+				t.Error("expected an error but got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package users
+
+import (
+	"brickedup/backend/avatars"
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrNotMember is returned when restricting a search to an organization or
+// project the viewer is not a member of.
+var ErrNotMember = errors.New("not a member of the organization or project")
+
+// maxSearchLength is the longest search query accepted.
+const maxSearchLength = 100
+
+// likeEscaper escapes the wildcards of LIKE patterns, with \ as the
+// escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchCursor is the position after the last user of a page, in the order
+// of search results.
+type searchCursor struct {
+	Name string `json:"n"`
+	ID   int    `json:"i"`
+}
+
+// encodeCursor returns the opaque cursor of the page after the user.
+func encodeCursor(name string, id int) string {
+	data, _ := json.Marshal(searchCursor{name, id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the position encoded in a cursor, or nil if it is
+// not one.
+func decodeCursor(cursor string) *searchCursor {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil
+	}
+
+	var c searchCursor
+	if json.Unmarshal(data, &c) != nil || c.ID <= 0 {
+		return nil
+	}
+	return &c
+}
+
+// canSearchIn reports whether the viewer may list the members of the
+// organization or project of the search: members of an organization may,
+// and members of a project or of the organization it belongs to.
+func canSearchIn(db *sql.DB, viewer int, search utils.UserSearch) (bool, error) {
+	var allowed bool
+	var err error
+
+	switch {
+	case search.OrgID != 0:
+		err = db.QueryRow(
+			`SELECT EXISTS (
+				SELECT 1 FROM ORG_MEMBER WHERE userid = ? AND orgid = ?
+			)`,
+			viewer, search.OrgID).Scan(&allowed)
+
+	case search.ProjectID != 0:
+		err = db.QueryRow(
+			`SELECT EXISTS (
+				SELECT 1 FROM PROJECT_MEMBER WHERE userid = ? AND projectid = ?
+			) OR EXISTS (
+				SELECT 1 FROM PROJECT p
+				JOIN ORG_MEMBER m ON m.orgid = p.orgid
+				WHERE m.userid = ? AND p.id = ?
+			)`,
+			viewer, search.ProjectID, viewer, search.ProjectID).Scan(&allowed)
+
+	default:
+		allowed = true
+	}
+
+	return allowed, err
+}
+
+// SearchUsers returns a page of the active users matching the search, as
+// seen by `viewer`, ordered by name. Emails are only shown, and matched,
+// for the viewer themselves and the users sharing an organization or
+// project with them. Restricting the search to an organization or project
+// the viewer is not a member of fails with ErrNotMember.
+func SearchUsers(db *sql.DB, viewer int, search utils.UserSearch) (*utils.UserPage, error) {
+	v := validate.New()
+	if search.Query != "" {
+		v.Line("q", search.Query, maxSearchLength)
+	}
+	if search.OrgID != 0 && search.ProjectID != 0 {
+		v.Fail("projectid", validate.CodeInvalid, "cannot be combined with orgid")
+	}
+
+	limit := search.Limit
+	if limit == 0 {
+		limit = min(SearchPageSize, maxSearchPageSize)
+	} else if limit < 0 || limit > maxSearchPageSize {
+		v.Fail("limit", validate.CodeInvalid, "must be between 1 and %d", maxSearchPageSize)
+	}
+
+	var cursor *searchCursor
+	if search.Cursor != "" {
+		cursor = decodeCursor(search.Cursor)
+		if cursor == nil {
+			v.Fail("cursor", validate.CodeInvalid, "is not a valid cursor")
+		}
+	}
+
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	allowed, err := canSearchIn(db, viewer, search)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrNotMember
+	}
+
+	// Prefixes match the start of any word of the name
+	term := likeEscaper.Replace(search.Query)
+	match := `(u.name LIKE ? ESCAPE '\' OR (u.id IN visible AND u.email LIKE ? ESCAPE '\'))`
+	args := []any{viewer, viewer, viewer, "%" + term + "%", "%" + term + "%"}
+	if search.Prefix {
+		match = `(u.name LIKE ? ESCAPE '\' OR u.name LIKE ? ESCAPE '\'
+			OR (u.id IN visible AND u.email LIKE ? ESCAPE '\'))`
+		args = []any{viewer, viewer, viewer, term + "%", "% " + term + "%", term + "%"}
+	}
+
+	query := `WITH visible(id) AS (` + visibleEmails + `)
+		SELECT u.id, u.name, COALESCE(u.avatar, ''), u.email, u.id IN visible
+		FROM USER u
+		WHERE u.verified = 1 AND u.deactivated IS NULL AND u.anonymized = 0
+		AND ` + match
+
+	if search.OrgID != 0 {
+		query += ` AND u.id IN (SELECT userid FROM ORG_MEMBER WHERE orgid = ?)`
+		args = append(args, search.OrgID)
+	}
+	if search.ProjectID != 0 {
+		query += ` AND u.id IN (SELECT userid FROM PROJECT_MEMBER WHERE projectid = ?)`
+		args = append(args, search.ProjectID)
+	}
+	if cursor != nil {
+		query += ` AND (u.name > ? COLLATE NOCASE OR (u.name = ? COLLATE NOCASE AND u.id > ?))`
+		args = append(args, cursor.Name, cursor.Name, cursor.ID)
+	}
+
+	// One more than the page tells whether there is a next one
+	query += ` ORDER BY u.name COLLATE NOCASE, u.id LIMIT ?`
+	args = append(args, limit+1)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page := &utils.UserPage{Users: []utils.UserSummary{}}
+	for rows.Next() {
+		var user utils.UserSummary
+		var visible bool
+		err := rows.Scan(&user.ID, &user.Name, &user.Avatar, &user.Email, &visible)
+		if err != nil {
+			return nil, err
+		}
+
+		user.Avatar = avatars.URL(user.ID, user.Avatar)
+		if !visible {
+			user.Email = ""
+		}
+		page.Users = append(page.Users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Users) > limit {
+		page.Users = page.Users[:limit]
+		last := page.Users[limit-1]
+		page.Next = encodeCursor(last.Name, last.ID)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,162 @@
+package users
+
+import (
+	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// addOutsider adds Zoe Quinn, a verified user sharing no organization or
+// project with anyone, and returns her ID.
+func addOutsider(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	res, err := db.Exec(
+		`INSERT INTO USER (email, password, name, verified)
+		VALUES ('zoe.quinn@example.org', '', 'Zoe Quinn', 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	return int(id)
+}
+
+// names returns the names of the users of a page.
+func names(page *utils.UserPage) []string {
+	var names []string
+	for _, user := range page.Users {
+		names = append(names, user.Name)
+	}
+	return names
+}
+
+func TestSearchUsers(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	zoe := addOutsider(t, db)
+
+	page, err := SearchUsers(db, 1, utils.UserSearch{Limit: 2})
+	if err != nil {
+		t.Fatalf("SearchUsers returned error: %v", err)
+	}
+	if want := []string{"Jane Smith", "John Doe"}; !slices.Equal(names(page), want) || page.Next == "" {
+		t.Fatalf("expected %v and a next page, got %v %q", want, names(page), page.Next)
+	}
+	if page.Users[0].Email != "jane.smith@example.com" || page.Users[0].Avatar != "/avatar?userid=2" {
+		t.Errorf("expected the email and avatar of a fellow member, got %+v", page.Users[0])
+	}
+
+	// Unverified users are not listed
+	page, err = SearchUsers(db, 1, utils.UserSearch{Limit: 2, Cursor: page.Next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Mike Johnson", "Zoe Quinn"}; !slices.Equal(names(page), want) || page.Next != "" {
+		t.Fatalf("expected %v on the last page, got %v %q", want, names(page), page.Next)
+	}
+	if page.Users[1].Email != "" {
+		t.Errorf("expected the email of a stranger to be hidden, got %s", page.Users[1].Email)
+	}
+
+	page, err = SearchUsers(db, zoe, utils.UserSearch{Query: "Zoe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Users) != 1 || page.Users[0].Email != "zoe.quinn@example.org" {
+		t.Errorf("expected users to see their own email, got %+v", page.Users)
+	}
+}
+
+func TestSearchUsersMatch(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	zoe := addOutsider(t, db)
+
+	tests := []struct {
+		viewer int
+		query  string
+		prefix bool
+		want   []string
+	}{
+		{1, "jo", false, []string{"John Doe", "Mike Johnson"}},
+		{1, "oe", false, []string{"John Doe", "Zoe Quinn"}},
+		{1, "oe", true, nil},
+		{1, "doe", true, []string{"John Doe"}},
+		{1, "JANE.SMITH@", true, []string{"Jane Smith"}},
+		{1, "%", false, nil},
+		// Hidden emails do not match either
+		{1, "example.org", false, nil},
+		{zoe, "example.org", false, []string{"Zoe Quinn"}},
+	}
+	for _, tt := range tests {
+		page, err := SearchUsers(db, tt.viewer, utils.UserSearch{Query: tt.query, Prefix: tt.prefix})
+		if err != nil {
+			t.Fatalf("%q: SearchUsers returned error: %v", tt.query, err)
+		}
+		if !slices.Equal(names(page), tt.want) {
+			t.Errorf("%q (prefix %v): expected %v, got %v", tt.query, tt.prefix, tt.want, names(page))
+		}
+	}
+}
+
+func TestSearchUsersRestricted(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	page, err := SearchUsers(db, 2, utils.UserSearch{OrgID: 2})
+	if err != nil {
+		t.Fatalf("SearchUsers returned error: %v", err)
+	}
+	if want := []string{"Jane Smith"}; !slices.Equal(names(page), want) {
+		t.Errorf("expected %v, got %v", want, names(page))
+	}
+
+	// Members of the organization may search its projects
+	page, err = SearchUsers(db, 1, utils.UserSearch{ProjectID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Mike Johnson"}; !slices.Equal(names(page), want) {
+		t.Errorf("expected %v, got %v", want, names(page))
+	}
+
+	if _, err = SearchUsers(db, 1, utils.UserSearch{OrgID: 2}); err != ErrNotMember {
+		t.Errorf("expected ErrNotMember, got %v", err)
+	}
+	if _, err = SearchUsers(db, 1, utils.UserSearch{ProjectID: 4}); err != ErrNotMember {
+		t.Errorf("expected ErrNotMember, got %v", err)
+	}
+
+	// Deactivated users are not listed
+	if _, err = db.Exec(`UPDATE USER SET deactivated = CURRENT_TIMESTAMP WHERE id = 3`); err != nil {
+		t.Fatal(err)
+	}
+	page, err = SearchUsers(db, 1, utils.UserSearch{ProjectID: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Users) != 0 {
+		t.Errorf("expected no users, got %v", names(page))
+	}
+}
+
+func TestSearchUsersInvalid(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, err := SearchUsers(db, 1, utils.UserSearch{
+		OrgID:     1,
+		ProjectID: 1,
+		Cursor:    "not a cursor",
+		Limit:     maxSearchPageSize + 1,
+	})
+
+	var errs validate.Errors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("expected errors for projectid, limit and cursor, got %v", err)
+	}
+}
@@ -1,21 +1,22 @@
 package users
 
 import (
-	"brickedup/backend/utils"
+	"brickedup/backend/mail"
+	"brickedup/backend/passwords"
+	"brickedup/backend/validate"
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
-	"fmt"
-	"log"
-	"os"
+	"errors"
+	"net/url"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
-	"gopkg.in/gomail.v2"
-
 	_ "modernc.org/sqlite" // SQLite driver for database/sql
 )
 
+// ErrEmailTaken is returned when signing up with the email of a verified account.
+var ErrEmailTaken = errors.New("email is already registered")
+
 // GenerateVerificationCode generates a random hex-encoded code
 func generateVerificationCode() string {
 	bytes := make([]byte, 16)
@@ -23,89 +24,73 @@ func generateVerificationCode() string {
 	return hex.EncodeToString(bytes)
 }
 
-// SendVerificationEmail sends an email using gomail with a verification code
-func sendVerificationEmail(to string, code string) {
-	email := os.Getenv("EMAIL")
-	password := os.Getenv("PASS")
-	host := os.Getenv("HOST")
-	port := os.Getenv("PORT")
-
-	verify_link := fmt.Sprintf("http://%s%s/verify?code=%s", host, port, code)
-
-	m := gomail.NewMessage()
-	m.SetHeader("From", email)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", "Account Verification")
-	m.SetBody("text/html", fmt.Sprintf(`
-		<p>
-			Click the link to verify your account!
-		</p>
-		<p>
-			<a href="%s">Verify</a>
-		</p>
-		<p>
-			If you cannot open the link, paste this into a new tab:
-		</p>
-		<p>
-			<quote>%s</quote>
-		</p>
-		`, verify_link, verify_link))
-
-	/* email is sent to user without implementation, but I did not
-	know how to implement sending the email without smtp or server access */
-	d := gomail.NewDialer("smtp.gmail.com", 587, email, password)
-	if err := d.DialAndSend(m); err != nil {
-		log.Println("Failed to send email:", err)
-	}
+// SendVerificationEmail emails the verification link to the user
+func sendVerificationEmail(to string, code string) error {
+	return mail.SendTemplate(to, mail.TemplateVerification, mail.VerificationData{
+		Link: mail.Link("/verify", url.Values{"code": {code}}),
+	})
 }
 
-// Singup handles user registration
+// Singup handles user registration. Signing up again with an email that was
+// never verified sends a new verification code. The account keeps its
+// password until the code is used, which proves the password was chosen by
+// the owner of the email, so nobody can set up an account for someone else
+// with a password they know.
 func Signup(db *sql.DB, email, password string) error {
-	sanitizedEmail := utils.SanitizeText(email, utils.EMAIL)
-	sanitizedPassword := utils.SanitizeText(password, utils.PASSWORD)
+	email = validate.NormalizeEmail(email)
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(sanitizedPassword), bcrypt.DefaultCost)
-	if err != nil {
+	v := validate.New()
+	v.Email("email", email)
+	passwords.Check(v, "password", password, email)
+	if err := v.Err(); err != nil {
 		return err
 	}
 
-	// Insert user into database
-	res, err := db.Exec(
-		`INSERT INTO USER (email, password, name, avatar) 
-		VALUES (?, ?, 'New User', 'default.png')`, 
-		sanitizedEmail, passwordHash)
+	var userID int
+	var verified bool
+	var verifyID sql.NullInt64
+	err := db.QueryRow(
+		`SELECT id, verified, verifyid FROM USER WHERE email = ?`,
+		email).Scan(&userID, &verified, &verifyID)
+
+	if err == nil && verified {
+		return ErrEmailTaken
+	} else if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	exists := err == nil
 
+	passwordHash, err := passwords.Hash(password)
 	if err != nil {
 		return err
 	}
-	userID, _ := res.LastInsertId()
 
-	// Generate verification code
-	code := generateVerificationCode()
+	if exists {
+		code, err := refreshVerificationCode(db, userID, verifyID, passwordHash)
+		if err != nil {
+			return err
+		}
+
+		return sendVerificationEmail(email, code)
+	}
 
-	// Insert verification record into VERIFY_USER table
-	res, err = db.Exec(
-		`INSERT INTO VERIFY_USER (code, expires)
-		VALUES (?, ?)`, 
-		code, time.Now().Add(24*time.Hour))
+	// Insert user into database
+	res, err := db.Exec(
+		`INSERT INTO USER (email, password, name, avatar, created) 
+		VALUES (?, ?, 'New User', 'default.png', ?)`, 
+		email, passwordHash, time.Now().UTC())
 
 	if err != nil {
 		return err
 	}
-	verifyID, _ := res.LastInsertId()
-
-	// Update USER to link verification ID
-	_, err = db.Exec(
-		`UPDATE USER 
-		SET verifyid = ? 
-		WHERE id = ?`, 
-		verifyID, userID)
+	newID, _ := res.LastInsertId()
 
+	// Generate verification code
+	code, err := refreshVerificationCode(db, int(newID), sql.NullInt64{}, passwordHash)
 	if err != nil {
 		return err
 	}
 
 	// Send verification email
-	sendVerificationEmail(email, code)
-	return nil
+	return sendVerificationEmail(email, code)
 }
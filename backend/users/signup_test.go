@@ -1,7 +1,9 @@
 package users
 
 import (
+	"brickedup/backend/mail/mailtest"
 	"brickedup/backend/utils"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 func TestRegisterUser(t *testing.T) {
 	db := utils.SetupTest(t)
 	defer db.Close()
+	outbox := mailtest.Record(t)
 
 	// Test user registration
 	email := "test@example.com"
@@ -45,6 +48,85 @@ func TestRegisterUser(t *testing.T) {
 	if expire.Before(time.Now()) {
 		t.Errorf("Verification code should not be expired")
 	}
+
+	// Check the verification email
+	sent := outbox.Messages()
+	if len(sent) != 1 || sent[0].To != email {
+		t.Fatalf("expected one email to %s, got %v", email, sent)
+	}
+	if !strings.Contains(sent[0].HTML, "/verify?code="+code) {
+		t.Errorf("email does not contain the verification link")
+	}
+}
+
+// TestSignupAgain checks that signing up again with an unverified email
+// refreshes the code instead of failing, and that verified emails are refused.
+func TestSignupAgain(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	var oldPassword string
+	var oldCreated time.Time
+	db.QueryRow(`SELECT password, created FROM USER WHERE id = 5`).Scan(&oldPassword, &oldCreated)
+
+	// Alex Brown (user 5) is unverified and was just sent a code
+	err := Signup(db, "alex.brown@example.com", "newpassword")
+	if err != ErrResendThrottled {
+		t.Fatalf("expected ErrResendThrottled, got %v", err)
+	}
+
+	_, err = db.Exec(`UPDATE VERIFY_USER SET created = ? WHERE id = 2`,
+		time.Now().UTC().Add(-ResendInterval-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Signup(db, "alex.brown@example.com", "newpassword")
+	if err != nil {
+		t.Fatalf("Signup with an unverified email failed: %v", err)
+	}
+
+	var users int
+	db.QueryRow(`SELECT COUNT(*) FROM USER WHERE email = 'alex.brown@example.com'`).Scan(&users)
+	if users != 1 {
+		t.Errorf("expected 1 account, got %d", users)
+	}
+
+	var code string
+	err = db.QueryRow(
+		`SELECT vu.code FROM VERIFY_USER vu
+		INNER JOIN USER u ON u.verifyid = vu.id
+		WHERE u.id = 5`).Scan(&code)
+	if err != nil || code == "234567" {
+		t.Fatalf("verification code was not refreshed: %q %v", code, err)
+	}
+
+	if sent := outbox.Messages(); len(sent) != 1 {
+		t.Fatalf("expected one email, got %d", len(sent))
+	}
+
+	// Nothing about the account changes before the code is used, so signing
+	// up again does not postpone the purge of unverified accounts
+	var password string
+	var created time.Time
+	db.QueryRow(`SELECT password, created FROM USER WHERE id = 5`).Scan(&password, &created)
+	if password != oldPassword || !created.Equal(oldCreated) {
+		t.Errorf("expected the account to be unchanged, got %q created %v", password, created)
+	}
+
+	// The new password is used once the account is verified
+	if err := VerifyUser(code, db); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Login(db, "alex.brown@example.com", "newpassword", false, "", ""); err != nil {
+		t.Errorf("login with the new password failed: %v", err)
+	}
+
+	err = Signup(db, "john.doe@example.com", "correct horse battery")
+	if err != ErrEmailTaken {
+		t.Errorf("expected ErrEmailTaken, got %v", err)
+	}
 }
 
 // TestGenerateVerificationCode checks the validity of generated codes
@@ -56,3 +138,35 @@ func TestGenerateVerificationCode(t *testing.T) {
 		t.Errorf("Generated codes should be unique, but got identical codes")
 	}
 }
+
+// TestSignupPreHijack checks that whoever signs up first with an address
+// cannot set the password of the account its owner verifies.
+func TestSignupPreHijack(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	outbox := mailtest.Record(t)
+
+	if err := Signup(db, "victim@example.com", "correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := db.Exec(`UPDATE VERIFY_USER SET created = ?`, time.Now().UTC().Add(-ResendInterval-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Signup(db, "victim@example.com", "purple monkey dishwasher"); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := outbox.Messages()
+	i := strings.Index(sent[1].Text, "code=")
+	if err = VerifyUser(strings.Fields(sent[1].Text[i+len("code="):])[0], db); err != nil {
+		t.Fatalf("VerifyUser returned error: %v", err)
+	}
+
+	if _, _, err = Login(db, "victim@example.com", "correct horse battery", false, "", ""); err != ErrInvalidCredentials {
+		t.Errorf("expected the first password to be replaced, got %v", err)
+	}
+	if _, _, err = Login(db, "victim@example.com", "purple monkey dishwasher", false, "", ""); err != nil {
+		t.Errorf("login with the owner's password failed: %v", err)
+	}
+}
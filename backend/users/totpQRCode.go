@@ -0,0 +1,35 @@
+package users
+
+import (
+	"brickedup/backend/totp"
+	"database/sql"
+	"errors"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrTOTPNotEnrolled is returned when the user has no TOTP secret to confirm.
+var ErrTOTPNotEnrolled = errors.New("two-factor authentication is not being set up")
+
+// TOTPQRCode returns the otpauth:// URI of the secret being enrolled as a
+// PNG QR code of `size` pixels, for authenticator apps to scan.
+func TOTPQRCode(db *sql.DB, userid int, size int) ([]byte, error) {
+	var email string
+	var secret sql.NullString
+	err := db.QueryRow(
+		`SELECT email, totp_secret FROM USER
+		WHERE id = ? AND totp_enabled = 0`,
+		userid).Scan(&email, &secret)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrTOTPEnabled
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !secret.Valid {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	return totp.QRCode(totp.URI(totpIssuer, email, secret.String), size)
+}
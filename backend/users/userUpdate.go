@@ -1,45 +1,50 @@
 package users
 
 import (
+	"brickedup/backend/passwords"
+	"brickedup/backend/sessions"
 	"brickedup/backend/utils"
+	"brickedup/backend/validate"
 	"database/sql"
-	"errors"
-	"strconv"
 
 	_ "modernc.org/sqlite"
 )
 
-// UpdateUser retrieves the user ID from the SESSION table (by sessionID)
-// and updates the user based on the new values provided.
-func UpdateUser(db *sql.DB, sessionID int, user *utils.User) error {
-	// Sanitize newName
-	user.Name 		= utils.SanitizeText(user.Name, utils.TEXT)
-	user.Email 		= utils.SanitizeText(user.Email, utils.EMAIL)
-	user.Password 	= utils.SanitizeText(user.Password, utils.PASSWORD)
-
-	// Look up the userID in the SESSION table.
-	var userID int
-	err := db.QueryRow("SELECT userid FROM SESSION WHERE id = ?", sessionID).Scan(&userID)
+// UpdateUser updates the user with the given ID based on the new values provided.
+// The email cannot be changed here, see RequestEmailChange; an empty one
+// keeps the current address. The avatar is uploaded with avatars.Upload.
+// If the password is changed and revokeSessions is set, every session of the
+// user except currentSession is revoked.
+func UpdateUser(db *sql.DB, userID int, user *utils.User, revokeSessions bool, currentSession int) error {
+	var email string
+	err := db.QueryRow(`SELECT email FROM USER WHERE id = ?`, userID).Scan(&email)
 	if err != nil {
-		// If no row is found, return a custom error message.
-		if err == sql.ErrNoRows {
-			return errors.New("no session found for session ID " + strconv.Itoa(sessionID))
-		}
-		// Otherwise, return the original error from the DB.
+		return err
+	}
+
+	// Validate the new values, which are stored as entered
+	v := validate.New()
+	v.Name("name", user.Name, validate.MaxNameLength)
+	if user.Email != "" && validate.NormalizeEmail(user.Email) != email {
+		v.Fail("email", validate.CodeInvalid, "can only be changed by confirming the new address")
+	}
+	user.Email = email
+	if user.Password != "" {
+		passwords.Check(v, "password", user.Password, user.Email)
+	}
+	if err := v.Err(); err != nil {
 		return err
 	}
 
 	// Update the user’s display name in the USER table.
 	query := `
 	UPDATE USER 
-	SET name = ?, avatar = ?, email = ?
+	SET name = ?
 	WHERE id = ?
 	`
 	_, err = db.Exec(
 		query, 
 		user.Name,
-		user.Avatar,
-		user.Email,
 		userID)
 
 	if err != nil {
@@ -47,15 +52,27 @@ func UpdateUser(db *sql.DB, sessionID int, user *utils.User) error {
 	}
 
 	if user.Password != "" {
-		_, err := db.Exec(`
+		hashedPassword, err := passwords.Hash(user.Password)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
 			UPDATE USER
-			SET password = ?
+			SET password = ?, legacy_password = 0
 			WHERE id = ?
-		`, user.Password, user.ID)
+		`, hashedPassword, userID)
 
 		if err != nil {
 			return err
 		}
+
+		if revokeSessions {
+			err = sessions.RevokeOtherSessions(db, userID, currentSession)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
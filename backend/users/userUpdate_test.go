@@ -1,7 +1,10 @@
 package users
 
 import (
+	"brickedup/backend/sessions"
 	"brickedup/backend/utils"
+	"brickedup/backend/validate"
+	"errors"
 	"testing"
 
 	_ "modernc.org/sqlite"
@@ -28,9 +31,9 @@ func TestUpdateUser(t *testing.T) {
     }
 
 	updatedUser := originalUser
-	updatedUser.Name = "Ivan123"
+	updatedUser.Name = "Ivan Petrov"
 
-    err = UpdateUser(db, 1, &updatedUser)
+    err = UpdateUser(db, 1, &updatedUser, false, 0)
     if err != nil {
         t.Errorf("ChangeDisplayName returned error: %v", err)
     }
@@ -44,3 +47,71 @@ func TestUpdateUser(t *testing.T) {
         t.Errorf("name was not changed from '%s' to '%s'", originalUser.Name, updatedName)
     }
 }
+
+// TestUpdateUserPassword checks that a password change stores the new hash
+// and can revoke the other sessions of the user.
+func TestUpdateUserPassword(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	// "session-1" and "session-4" both belong to John Doe (userid 1)
+	current, err := sessions.GetSession(db, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := utils.User{
+		Name:     "John Doe",
+		Email:    "john.doe@example.com",
+		Password: "newpassword",
+	}
+
+	err = UpdateUser(db, 1, &user, true, current.ID)
+	if err != nil {
+		t.Fatalf("UpdateUser returned error: %v", err)
+	}
+
+	_, _, err = Login(db, "john.doe@example.com", "newpassword", false, "", "")
+	if err != nil {
+		t.Errorf("login with the new password failed: %v", err)
+	}
+
+	if _, err = sessions.GetSession(db, "session-1"); err != nil {
+		t.Errorf("current session was revoked: %v", err)
+	}
+
+	if _, err = sessions.GetSession(db, "session-4"); err == nil {
+		t.Error("other session was not revoked")
+	}
+}
+
+// TestUpdateUserEmail checks that the email is not changed without
+// confirming the new address.
+func TestUpdateUserEmail(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	user := utils.User{Name: "John Doe", Email: "john@example.org"}
+	err := UpdateUser(db, 1, &user, false, 0)
+
+	var errs validate.Errors
+	if !errors.As(err, &errs) || errs[0].Field != "email" {
+		t.Errorf("expected the email to be rejected, got %v", err)
+	}
+
+	// Leaving it out or repeating it keeps the current address
+	for _, email := range []string{"", "John.Doe@example.com"} {
+		user := utils.User{Name: "Johnny Doe", Email: email}
+		if err := UpdateUser(db, 1, &user, false, 0); err != nil {
+			t.Errorf("email %q: UpdateUser returned error: %v", email, err)
+		}
+	}
+
+	var email string
+	if err := db.QueryRow(`SELECT email FROM USER WHERE id = 1`).Scan(&email); err != nil {
+		t.Fatal(err)
+	}
+	if email != "john.doe@example.com" {
+		t.Errorf("email was changed to %s", email)
+	}
+}
@@ -2,47 +2,68 @@ package users
 
 import (
 	"database/sql"
+	"errors"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// ErrInvalidVerificationCode is returned when a verification code is unknown
+// or expired. Expired codes can be replaced with ResendVerification.
+var ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+
 // VerifyUser verifies the user's email using the provided verification code.
-// It removes expired codes and sets the user's `verified` field to true.
+// It sets the user's `verified` field to true, sets the password chosen at
+// the signup the code was sent for, and removes the code.
 func VerifyUser(verificationCode string, db *sql.DB) error {
-	// Remove expired verification codes
-	_, err := db.Exec(
-		`DELETE FROM VERIFY_USER 
-		WHERE expires < ?`, 
-		time.Now())
-
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
 	// Check if the verification code exists and is valid
-	var userID int
-	err = db.QueryRow(`
-		SELECT u.id FROM USER u 
-		INNER JOIN VERIFY_USER vu ON u.verifyid = vu.id 
+	var userID, verifyID int
+	var password sql.NullString
+	err = tx.QueryRow(`
+		SELECT u.id, vu.id, vu.password FROM USER u
+		INNER JOIN VERIFY_USER vu ON u.verifyid = vu.id
 		WHERE vu.code = ? AND vu.expires >= ?`,
-		verificationCode, time.Now()).Scan(&userID)
+		verificationCode, time.Now()).Scan(&userID, &verifyID, &password)
 
+	if err == sql.ErrNoRows {
+		return ErrInvalidVerificationCode
+	} else if err != nil {
+		return err
+	}
+
+	// The code is used up first, so it cannot verify twice
+	res, err := tx.Exec(`DELETE FROM VERIFY_USER WHERE id = ?`, verifyID)
 	if err != nil {
 		return err
 	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return ErrInvalidVerificationCode
+	}
 
 	// Mark the user as verified and remove the verifyid
-	_, err = db.Exec(
-		`UPDATE USER 
-		SET verifyid = NULL, 
-		verified = 1 
-		WHERE id = ?`, 
-		userID)
+	if password.Valid {
+		_, err = tx.Exec(
+			`UPDATE USER
+			SET verifyid = NULL, verified = 1, password = ?, legacy_password = 0
+			WHERE id = ?`,
+			password.String, userID)
+	} else {
+		_, err = tx.Exec(
+			`UPDATE USER
+			SET verifyid = NULL, verified = 1
+			WHERE id = ?`,
+			userID)
+	}
 
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
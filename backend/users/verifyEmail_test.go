@@ -4,6 +4,7 @@ import (
 	"brickedup/backend/utils"
 	"database/sql"
 	"testing"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -64,3 +65,26 @@ func TestVerifyUser_FromPopulateSQL(t *testing.T) {
 		})
 	}
 }
+
+// TestVerifyUserExpired checks that expired codes are refused but kept, so
+// the user can ask for a new one.
+func TestVerifyUserExpired(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, err := db.Exec(`UPDATE VERIFY_USER SET expires = ? WHERE id = 1`,
+		time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyUser("123456", db); err != ErrInvalidVerificationCode {
+		t.Errorf("expected ErrInvalidVerificationCode, got %v", err)
+	}
+
+	var codes int
+	db.QueryRow(`SELECT COUNT(*) FROM VERIFY_USER WHERE id = 1`).Scan(&codes)
+	if codes != 1 {
+		t.Error("expired verification code was deleted")
+	}
+}
@@ -0,0 +1,130 @@
+package users
+
+import (
+	"brickedup/backend/sessions"
+	"brickedup/backend/utils"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrInvalidMFAChallenge is returned when an MFA challenge is unknown,
+// expired or had too many wrong codes.
+var ErrInvalidMFAChallenge = errors.New("invalid or expired two-factor challenge")
+
+// createMFAChallenge stores a challenge for the user, who passed the first
+// factor with the login method `method`, and returns it. Only the hash of
+// the challenge is stored.
+func createMFAChallenge(db *sql.DB, userid int, remember bool, method string) (*utils.MFAChallenge, error) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expires := now.Add(mfaChallengeLifetime)
+
+	// Remove expired challenges
+	_, err = db.Exec(`DELETE FROM MFA_CHALLENGE WHERE expires <= ?`, now)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(
+		`INSERT INTO MFA_CHALLENGE (userid, token, remember, method, expires)
+		VALUES (?, ?, ?, ?, ?)`,
+		userid, utils.HashToken(token), remember, method, expires)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &utils.MFAChallenge{
+		MFARequired: true,
+		Challenge:   token,
+		Expires:     expires,
+	}, nil
+}
+
+// VerifyMFA exchanges the challenge returned by Login and a TOTP or recovery
+// code for a session. The challenge ends after maxMFAAttempts wrong codes.
+// Wrong codes count as failed logins of the account, like wrong passwords.
+func VerifyMFA(db *sql.DB, challenge string, code string, ip, userAgent string) (*utils.SessionData, error) {
+	var id, userid int
+	var remember bool
+	var email, method string
+	err := db.QueryRow(
+		`SELECT c.id, c.userid, c.remember, c.method, u.email
+		FROM MFA_CHALLENGE c
+		JOIN USER u ON u.id = c.userid
+		WHERE c.token = ? AND c.expires > ? AND c.attempts < ?`,
+		utils.HashToken(challenge), time.Now(), maxMFAAttempts).Scan(&id, &userid, &remember, &method, &email)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidMFAChallenge
+	} else if err != nil {
+		return nil, err
+	}
+
+	wait, err := LoginRetryAfter(db, email, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	if wait > 0 {
+		err = recordLoginAttempt(db, method, email, userid, ip, userAgent, false, reasonThrottled)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrTooManyAttempts
+	}
+
+	// The attempt is counted before the code is checked, so concurrent
+	// guesses cannot get past maxMFAAttempts
+	res, err := db.Exec(
+		`UPDATE MFA_CHALLENGE SET attempts = attempts + 1
+		WHERE id = ? AND attempts < ?`,
+		id, maxMFAAttempts)
+
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n != 1 {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	ok, err := checkSecondFactor(db, userid, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		err = recordLoginAttempt(db, method, email, userid, ip, userAgent, false, reasonWrong2FACode)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidTOTPCode
+	}
+
+	// A challenge is exchanged for a single session
+	res, err = db.Exec(`DELETE FROM MFA_CHALLENGE WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n != 1 {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	err = recordLoginAttempt(db, method, email, userid, ip, userAgent, true, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions.CreateSession(db, userid, remember, ip, userAgent)
+}
@@ -0,0 +1,166 @@
+package users
+
+import (
+	"brickedup/backend/totp"
+	"brickedup/backend/utils"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestVerifyMFA(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	secret, recovery := enableTOTP(t, db, 1)
+
+	login := func() string {
+		t.Helper()
+
+		session, challenge, err := Login(db, "john.doe@example.com", "hashed_password_1", true, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session != nil || challenge == nil || !challenge.MFARequired {
+			t.Fatal("expected an MFA challenge instead of a session")
+		}
+		return challenge.Challenge
+	}
+
+	challenge := login()
+
+	if _, err := VerifyMFA(db, "unknown", "000000", "", ""); err != ErrInvalidMFAChallenge {
+		t.Errorf("expected ErrInvalidMFAChallenge, got %v", err)
+	}
+
+	if _, err := VerifyMFA(db, challenge, "000000", "", ""); err != ErrInvalidTOTPCode {
+		t.Errorf("expected ErrInvalidTOTPCode, got %v", err)
+	}
+
+	// The code used to confirm the enrollment cannot be replayed
+	var lastStep int64
+	db.QueryRow(`SELECT totp_last_step FROM USER WHERE id = 1`).Scan(&lastStep)
+
+	used, _ := totp.Code(secret, lastStep)
+	if _, err := VerifyMFA(db, challenge, used, "", ""); err != ErrInvalidTOTPCode {
+		t.Errorf("replayed code: expected ErrInvalidTOTPCode, got %v", err)
+	}
+
+	next, _ := totp.Code(secret, lastStep+1)
+	session, err := VerifyMFA(db, challenge, next, "", "")
+	if err != nil {
+		t.Fatalf("VerifyMFA returned error: %v", err)
+	}
+	if session.UserID != 1 || !session.Remember {
+		t.Errorf("unexpected session %+v", session)
+	}
+
+	// Challenges are single-use
+	if _, err := VerifyMFA(db, challenge, next, "", ""); err != ErrInvalidMFAChallenge {
+		t.Errorf("expected ErrInvalidMFAChallenge, got %v", err)
+	}
+
+	// Recovery codes work once, in any case and with or without the dash
+	code := strings.ToUpper(strings.ReplaceAll(recovery[0], "-", ""))
+	if _, err := VerifyMFA(db, login(), code, "", ""); err != nil {
+		t.Errorf("recovery code was refused: %v", err)
+	}
+	if _, err := VerifyMFA(db, login(), recovery[0], "", ""); err != ErrInvalidTOTPCode {
+		t.Errorf("recovery code was accepted twice: %v", err)
+	}
+}
+
+func TestVerifyMFAAttempts(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+
+	_, recovery := enableTOTP(t, db, 1)
+
+	_, challenge, err := Login(db, "john.doe@example.com", "hashed_password_1", false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Clear the failed logins, so only the challenge limits the attempts
+	for i := 0; i < maxMFAAttempts; i++ {
+		VerifyMFA(db, challenge.Challenge, "000000", "", "")
+		db.Exec(`DELETE FROM LOGIN_ATTEMPT`)
+	}
+
+	_, err = VerifyMFA(db, challenge.Challenge, recovery[0], "", "")
+	if err != ErrInvalidMFAChallenge {
+		t.Errorf("expected ErrInvalidMFAChallenge after too many attempts, got %v", err)
+	}
+}
+
+// TestVerifyMFAConcurrent checks that racing logins cannot use a TOTP code
+// twice or guess more than maxMFAAttempts codes.
+func TestVerifyMFAConcurrent(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer db.Close()
+	// Like the server, so the requests interleave statement by statement
+	db.SetMaxOpenConns(1)
+
+	secret, _ := enableTOTP(t, db, 1)
+
+	var lastStep int64
+	db.QueryRow(`SELECT totp_last_step FROM USER WHERE id = 1`).Scan(&lastStep)
+	next, _ := totp.Code(secret, lastStep+1)
+
+	const racers = 4 * maxMFAAttempts
+	results := make(chan error, racers)
+	start := make(chan struct{})
+	for range racers {
+		_, challenge, err := Login(db, "john.doe@example.com", "hashed_password_1", false, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			<-start
+			_, err := VerifyMFA(db, challenge.Challenge, next, "", "")
+			results <- err
+		}()
+	}
+	close(start)
+
+	var accepted int
+	for range racers {
+		if err := <-results; err == nil {
+			accepted++
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("expected the code to be accepted once, got %d", accepted)
+	}
+
+	// Forget the failures so far, which would lock the account
+	db.Exec(`DELETE FROM LOGIN_ATTEMPT`)
+	_, challenge, err := Login(db, "john.doe@example.com", "hashed_password_1", false, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start = make(chan struct{})
+	for range racers {
+		go func() {
+			<-start
+			_, err := VerifyMFA(db, challenge.Challenge, "000000", "", "")
+			results <- err
+		}()
+	}
+	close(start)
+
+	var checked int
+	for range racers {
+		// Others are refused by the challenge or the login backoff
+		if err := <-results; err == ErrInvalidTOTPCode {
+			checked++
+		}
+	}
+	var attempts int
+	db.QueryRow(`SELECT attempts FROM MFA_CHALLENGE WHERE token = ?`, utils.HashToken(challenge.Challenge)).Scan(&attempts)
+	if checked > maxMFAAttempts || attempts != checked {
+		t.Errorf("expected at most %d codes to be checked, got %d with %d attempts counted", maxMFAAttempts, checked, attempts)
+	}
+}
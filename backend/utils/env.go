@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DurationFromEnv parses the environment variable `name` as a duration.
+// Unset or invalid values result in `fallback`.
+func DurationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Invalid %s %q, using %s\n", name, value, fallback)
+		return fallback
+	}
+
+	return duration
+}
+
+// IntFromEnv parses the environment variable `name` as a non-negative integer.
+// Unset or invalid values result in `fallback`.
+func IntFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("Invalid %s %q, using %d\n", name, value, fallback)
+		return fallback
+	}
+
+	return n
+}
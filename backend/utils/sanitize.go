@@ -21,6 +21,10 @@ const DangerousCharsRegex = `[;'"\-#/\*\\]+`
 // sanitizeText applies different sanitization rules depending
 // on the provided inputType. Each case uses a helper function
 // from go-sanitize.
+//
+// Deprecated: input is checked with the validate package and stored as
+// entered. SanitizeText is only kept to check passwords that were hashed
+// after being sanitized.
 func SanitizeText(input string, inputType InputType) string {
 	switch inputType {
 
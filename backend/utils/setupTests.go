@@ -3,18 +3,27 @@ package utils
 import (
 	"database/sql"
 	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	_ "modernc.org/sqlite"
 )
 
+// sqlDir is the directory holding init.sql and populate.sql. It is resolved
+// relative to this file so that tests of any package can find it.
+func sqlDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "sql")
+}
+
 // SetupTest populates an in-memory Sqlite database for testing.
 func SetupTest(t *testing.T) *sql.DB {
 	db, err := sql.Open("sqlite", ":memory:")
 	if err != nil {
 		t.Fatalf("failed to open in-memory database: %v", err)
 	}
-	initSQL, err := os.ReadFile("../../sql/init.sql")
+	initSQL, err := os.ReadFile(filepath.Join(sqlDir(), "init.sql"))
 	if err != nil {
 		t.Fatalf("failed to read init.sql: %v", err)
 	}
@@ -22,7 +31,7 @@ func SetupTest(t *testing.T) *sql.DB {
 	if err != nil {
 		t.Fatalf("failed to execute init.sql: %v", err)
 	}
-	populateSQL, err := os.ReadFile("../../sql/populate.sql")
+	populateSQL, err := os.ReadFile(filepath.Join(sqlDir(), "populate.sql"))
 	if err != nil {
 		t.Fatalf("failed to read populate.sql: %v", err)
 	}
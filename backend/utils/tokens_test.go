@@ -0,0 +1,32 @@
+package utils
+
+import "testing"
+
+func TestGenerateToken(t *testing.T) {
+	token, err := GenerateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(token) != 43 {
+		t.Errorf("expected a 43 character token, got %q", token)
+	}
+
+	other, err := GenerateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token == other {
+		t.Error("generated the same token twice")
+	}
+}
+
+func TestHashToken(t *testing.T) {
+	// Digest of "session-1" as stored in populate.sql
+	want := "84097828fc31a8c8d29210df48901a85de7fd013f686b17be77d1be29cb7a98b"
+
+	if got := HashToken("session-1"); got != want {
+		t.Errorf("HashToken() = %s, want %s", got, want)
+	}
+}
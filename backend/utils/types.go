@@ -5,10 +5,105 @@ import (
 	"time"
 )
 
+// SessionData is returned to the client after a successful login.
+// SessionID holds the raw session token. The same token is also set as an
+// HttpOnly cookie; the JSON copy only exists for clients which still send the
+// deprecated `sessionid` form field.
+// RefreshToken can be exchanged on /refresh for a new pair of tokens once the
+// session expired from inactivity, up until AbsoluteExpires.
 type SessionData struct {
-	SessionID 		int64 		`json:"sessionid"`
+	SessionID 		string 		`json:"sessionid"`
+	RefreshToken	string		`json:"refresh_token"`
 	UserID			int			`json:"userid"`
+	Remember		bool		`json:"remember"`
 	Expires 		time.Time	`json:"expires"`
+	AbsoluteExpires	time.Time	`json:"absolute_expires"`
+}
+
+// MFAChallenge is returned by /login instead of a session when the user has
+// two-factor authentication enabled. The challenge is exchanged on /login/2fa
+// together with a code for a session.
+type MFAChallenge struct {
+	MFARequired		bool		`json:"mfa_required"`
+	Challenge		string		`json:"mfa_challenge"`
+	Expires			time.Time	`json:"expires"`
+}
+
+// TOTPEnrollment holds the secret of a TOTP authenticator being set up,
+// both as is and as an otpauth:// URI.
+type TOTPEnrollment struct {
+	Secret			string		`json:"secret"`
+	URI				string		`json:"uri"`
+}
+
+// APIToken describes a personal access token as shown on /tokens. The token
+// itself is only ever shown once, in NewAPIToken. LastUsed is nil for tokens
+// that were never used.
+type APIToken struct {
+	ID				int			`json:"id"`
+	UserID			int			`json:"userid"`
+	Name			string		`json:"name"`
+	Scopes			[]string	`json:"scopes"`
+	Created			time.Time	`json:"created"`
+	Expires			time.Time	`json:"expires"`
+	LastUsed		*time.Time	`json:"last_used"`
+}
+
+// NewAPIToken is returned once when a personal access token is created.
+// Token is sent as `Authorization: Bearer <token>`.
+type NewAPIToken struct {
+	APIToken
+	Token			string		`json:"token"`
+}
+
+// Invitation describes a pending invitation to an organization or project,
+// as shown to its managers. Kind is "org" or "project" and TargetID the ID of
+// the organization or project. Email is empty for shareable join links,
+// which can be used MaxUses times; invitations by email can be used once.
+type Invitation struct {
+	ID				int			`json:"id"`
+	Kind			string		`json:"kind"`
+	TargetID		int			`json:"targetid"`
+	RoleID			int			`json:"roleid"`
+	Role			string		`json:"role"`
+	Email			string		`json:"email,omitempty"`
+	InviterID		int			`json:"inviterid"`
+	MaxUses			int			`json:"max_uses"`
+	Uses			int			`json:"uses"`
+	Created			time.Time	`json:"created"`
+	Expires			time.Time	`json:"expires"`
+}
+
+// NewJoinLink is returned once when a join link is created. Link is the
+// frontend page accepting Token.
+type NewJoinLink struct {
+	Invitation
+	Token			string		`json:"token"`
+	Link			string		`json:"link"`
+}
+
+// DataExport describes a requested archive of a user's personal data.
+// Status is "pending", "running", "ready", "failed" or "expired". Once
+// ready, the download link is emailed and works until Expires.
+type DataExport struct {
+	ID				int			`json:"id"`
+	Status			string		`json:"status"`
+	Created			time.Time	`json:"created"`
+	Finished		*time.Time	`json:"finished"`
+	Expires			*time.Time	`json:"expires"`
+}
+
+// Session describes a login session of a user as shown on /sessions.
+// Current marks the session the request was made with.
+type Session struct {
+	ID				int			`json:"id"`
+	UserID			int			`json:"userid"`
+	Created			time.Time	`json:"created"`
+	LastSeen		time.Time	`json:"last_seen"`
+	Expires			time.Time	`json:"expires"`
+	IP				string		`json:"ip"`
+	UserAgent		string		`json:"user_agent"`
+	Current			bool		`json:"current"`
 }
 
 // User contains the details of a user along with all projects
@@ -25,6 +120,59 @@ type User struct {
 	Issues			[]int 		`json:"issues"`
 }
 
+// UserSummary is a user as listed in search results. Email is empty where
+// the viewer may not see it.
+type UserSummary struct {
+	ID				int			`json:"id"`
+	Name			string		`json:"name"`
+	Avatar			string		`json:"avatar"`
+	Email			string		`json:"email,omitempty"`
+}
+
+// UserSearch is a search of the user directory. Query matches names and
+// visible emails, by prefix if Prefix is set and anywhere otherwise.
+// Results are restricted to the members of OrgID or ProjectID if set.
+// Cursor is the Next of the previous page, empty for the first one.
+type UserSearch struct {
+	Query			string
+	Prefix			bool
+	OrgID			int
+	ProjectID		int
+	Cursor			string
+	Limit			int
+}
+
+// UserPage is a page of search results. Next is the cursor of the next
+// page, empty on the last one.
+type UserPage struct {
+	Users			[]UserSummary	`json:"users"`
+	Next			string			`json:"next,omitempty"`
+}
+
+// Preferences are the settings of a user, with the defaults filled in for
+// those they did not choose. Notifications lists the channels each event
+// type is notified on. DefaultOrg is nil if there is none.
+type Preferences struct {
+	TimeZone		string				`json:"timezone"`
+	Locale			string				`json:"locale"`
+	DateFormat		string				`json:"date_format"`
+	DefaultOrg		*int				`json:"default_org"`
+	Notifications	map[string][]string	`json:"notifications"`
+	Digest			string				`json:"digest"`
+}
+
+// PreferencesUpdate holds the preferences to change; nil fields and events
+// missing from Notifications are left as they are. A DefaultOrg of zero
+// clears it.
+type PreferencesUpdate struct {
+	TimeZone		*string
+	Locale			*string
+	DateFormat		*string
+	DefaultOrg		*int
+	Notifications	map[string][]string
+	Digest			*string
+}
+
 // Project contains the details of a project.
 type Project struct {
 	ID       int		`json:"id"`
@@ -75,6 +223,53 @@ type Issue struct {
 	Dependencies	[]int			`json:"dependencies"`
 }
 
+// IssueSummary is an issue as listed among the dependencies of another.
+type IssueSummary struct {
+	ID				int				`json:"id"`
+	ProjectID		int				`json:"projectid"`
+	Title			string			`json:"title"`
+	Completed		sql.NullTime	`json:"completed"`
+}
+
+// IssueDependencies lists the issues an issue depends on and the issues
+// that depend on it.
+type IssueDependencies struct {
+	Dependencies	[]IssueSummary	`json:"dependencies"`
+	Dependents		[]IssueSummary	`json:"dependents"`
+}
+
+// GraphIssue is an issue as a node of the dependency graph of a project.
+// Dependencies only lists issues of the same project.
+type GraphIssue struct {
+	ID				int				`json:"id"`
+	Title			string			`json:"title"`
+	Cost			int				`json:"cost"`
+	Color			string			`json:"color"`
+	Completed		bool			`json:"completed"`
+	Dependencies	[]int			`json:"dependencies"`
+}
+
+// GraphFocus holds the issues an issue transitively depends on and the
+// issues transitively depending on it.
+type GraphFocus struct {
+	ID				int				`json:"id"`
+	Blockers		[]int			`json:"blockers"`
+	Dependents		[]int			`json:"dependents"`
+}
+
+// IssueGraph is the dependency graph of a project and what it tells about
+// planning the work: an order to do the issues in, the issues ready to
+// start, and the critical path of the remaining work, weighted by cost.
+type IssueGraph struct {
+	ProjectID		int				`json:"projectid"`
+	Issues			[]GraphIssue	`json:"issues"`
+	Order			[]int			`json:"order"`
+	Ready			[]int			`json:"ready"`
+	CriticalPath	[]int			`json:"critical_path"`
+	CriticalCost	int				`json:"critical_cost"`
+	Issue			*GraphFocus		`json:"issue,omitempty"`
+}
+
 // Tag holds the details for a tag.
 type Tag struct {
 	ID        int    `json:"id"`
@@ -88,6 +283,7 @@ type Tag struct {
 type Organization struct {
 	ID    		int 		`json:"id"`
 	Name  		string		`json:"name"`
+	Require2FA	bool		`json:"require_2fa"`
 	Members 	[]int 		`json:"members"`
 	Projects 	[]int 		`json:"projects"`
 	Roles 		[]int		`json:"roles"`
@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// hexColorRegex matches CSS hex colors like "#f54242" and "#fff".
+var hexColorRegex = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Required rejects empty and whitespace-only values.
+func (v *Validator) Required(field string, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.Fail(field, CodeRequired, "is required")
+	}
+}
+
+// Length rejects values with fewer than `min` or more than `max` characters.
+// Characters are counted as Unicode code points, not bytes.
+func (v *Validator) Length(field string, value string, min int, max int) {
+	n := utf8.RuneCountInString(value)
+	if n < min {
+		v.Fail(field, CodeTooShort, "must be at least %d characters", min)
+	} else if n > max {
+		v.Fail(field, CodeTooLong, "must be at most %d characters", max)
+	}
+}
+
+// Text rejects values that are not valid UTF-8, contain control characters
+// other than newlines and tabs, or are longer than `max` characters.
+// Everything else, punctuation and digits included, is allowed.
+func (v *Validator) Text(field string, value string, max int) {
+	if !utf8.ValidString(value) {
+		v.Fail(field, CodeInvalid, "must be valid UTF-8")
+		return
+	}
+
+	for _, r := range value {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			v.Fail(field, CodeInvalid, "must not contain control characters")
+			return
+		}
+	}
+
+	v.Length(field, value, 0, max)
+}
+
+// Line is like Text for single-line values: it requires a value and rejects
+// line breaks and tabs as well.
+func (v *Validator) Line(field string, value string, max int) {
+	v.Required(field, value)
+	if strings.ContainsAny(value, "\n\r\t") {
+		v.Fail(field, CodeInvalid, "must be a single line")
+	}
+	v.Text(field, value, max)
+}
+
+// Name checks a person's name: a single line of letters in any script,
+// combining marks, spaces and the punctuation found in names (' - . ,).
+func (v *Validator) Name(field string, value string, max int) {
+	v.Line(field, value, max)
+	if v.Failed(field) {
+		return
+	}
+
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsMark(r) && !strings.ContainsRune(" '’-.,", r) {
+			v.Fail(field, CodeInvalid, "may only contain letters, spaces and ' - . ,")
+			return
+		}
+	}
+}
+
+// Email checks the syntax of an email address. Display names ("John
+// <john@example.com>") are not accepted.
+func (v *Validator) Email(field string, value string) {
+	v.Required(field, value)
+	if v.Failed(field) {
+		return
+	}
+
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value || addr.Name != "" ||
+		!strings.Contains(value[strings.LastIndex(value, "@")+1:], ".") {
+		v.Fail(field, CodeInvalid, "must be a valid email address")
+		return
+	}
+
+	v.Length(field, value, 3, 254)
+}
+
+// HexColor checks a CSS hex color such as "#f54242".
+func (v *Validator) HexColor(field string, value string) {
+	v.Required(field, value)
+	if !hexColorRegex.MatchString(value) {
+		v.Fail(field, CodeInvalid, "must be a hex color like #f54242")
+	}
+}
+
+// MaxBytes rejects values longer than `max` bytes, for limits that count
+// bytes rather than characters.
+func (v *Validator) MaxBytes(field string, value string, max int) {
+	if len(value) > max {
+		v.Fail(field, CodeTooLong, "must be at most %d bytes", max)
+	}
+}
+
+// NormalizeEmail returns the form emails are stored and looked up in:
+// trimmed and lowercased.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Limits shared by the fields of the domain packages.
+const (
+	// MaxNameLength limits names of people, organizations, projects and tags.
+	MaxNameLength = 100
+
+	// MaxTitleLength limits issue titles.
+	MaxTitleLength = 200
+
+	// MaxTextLength limits descriptions and charters.
+	MaxTextLength = 10000
+
+	// MaxPasswordBytes is the most bcrypt can hash.
+	MaxPasswordBytes = 72
+)
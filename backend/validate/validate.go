@@ -0,0 +1,88 @@
+// Package validate checks user input field by field. Input that does not
+// pass is rejected with an error per field instead of being altered, so
+// whatever passes can be stored exactly as it was entered.
+//
+// Checks are collected on a Validator:
+//
+//	v := validate.New()
+//	v.Name("name", user.Name, 100)
+//	v.Email("email", user.Email)
+//	if err := v.Err(); err != nil {
+//		return err
+//	}
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes why the value of a single field was rejected.
+// Code is a stable identifier for clients, Message is meant for humans.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is the list of rejected fields. It is returned as an error by
+// Validator.Err and by the domain functions that validate their input.
+type Errors []FieldError
+
+// Error lists the rejected fields and why.
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, err := range e {
+		parts[i] = err.Field + ": " + err.Message
+	}
+	return "invalid input: " + strings.Join(parts, "; ")
+}
+
+// Error codes of FieldError.
+const (
+	CodeRequired = "required"
+	CodeTooShort = "too_short"
+	CodeTooLong  = "too_long"
+	CodeInvalid  = "invalid"
+)
+
+// Validator collects the errors of a set of fields. Each field is only
+// reported once, for the first check it fails.
+type Validator struct {
+	errs Errors
+}
+
+// New returns an empty Validator.
+func New() *Validator {
+	return &Validator{}
+}
+
+// Fail rejects the field, unless it was already rejected.
+func (v *Validator) Fail(field string, code string, format string, args ...any) {
+	if v.Failed(field) {
+		return
+	}
+	v.errs = append(v.errs, FieldError{
+		Field:   field,
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Failed reports whether the field was rejected.
+func (v *Validator) Failed(field string) bool {
+	for _, err := range v.errs {
+		if err.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the collected errors as Errors, or nil if every field passed.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
@@ -0,0 +1,92 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// code runs check on a fresh Validator and returns the code of the error it
+// reported for "f", or "" if it passed.
+func code(check func(v *Validator)) string {
+	v := New()
+	check(v)
+	var errs Errors
+	if !errors.As(v.Err(), &errs) {
+		return ""
+	}
+	return errs[0].Code
+}
+
+func TestChecks(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(v *Validator)
+		want  string
+	}{
+		{"title with punctuation", func(v *Validator) { v.Line("f", "Fix bug #42 in v2.0", MaxTitleLength) }, ""},
+		{"empty line", func(v *Validator) { v.Line("f", "  ", MaxTitleLength) }, CodeRequired},
+		{"multi-line line", func(v *Validator) { v.Line("f", "a\nb", MaxTitleLength) }, CodeInvalid},
+		{"long line", func(v *Validator) { v.Line("f", strings.Repeat("é", 201), MaxTitleLength) }, CodeTooLong},
+		{"multi-line text", func(v *Validator) { v.Text("f", "line 1\r\n\tline 2", MaxTextLength) }, ""},
+		{"empty text", func(v *Validator) { v.Text("f", "", MaxTextLength) }, ""},
+		{"control character", func(v *Validator) { v.Text("f", "bell\a", MaxTextLength) }, CodeInvalid},
+		{"invalid UTF-8", func(v *Validator) { v.Text("f", "\xff", MaxTextLength) }, CodeInvalid},
+		{"name", func(v *Validator) { v.Name("f", "Renée O'Brien-Smith", MaxNameLength) }, ""},
+		{"non-latin name", func(v *Validator) { v.Name("f", "José Ñúñez 山田太郎", MaxNameLength) }, ""},
+		{"name with digits", func(v *Validator) { v.Name("f", "Ivan123", MaxNameLength) }, CodeInvalid},
+		{"name with markup", func(v *Validator) { v.Name("f", "<b>Ivan</b>", MaxNameLength) }, CodeInvalid},
+		{"email", func(v *Validator) { v.Email("f", "john.doe+work@example.com") }, ""},
+		{"empty email", func(v *Validator) { v.Email("f", "") }, CodeRequired},
+		{"email without domain", func(v *Validator) { v.Email("f", "john@localhost") }, CodeInvalid},
+		{"email with display name", func(v *Validator) { v.Email("f", "John <john@example.com>") }, CodeInvalid},
+		{"email without at", func(v *Validator) { v.Email("f", "john.example.com") }, CodeInvalid},
+		{"long hex color", func(v *Validator) { v.HexColor("f", "#F54242") }, ""},
+		{"short hex color", func(v *Validator) { v.HexColor("f", "#fff") }, ""},
+		{"named color", func(v *Validator) { v.HexColor("f", "red") }, CodeInvalid},
+		{"missing color", func(v *Validator) { v.HexColor("f", "") }, CodeRequired},
+		{"bytes", func(v *Validator) { v.MaxBytes("f", strings.Repeat("a", 72), MaxPasswordBytes) }, ""},
+		{"too many bytes", func(v *Validator) { v.MaxBytes("f", strings.Repeat("é", 37), MaxPasswordBytes) }, CodeTooLong},
+		{"too short", func(v *Validator) { v.Length("f", "ab", 3, 10) }, CodeTooShort},
+	}
+	for _, tt := range tests {
+		if got := code(tt.check); got != tt.want {
+			t.Errorf("%s: expected code %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestValidatorErrors(t *testing.T) {
+	v := New()
+	v.Line("title", "", MaxTitleLength)
+	v.HexColor("color", "blue")
+	v.Text("desc", "ok", MaxTextLength)
+	err := v.Err()
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected one error per failing field, got %v", errs)
+	}
+	if errs[0].Field != "title" || errs[0].Code != CodeRequired {
+		t.Errorf("expected title to be required, got %+v", errs[0])
+	}
+	if errs[1].Field != "color" || errs[1].Code != CodeInvalid {
+		t.Errorf("expected color to be invalid, got %+v", errs[1])
+	}
+	if err.Error() != "invalid input: title: is required; color: must be a hex color like #f54242" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+
+	if err := New().Err(); err != nil {
+		t.Errorf("expected nil error without failures, got %v", err)
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	if got := NormalizeEmail("  John.Doe@Example.COM "); got != "john.doe@example.com" {
+		t.Errorf("expected normalized email, got %q", got)
+	}
+}
@@ -4,6 +4,7 @@ go 1.23.4
 
 require (
 	github.com/mrz1836/go-sanitize v1.3.3
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/crypto v0.36.0
 	gopkg.in/gomail.v2 v2.0.0-20160411212932-81ebce5c23df
@@ -2,18 +2,132 @@ package main
 
 import (
 	"brickedup/backend"
+	"brickedup/backend/exports"
+	"brickedup/backend/users"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// every runs fn, named `name` in the logs, right away and then once per
+// interval, each time with its own database connection. Errors are logged
+// and the next run goes ahead as planned.
+func every(interval time.Duration, name string, fn func(*sql.DB) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		db, err := sql.Open("sqlite", os.Getenv("DB"))
+		if err == nil {
+			db.SetMaxOpenConns(1)
+			err = fn(db)
+			db.Close()
+		}
+
+		if err != nil {
+			log.Printf("Failed to %s: %v\n", name, err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// purgeUnverifiedUsers deletes accounts that were never verified, according
+// to users.UnverifiedRetentionDays.
+func purgeUnverifiedUsers(db *sql.DB) error {
+	n, err := users.PurgeUnverifiedUsers(db, users.UnverifiedRetentionDays)
+	if n > 0 {
+		log.Printf("Purged %d unverified accounts\n", n)
+	}
+	return err
+}
+
+// anonymizeDeactivatedUsers erases the personal data of accounts whose
+// grace period after deactivation is over, according to
+// users.DeactivationGraceDays.
+func anonymizeDeactivatedUsers(db *sql.DB) error {
+	n, err := users.PurgeDeactivatedUsers(db, users.DeactivationGraceDays)
+	if n > 0 {
+		log.Printf("Anonymized %d deactivated accounts\n", n)
+	}
+	return err
+}
+
+// runDataExports builds the requested data exports and expires the ones
+// whose download links ran out.
+func runDataExports(db *sql.DB) error {
+	n, err := exports.RunPending(db)
+	if n > 0 {
+		log.Printf("Built %d data exports\n", n)
+	}
+	return err
+}
+
+// exportUser writes everything stored about a user to a file, as a ZIP
+// archive or, for files ending in .json, just the data. It implements
+// `brickedup export-user <userid> [file]` for handling requests made
+// outside the application.
+func exportUser(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("usage: brickedup export-user <userid> [file]")
+	}
+
+	userid, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid user id %q", args[0])
+	}
+
+	file := fmt.Sprintf("user-%d-export.zip", userid)
+	if len(args) == 2 {
+		file = args[1]
+	}
+
+	db, err := sql.Open("sqlite", os.Getenv("DB"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	var data []byte
+	if strings.HasSuffix(file, ".json") {
+		data, err = exports.BuildJSON(db, userid)
+	} else {
+		data, err = exports.Build(db, userid)
+	}
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user %d not found", userid)
+	} else if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(file, data, 0600); err != nil {
+		return err
+	}
+
+	fmt.Println("Exported user", userid, "to", file)
+	return nil
+}
+
 // Main sets up the server and starts listening on the defined PORT.
 // It registers MainHandler to process all incoming HTTP requests.
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "export-user" {
+		if err := exportUser(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Persistent log file
 	logFilePath := os.Getenv("LOGS")
 	if logFilePath != "" {
@@ -27,6 +141,10 @@ func main() {
 		log.SetOutput(logFile)
 	}
 
+	go every(time.Hour, "purge unverified accounts", purgeUnverifiedUsers)
+	go every(time.Hour, "anonymize deactivated accounts", anonymizeDeactivatedUsers)
+	go every(30*time.Second, "run data exports", runDataExports)
+
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 
 		origin := r.Header.Get("Origin")
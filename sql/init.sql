@@ -2,13 +2,16 @@ PRAGMA foreign_keys = ON;
 
 CREATE TABLE ORGANIZATION (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    name TEXT UNIQUE NOT NULL
+    name TEXT UNIQUE NOT NULL,
+    require_2fa BOOLEAN NOT NULL DEFAULT 0 -- members must enable two-factor authentication
 );
 
 CREATE TABLE VERIFY_USER (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     code INTEGER UNIQUE NOT NULL,
-    expires DATE NOT NULL
+    expires DATE NOT NULL,
+    password TEXT, -- hash from the signup that sent the code, set once it is used
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP -- when the code was last sent
 );
 
 -- Tables that depend only on tables already created
@@ -20,16 +23,152 @@ CREATE TABLE USER (
     name TEXT NOT NULL,
     avatar TEXT,
     verified BOOLEAN NOT NULL DEFAULT 0,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    legacy_password BOOLEAN NOT NULL DEFAULT 0, -- hashed after stripping some characters, see sql/migrate_verbatim_input.sql
+    totp_secret TEXT, -- base32 TOTP secret, set while enrolling and once enabled
+    totp_enabled BOOLEAN NOT NULL DEFAULT 0,
+    totp_last_step INTEGER NOT NULL DEFAULT 0, -- last accepted time step, against replays
+    deactivated TIMESTAMP, -- when the user deactivated the account, NULL while active
+    anonymized BOOLEAN NOT NULL DEFAULT 0, -- personal data was erased after the grace period
     FOREIGN KEY (verifyid) REFERENCES VERIFY_USER(id) ON DELETE SET NULL
 );
 
 CREATE TABLE SESSION (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     userid INTEGER NOT NULL,
+    token TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the session token
+    expires TIMESTAMP NOT NULL, -- slides forward on activity (idle timeout)
+    absolute_expires TIMESTAMP NOT NULL, -- hard limit, never extended
+    remember BOOLEAN NOT NULL DEFAULT 0,
+    created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
+);
+
+CREATE TABLE REFRESH_TOKEN (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    sessionid INTEGER NOT NULL,
+    token TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the refresh token
+    used BOOLEAN NOT NULL DEFAULT 0,
+    FOREIGN KEY (sessionid) REFERENCES SESSION(id) ON DELETE CASCADE
+);
+
+CREATE TABLE LOGIN_ATTEMPT (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL, -- as entered, lowercased
+    userid INTEGER, -- NULL for unknown emails
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    reason TEXT NOT NULL DEFAULT '', -- why the attempt failed
+    method TEXT NOT NULL DEFAULT 'password', -- password, oidc or magic_link
+    created TIMESTAMP NOT NULL
+);
+
+CREATE INDEX LOGIN_ATTEMPT_EMAIL ON LOGIN_ATTEMPT (email, created);
+CREATE INDEX LOGIN_ATTEMPT_IP ON LOGIN_ATTEMPT (ip, created);
+
+CREATE TABLE RECOVERY_CODE (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER NOT NULL,
+    code TEXT NOT NULL, -- SHA-256 hex digest of the recovery code
+    used BOOLEAN NOT NULL DEFAULT 0,
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
+);
+
+CREATE TABLE MFA_CHALLENGE (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER NOT NULL,
+    token TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the challenge
+    remember BOOLEAN NOT NULL DEFAULT 0,
+    method TEXT NOT NULL DEFAULT 'password', -- how the first factor was passed
+    expires TIMESTAMP NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
+);
+
+CREATE TABLE MAGIC_LINK (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER NOT NULL,
+    token TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the token in the link
+    browser TEXT NOT NULL, -- SHA-256 hex digest of the requesting browser's secret
+    remember BOOLEAN NOT NULL DEFAULT 0,
+    ip TEXT NOT NULL DEFAULT '', -- of the request for the link
+    user_agent TEXT NOT NULL DEFAULT '',
+    created TIMESTAMP NOT NULL,
+    expires TIMESTAMP NOT NULL,
+    used TIMESTAMP, -- set once the link logged the user in
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
+);
+
+CREATE TABLE EMAIL_CHANGE (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER UNIQUE NOT NULL, -- one pending change per user
+    email TEXT NOT NULL, -- the new address, replacing USER.email once confirmed
+    token TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the token in the confirmation link
+    created TIMESTAMP NOT NULL,
     expires TIMESTAMP NOT NULL,
     FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
 );
 
+CREATE TABLE REACTIVATION (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER UNIQUE NOT NULL, -- one link per deactivated user
+    token TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the token in the reactivation link
+    created TIMESTAMP NOT NULL, -- when the link was last sent
+    expires TIMESTAMP NOT NULL, -- end of the grace period
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
+);
+
+CREATE TABLE DATA_EXPORT (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending', -- 'pending', 'running', 'ready', 'failed' or 'expired'
+    token TEXT UNIQUE, -- SHA-256 hex digest of the token in the download link, once ready
+    blob TEXT, -- key of the archive in the blob store, once ready
+    created TIMESTAMP NOT NULL,
+    started TIMESTAMP, -- when the archive began to be built, to notice abandoned exports
+    finished TIMESTAMP,
+    expires TIMESTAMP, -- of the download link
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
+);
+
+CREATE TABLE API_TOKEN (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    token TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the token
+    scopes TEXT NOT NULL, -- space-separated, e.g. "issues:read issues:write"
+    created TIMESTAMP NOT NULL,
+    expires TIMESTAMP NOT NULL,
+    last_used TIMESTAMP,
+    UNIQUE (userid, name),
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
+);
+
+CREATE TABLE USER_IDENTITY (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER NOT NULL,
+    provider TEXT NOT NULL, -- name of the OIDC provider
+    subject TEXT NOT NULL, -- "sub" claim, stable per provider
+    email TEXT NOT NULL, -- email at the provider when the identity was linked
+    created TIMESTAMP NOT NULL,
+    UNIQUE (provider, subject),
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
+);
+
+CREATE TABLE OIDC_STATE (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    state TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the state parameter
+    provider TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    verifier TEXT NOT NULL, -- PKCE code verifier
+    remember BOOLEAN NOT NULL DEFAULT 0,
+    expires TIMESTAMP NOT NULL
+);
+
 CREATE TABLE ORG_ROLE (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     orgid INTEGER NOT NULL,
@@ -140,6 +279,43 @@ CREATE TABLE PROJECT_MEMBER_ROLE (
     FOREIGN KEY (roleid) REFERENCES PROJECT_ROLE(id) ON DELETE CASCADE
 );
 
+CREATE TABLE INVITATION (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL, -- 'org' or 'project'
+    targetid INTEGER NOT NULL, -- ORGANIZATION(id) or PROJECT(id), depending on kind
+    roleid INTEGER NOT NULL, -- ORG_ROLE(id) or PROJECT_ROLE(id), given on acceptance
+    email TEXT, -- invitee, NULL for shareable join links
+    token TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the token in the link
+    inviter INTEGER NOT NULL,
+    max_uses INTEGER NOT NULL DEFAULT 1,
+    uses INTEGER NOT NULL DEFAULT 0,
+    created TIMESTAMP NOT NULL,
+    expires TIMESTAMP NOT NULL,
+    sent TIMESTAMP, -- when the email was last sent
+    FOREIGN KEY (inviter) REFERENCES USER(id) ON DELETE CASCADE
+);
+
+CREATE TABLE USER_PREFERENCES (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER UNIQUE NOT NULL, -- users without a row have the defaults
+    timezone TEXT NOT NULL, -- IANA name, e.g. "Europe/Berlin"
+    locale TEXT NOT NULL, -- BCP 47 tag, e.g. "en-US"
+    date_format TEXT NOT NULL, -- 'long', 'iso', 'mdy' or 'dmy'
+    default_org INTEGER, -- organization shown after logging in
+    digest TEXT NOT NULL, -- email digest frequency: 'never', 'daily' or 'weekly'
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE,
+    FOREIGN KEY (default_org) REFERENCES ORGANIZATION(id) ON DELETE SET NULL
+);
+
+CREATE TABLE NOTIFICATION_SETTING (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    userid INTEGER NOT NULL,
+    event TEXT NOT NULL, -- e.g. 'issue_assigned'; events without a row use the defaults
+    channels TEXT NOT NULL, -- space-separated, e.g. "email in_app", empty for none
+    UNIQUE (userid, event),
+    FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
+);
+
 CREATE TABLE PROJECT_ISSUES (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     projectid INTEGER NOT NULL,
@@ -160,7 +336,7 @@ CREATE TABLE USER_ISSUES (
 CREATE TABLE FORGOT_PASSWORD (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     userid INTEGER NOT NULL,
-    code INTEGER NOT NULL,
+    code TEXT UNIQUE NOT NULL, -- SHA-256 hex digest of the reset token
     expirationdate TIMESTAMP NOT NULL,
     FOREIGN KEY (userid) REFERENCES USER(id) ON DELETE CASCADE
 );
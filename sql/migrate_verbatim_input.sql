@@ -0,0 +1,18 @@
+-- Migrates a database created before user input was stored verbatim.
+-- Run it once on such a database:
+--
+--     sqlite3 bricked-up_prod.db < sql/migrate_verbatim_input.sql
+--
+-- Text that was stripped on the way in cannot be recovered and stays as it
+-- is. Emails were already stored trimmed and lowercased, and tag colors with
+-- a leading '#', so they need no changes.
+
+BEGIN TRANSACTION;
+
+-- Passwords used to be stripped of ; ' " - # / * \ before they were hashed.
+-- Accounts marked here can still log in with the password they typed, which
+-- is then rehashed as entered and the mark removed.
+ALTER TABLE USER ADD COLUMN legacy_password BOOLEAN NOT NULL DEFAULT 0;
+UPDATE USER SET legacy_password = 1;
+
+COMMIT;
@@ -19,12 +19,13 @@ INSERT INTO USER (verifyid, email, password, name, avatar, verified) VALUES
 (2, 'alex.brown@example.com', '$2a$10$vsQ0I0vp7bINyqe77WaqcOlB2vUXgZaC4JhNr1.6sb36N8xekHuqO', 'Alex Brown', 'avatar5.png', 0);
 
 -- Populate SESSION table
-INSERT INTO SESSION (userid, expires) VALUES
-(1, '2025-03-10 09:30:00'),
-(2, '2025-03-10 10:15:00'),
-(3, '2025-03-10 14:22:00'),
-(1, '2025-03-11 08:45:00'),
-(4, '2025-03-11 11:10:00');
+-- The tokens are the SHA-256 digests of 'session-1' through 'session-5'.
+INSERT INTO SESSION (userid, token, expires, absolute_expires) VALUES
+(1, '84097828fc31a8c8d29210df48901a85de7fd013f686b17be77d1be29cb7a98b', '3025-03-10 09:30:00', '3025-03-10 09:30:00'),
+(2, '5d9061408048c12d053925aed45333a142997f26a2cd1e0c4a87678c53a1e3ae', '3025-03-10 10:15:00', '3025-03-10 10:15:00'),
+(3, 'eb278475f606714397df8cb657e1c7ee252f4c85e62e2cb65c5fea66b2ec4fb0', '3025-03-10 14:22:00', '3025-03-10 14:22:00'),
+(1, 'e1cdfcfb8292183130a3c977a5fd646fc16a3dd1e1d9d90d99f555b736b3260e', '3025-03-11 08:45:00', '3025-03-11 08:45:00'),
+(4, 'd7b2fab495bd092aed57a1cc49972141a4149ff3f7e7b936a153d4d80e069545', '3025-03-11 11:10:00', '3025-03-11 11:10:00');
 
 -- Populate ORG_ROLE table
 INSERT INTO ORG_ROLE (orgid, name, can_read, can_write, can_exec) VALUES
@@ -163,8 +164,9 @@ INSERT INTO USER_ISSUES (userid, issueid) VALUES
 (3, 5);
 
 -- Populate FORGOT_PASSWORD table
+-- The codes are the SHA-256 digests of 'reset-1' through 'reset-4'.
 INSERT INTO FORGOT_PASSWORD (userid, code, expirationdate) VALUES
-(1, 123456, '2025-03-12'),
-(2, 234567, '2025-03-13'),
-(4, 345678, '2025-03-14'),
-(5, 456789, '2025-03-15');
+(1, '24ffdd4b62216e2c93a49532e76c54b06bb9444e41af5f8f1eec3d21918ba465', '2025-03-12'),
+(2, 'ea92d21e6d2558ab1e82016ab473906bf621818aa9903fba82753a90d36ec740', '3025-03-13'),
+(4, '3cdc5e55088168a874db2ed6854e39a942b2f68ad8915a32bb3fb5b36adaadb1', '3025-03-14'),
+(5, '6fd7174933c39d6d1f26d003561ae09f72837e1be718d76b0edecc6d0becef67', '3025-03-15');